@@ -0,0 +1,106 @@
+// Package graph serves the todo domain as a GraphQL schema: todos with their
+// parent, children and project, and a subscription to todo changes.
+package graph
+
+import (
+	"context"
+	"errors"
+
+	"todo_project/service"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+// Executor runs GraphQL operations against the todo schema.
+type Executor struct {
+	schema      graphql.Schema
+	todoService service.TodoService
+}
+
+func NewExecutor(todoService service.TodoService, events service.TodoEventSource) (*Executor, error) {
+	schema, err := newSchema(todoService, events)
+	if err != nil {
+		return nil, err
+	}
+	return &Executor{schema: schema, todoService: todoService}, nil
+}
+
+// Operation is a parsed and validated operation, ready to run.
+type Operation struct {
+	doc        *ast.Document
+	definition *ast.OperationDefinition
+	name       string
+	variables  map[string]interface{}
+}
+
+// IsSubscription tells whether the operation is a subscription, whose
+// results arrive over time.
+func (o *Operation) IsSubscription() bool {
+	return o.definition.Operation == ast.OperationTypeSubscription
+}
+
+// Prepare parses and validates query and checks it against the depth and
+// complexity limits.
+func (e *Executor) Prepare(query string, operationName string, variables map[string]interface{}) (*Operation, []gqlerrors.FormattedError) {
+	doc, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{
+		Body: []byte(query),
+		Name: "GraphQL request",
+	})})
+	if err != nil {
+		return nil, gqlerrors.FormatErrors(err)
+	}
+	if result := graphql.ValidateDocument(&e.schema, doc, nil); !result.IsValid {
+		return nil, result.Errors
+	}
+
+	var definition *ast.OperationDefinition
+	for _, node := range doc.Definitions {
+		candidate, ok := node.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if operationName == "" && definition != nil {
+			return nil, gqlerrors.FormatErrors(errors.New("operationName is required when the query holds several operations"))
+		}
+		if operationName == "" || (candidate.Name != nil && candidate.Name.Value == operationName) {
+			definition = candidate
+		}
+	}
+	if definition == nil {
+		return nil, gqlerrors.FormatErrors(errors.New("unknown operation " + operationName))
+	}
+	if err := checkLimits(doc, definition, variables); err != nil {
+		return nil, gqlerrors.FormatErrors(err)
+	}
+	return &Operation{doc: doc, definition: definition, name: operationName, variables: variables}, nil
+}
+
+// Execute runs a query operation.
+func (e *Executor) Execute(ctx context.Context, op *Operation) *graphql.Result {
+	ctx = context.WithValue(ctx, loadersKey{}, newLoaders(e.todoService, true))
+	return graphql.Execute(graphql.ExecuteParams{
+		Schema:        e.schema,
+		AST:           op.doc,
+		OperationName: op.name,
+		Args:          op.variables,
+		Context:       ctx,
+	})
+}
+
+// Subscribe runs a subscription operation. The channel yields one result
+// per event and is closed once ctx is done or the event stream ends; it must
+// be drained until then.
+func (e *Executor) Subscribe(ctx context.Context, op *Operation) chan *graphql.Result {
+	ctx = context.WithValue(ctx, loadersKey{}, newLoaders(e.todoService, false))
+	return graphql.ExecuteSubscription(graphql.ExecuteParams{
+		Schema:        e.schema,
+		AST:           op.doc,
+		OperationName: op.name,
+		Args:          op.variables,
+		Context:       ctx,
+	})
+}
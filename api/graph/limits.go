@@ -0,0 +1,125 @@
+package graph
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+var (
+	// MaxDepth caps how deeply the fields of a query may be nested.
+	MaxDepth = 8
+	// MaxComplexity caps the estimated cost of a query: every field costs 1,
+	// and the fields selected below a list count once per item it may hold.
+	MaxComplexity = 1000
+)
+
+const (
+	defaultFirst = 20
+	maxFirst     = 100
+)
+
+// listFields are the fields returning lists, paged with a first argument.
+var listFields = map[string]bool{
+	"todos":    true,
+	"children": true,
+}
+
+// checkLimits rejects operations nested deeper than MaxDepth or costing more
+// than MaxComplexity. Introspection fields are not counted.
+func checkLimits(doc *ast.Document, operation *ast.OperationDefinition, variables map[string]interface{}) error {
+	fragments := map[string]*ast.FragmentDefinition{}
+	for _, definition := range doc.Definitions {
+		if fragment, ok := definition.(*ast.FragmentDefinition); ok {
+			fragments[fragment.Name.Value] = fragment
+		}
+	}
+	limits := &limitWalker{fragments: fragments, variables: variables}
+	complexity := limits.cost(operation.SelectionSet, 1)
+	if limits.depth > MaxDepth {
+		return fmt.Errorf("query is nested %d levels deep, the limit is %d", limits.depth, MaxDepth)
+	}
+	if complexity > MaxComplexity {
+		return fmt.Errorf("query complexity is %d, the limit is %d", complexity, MaxComplexity)
+	}
+	return nil
+}
+
+type limitWalker struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+	depth     int
+}
+
+func (w *limitWalker) cost(set *ast.SelectionSet, depth int) int {
+	if set == nil {
+		return 0
+	}
+	total := 0
+	for _, selection := range set.Selections {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(selection.Name.Value, "__") {
+				continue
+			}
+			if depth > w.depth {
+				w.depth = depth
+			}
+			below := w.cost(selection.SelectionSet, depth+1)
+			if listFields[selection.Name.Value] {
+				below *= w.first(selection)
+			}
+			total += 1 + below
+		case *ast.InlineFragment:
+			total += w.cost(selection.SelectionSet, depth)
+		case *ast.FragmentSpread:
+			if fragment, ok := w.fragments[selection.Name.Value]; ok {
+				total += w.cost(fragment.SelectionSet, depth)
+			}
+		}
+	}
+	return total
+}
+
+// first returns how many items a list field may return.
+func (w *limitWalker) first(field *ast.Field) int {
+	for _, argument := range field.Arguments {
+		if argument.Name.Value != "first" {
+			continue
+		}
+		var value interface{}
+		switch v := argument.Value.(type) {
+		case *ast.IntValue:
+			value = v.Value
+		case *ast.Variable:
+			value = w.variables[v.Name.Value]
+		}
+		return pageSize(value)
+	}
+	return defaultFirst
+}
+
+// pageSize reads a first argument, defaulting to defaultFirst and capping
+// at maxFirst.
+func pageSize(value interface{}) int {
+	n := defaultFirst
+	switch v := value.(type) {
+	case int:
+		n = v
+	case float64:
+		n = int(v)
+	case string:
+		if parsed, err := strconv.Atoi(v); err == nil {
+			n = parsed
+		}
+	}
+	if n < 0 {
+		n = 0
+	}
+	if n > maxFirst {
+		n = maxFirst
+	}
+	return n
+}
@@ -0,0 +1,118 @@
+package graph
+
+import (
+	"context"
+	"strconv"
+
+	"todo_project/common/filter"
+	"todo_project/model"
+	"todo_project/service"
+
+	"github.com/graph-gophers/dataloader/v7"
+)
+
+// loaders batch the todo lookups of one request, so resolving the parent or
+// children of a whole page of todos costs one query instead of one per todo.
+// Subscriptions run many executions over time and use them uncached.
+type loaders struct {
+	todos    *dataloader.Loader[int, *model.Todo]
+	children *dataloader.Loader[groupKey, []*model.Todo]
+	projects *dataloader.Loader[groupKey, []*model.Todo]
+}
+
+// groupKey asks for one page of the todos of a group.
+type groupKey struct {
+	ID     int
+	First  int
+	Offset int
+}
+
+type loadersKey struct{}
+
+// batchCapacity caps the IDs looked up by a single query.
+const batchCapacity = 100
+
+func newLoaders(todoService service.TodoService, cached bool) *loaders {
+	return &loaders{
+		todos: dataloader.NewBatchedLoader(func(ctx context.Context, ids []int) []*dataloader.Result[*model.Todo] {
+			todos, err := todoService.ListTodos(model.TodoQuery{Filter: anyOf("id", ids)})
+			results := make([]*dataloader.Result[*model.Todo], len(ids))
+			byID := make(map[int]*model.Todo, len(todos))
+			for _, todo := range todos {
+				byID[todo.ID] = todo
+			}
+			for i, id := range ids {
+				results[i] = &dataloader.Result[*model.Todo]{Data: byID[id], Error: err}
+			}
+			return results
+		}, loaderOptions[int, *model.Todo](cached)...),
+		children: newGroupLoader(todoService, cached, "parent", func(todo *model.Todo) *int { return todo.ParentID }),
+		projects: newGroupLoader(todoService, cached, "project", func(todo *model.Todo) *int { return todo.ProjectID }),
+	}
+}
+
+func loaderOptions[K comparable, V any](cached bool) []dataloader.Option[K, V] {
+	options := []dataloader.Option[K, V]{dataloader.WithBatchCapacity[K, V](batchCapacity)}
+	if !cached {
+		options = append(options, dataloader.WithCache[K, V](&dataloader.NoCache[K, V]{}))
+	}
+	return options
+}
+
+// newGroupLoader loads a page of the todos whose field, read by key, is one
+// of the requested IDs, in list order. Groups are paged by the database, so
+// large ones are never read whole; groups asking for the same page share a
+// query.
+func newGroupLoader(todoService service.TodoService, cached bool, field string, key func(*model.Todo) *int) *dataloader.Loader[groupKey, []*model.Todo] {
+	return dataloader.NewBatchedLoader(func(ctx context.Context, keys []groupKey) []*dataloader.Result[[]*model.Todo] {
+		pages := map[groupKey][]int{}
+		for _, k := range keys {
+			window := groupKey{First: k.First, Offset: k.Offset}
+			pages[window] = append(pages[window], k.ID)
+		}
+		groups := make(map[groupKey][]*model.Todo, len(keys))
+		errs := map[groupKey]error{}
+		for window, ids := range pages {
+			if window.First == 0 {
+				continue
+			}
+			todos, err := todoService.ListTodos(model.TodoQuery{
+				Filter:  anyOf(field, ids),
+				Sort:    []filter.SortKey{{Field: "position"}},
+				GroupBy: field,
+				Limit:   window.First,
+				Offset:  window.Offset,
+			})
+			errs[window] = err
+			for _, todo := range todos {
+				if id := key(todo); id != nil {
+					k := groupKey{ID: *id, First: window.First, Offset: window.Offset}
+					groups[k] = append(groups[k], todo)
+				}
+			}
+		}
+		results := make([]*dataloader.Result[[]*model.Todo], len(keys))
+		for i, k := range keys {
+			results[i] = &dataloader.Result[[]*model.Todo]{Data: groups[k], Error: errs[groupKey{First: k.First, Offset: k.Offset}]}
+		}
+		return results
+	}, loaderOptions[groupKey, []*model.Todo](cached)...)
+}
+
+// anyOf matches todos whose field equals one of ids.
+func anyOf(field string, ids []int) filter.Expr {
+	var expr filter.Expr
+	for _, id := range ids {
+		term := filter.Comparison{Field: field, Op: filter.OpEqual, Value: strconv.Itoa(id)}
+		if expr == nil {
+			expr = term
+		} else {
+			expr = filter.Or{Left: expr, Right: term}
+		}
+	}
+	return expr
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
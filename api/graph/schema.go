@@ -0,0 +1,354 @@
+package graph
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"todo_project/common/eventbus"
+	"todo_project/common/filter"
+	"todo_project/common/log"
+	"todo_project/model"
+	"todo_project/service"
+
+	"github.com/graphql-go/graphql"
+)
+
+// errNoSubscriptions is returned when there is no event stream to subscribe to.
+var errNoSubscriptions = errors.New("subscriptions are not available")
+
+// project is a project as seen by the schema. Projects are only known by
+// the ID their todos carry.
+type project struct {
+	ID int
+}
+
+// todoEvent is a todo change delivered to subscriptions.
+type todoEvent struct {
+	ID            string
+	EventID       string
+	Type          string
+	Actor         string
+	OccurredAt    time.Time
+	Todo          *model.Todo
+	ChangedFields []string
+}
+
+func newSchema(todoService service.TodoService, events service.TodoEventSource) (graphql.Schema, error) {
+	var todoType *graphql.Object
+	projectType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Project",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id": &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(*project).ID, nil
+				}},
+				"todos": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(todoType))),
+					Args: pageArgs(),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						thunk := loadersFrom(p.Context).projects.Load(p.Context, pageOf(p.Source.(*project).ID, p.Args))
+						return func() (interface{}, error) {
+							todos, err := thunk()
+							if err != nil {
+								return nil, internalError(err, "Failed to get todos")
+							}
+							return todos, nil
+						}, nil
+					},
+				},
+			}
+		}),
+	})
+
+	todoType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Todo",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":          todoField(graphql.NewNonNull(graphql.Int), func(t *model.Todo) interface{} { return t.ID }),
+				"name":        todoField(graphql.NewNonNull(graphql.String), func(t *model.Todo) interface{} { return t.Name }),
+				"description": todoField(graphql.NewNonNull(graphql.String), func(t *model.Todo) interface{} { return t.Description }),
+				"status":      todoField(graphql.NewNonNull(graphql.String), func(t *model.Todo) interface{} { return t.Status }),
+				"version":     todoField(graphql.NewNonNull(graphql.Int), func(t *model.Todo) interface{} { return t.Version }),
+				"position":    todoField(graphql.String, func(t *model.Todo) interface{} { return t.Position }),
+				"dueAt":       todoField(graphql.DateTime, func(t *model.Todo) interface{} { return t.DueAt }),
+				"recurrence":  todoField(graphql.String, func(t *model.Todo) interface{} { return t.Recurrence }),
+				"timezone":    todoField(graphql.String, func(t *model.Todo) interface{} { return t.Timezone }),
+				"occurrence":  todoField(graphql.Int, func(t *model.Todo) interface{} { return t.Occurrence }),
+				"projectId":   todoField(graphql.Int, func(t *model.Todo) interface{} { return nullableInt(t.ProjectID) }),
+				"parentId":    todoField(graphql.Int, func(t *model.Todo) interface{} { return nullableInt(t.ParentID) }),
+				"createdAt":   todoField(graphql.NewNonNull(graphql.DateTime), func(t *model.Todo) interface{} { return t.CreatedAt }),
+				"updatedAt":   todoField(graphql.NewNonNull(graphql.DateTime), func(t *model.Todo) interface{} { return t.UpdatedAt }),
+				"project": todoField(projectType, func(t *model.Todo) interface{} {
+					if t.ProjectID == nil {
+						return nil
+					}
+					return &project{ID: *t.ProjectID}
+				}),
+				"parent": &graphql.Field{
+					Type: todoType,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						todo := p.Source.(*model.Todo)
+						if todo.ParentID == nil {
+							return nil, nil
+						}
+						return loadTodo(p, *todo.ParentID), nil
+					},
+				},
+				"children": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(todoType))),
+					Args: graphql.FieldConfigArgument{
+						"first": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: defaultFirst},
+					},
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						thunk := loadersFrom(p.Context).children.Load(p.Context, pageOf(p.Source.(*model.Todo).ID, p.Args))
+						return func() (interface{}, error) {
+							todos, err := thunk()
+							if err != nil {
+								return nil, internalError(err, "Failed to get children")
+							}
+							return todos, nil
+						}, nil
+					},
+				},
+			}
+		}),
+	})
+
+	eventType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "TodoEvent",
+		Fields: graphql.Fields{
+			"id":            eventField(graphql.NewNonNull(graphql.String), func(e *todoEvent) interface{} { return e.ID }),
+			"eventId":       eventField(graphql.NewNonNull(graphql.String), func(e *todoEvent) interface{} { return e.EventID }),
+			"type":          eventField(graphql.NewNonNull(graphql.String), func(e *todoEvent) interface{} { return e.Type }),
+			"actor":         eventField(graphql.String, func(e *todoEvent) interface{} { return e.Actor }),
+			"occurredAt":    eventField(graphql.DateTime, func(e *todoEvent) interface{} { return e.OccurredAt }),
+			"changedFields": eventField(graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String))), func(e *todoEvent) interface{} { return e.ChangedFields }),
+			"todo": eventField(todoType, func(e *todoEvent) interface{} {
+				if e.Todo == nil {
+					return nil
+				}
+				return e.Todo
+			}),
+		},
+	})
+
+	queryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"todo": &graphql.Field{
+				Type: todoType,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return loadTodo(p, p.Args["id"].(int)), nil
+				},
+			},
+			"todos": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(todoType))),
+				Args: func() graphql.FieldConfigArgument {
+					args := pageArgs()
+					args["filter"] = &graphql.ArgumentConfig{Type: graphql.String, Description: "Filter expression, as in GET /api/v2/todo"}
+					args["sort"] = &graphql.ArgumentConfig{Type: graphql.String, Description: "Sort fields, - for descending"}
+					return args
+				}(),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					query := model.TodoQuery{Limit: pageSize(p.Args["first"]), Offset: offset(p.Args)}
+					if text, _ := p.Args["filter"].(string); text != "" {
+						expr, err := filter.Parse(text)
+						if err == nil {
+							err = filter.Validate(expr, model.TodoFilterFields)
+						}
+						if err != nil {
+							return nil, err
+						}
+						query.Filter = expr
+					}
+					keys, err := filter.ParseSort(stringArg(p.Args, "sort"))
+					if err == nil {
+						_, err = filter.CompileSort(keys, model.TodoFilterFields)
+					}
+					if err != nil {
+						return nil, err
+					}
+					query.Sort = keys
+					if query.Limit == 0 {
+						return []*model.Todo{}, nil
+					}
+
+					todos, err := todoService.ListTodos(query)
+					if err != nil {
+						return nil, internalError(err, "Failed to get todos")
+					}
+					return todos, nil
+				},
+			},
+			"project": &graphql.Field{
+				Type: projectType,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return &project{ID: p.Args["id"].(int)}, nil
+				},
+			},
+		},
+	})
+
+	subscriptionType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Subscription",
+		Fields: graphql.Fields{
+			"todoChanged": &graphql.Field{
+				Type:        graphql.NewNonNull(eventType),
+				Description: "Todo changes as they happen, optionally only those of one project",
+				Args: graphql.FieldConfigArgument{
+					"projectId": &graphql.ArgumentConfig{Type: graphql.Int},
+				},
+				Subscribe: func(p graphql.ResolveParams) (interface{}, error) {
+					if events == nil {
+						return nil, errNoSubscriptions
+					}
+					projectID, byProject := p.Args["projectId"].(int)
+					live, stop := events.Subscribe()
+					out := make(chan interface{})
+					go func() {
+						defer close(out)
+						defer stop()
+						for {
+							select {
+							case <-p.Context.Done():
+								return
+							case message, ok := <-live:
+								if !ok {
+									return
+								}
+								event, err := decodeEvent(message)
+								if err != nil {
+									log.Errorf("Failed to decode todo event %s: %v", message.ID, err)
+									continue
+								}
+								if byProject && (event.Todo == nil || event.Todo.ProjectID == nil || *event.Todo.ProjectID != projectID) {
+									continue
+								}
+								select {
+								case out <- event:
+								case <-p.Context.Done():
+									return
+								}
+							}
+						}
+					}()
+					return out, nil
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source, nil
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{
+		Query:        queryType,
+		Subscription: subscriptionType,
+	})
+}
+
+func todoField(fieldType graphql.Output, get func(*model.Todo) interface{}) *graphql.Field {
+	return &graphql.Field{
+		Type: fieldType,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return get(p.Source.(*model.Todo)), nil
+		},
+	}
+}
+
+func eventField(fieldType graphql.Output, get func(*todoEvent) interface{}) *graphql.Field {
+	return &graphql.Field{
+		Type: fieldType,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return get(p.Source.(*todoEvent)), nil
+		},
+	}
+}
+
+// loadTodo resolves a todo by ID through the request's batching loader.
+func loadTodo(p graphql.ResolveParams, id int) func() (interface{}, error) {
+	thunk := loadersFrom(p.Context).todos.Load(p.Context, id)
+	return func() (interface{}, error) {
+		todo, err := thunk()
+		if err != nil {
+			return nil, internalError(err, "Failed to get todo")
+		}
+		if todo == nil {
+			return nil, nil
+		}
+		return todo, nil
+	}
+}
+
+func pageArgs() graphql.FieldConfigArgument {
+	return graphql.FieldConfigArgument{
+		"first":  &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: defaultFirst},
+		"offset": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 0},
+	}
+}
+
+// pageOf returns the key loading the page of group id selected by the first
+// and offset arguments.
+func pageOf(id int, args map[string]interface{}) groupKey {
+	return groupKey{ID: id, First: pageSize(args["first"]), Offset: offset(args)}
+}
+
+func offset(args map[string]interface{}) int {
+	if n, ok := args["offset"].(int); ok && n > 0 {
+		return n
+	}
+	return 0
+}
+
+func stringArg(args map[string]interface{}, name string) string {
+	value, _ := args[name].(string)
+	return value
+}
+
+func nullableInt(value *int) interface{} {
+	if value == nil {
+		return nil
+	}
+	return *value
+}
+
+// internalError logs err and returns an error that does not leak it.
+func internalError(err error, message string) error {
+	log.Errorf("%s: %v", message, err)
+	return errors.New(message)
+}
+
+// decodeEvent reads a todo event from the stream.
+func decodeEvent(message eventbus.Message) (*todoEvent, error) {
+	var payload struct {
+		Actor      string             `json:"actor"`
+		OccurredAt time.Time          `json:"occurred_at"`
+		Todo       *model.Todo        `json:"todo"`
+		Changes    model.FieldChanges `json:"changes"`
+	}
+	if err := json.Unmarshal(message.Payload, &payload); err != nil {
+		return nil, err
+	}
+	event := &todoEvent{
+		ID:            message.ID,
+		EventID:       message.EventID,
+		Type:          message.Type,
+		Actor:         payload.Actor,
+		OccurredAt:    payload.OccurredAt,
+		Todo:          payload.Todo,
+		ChangedFields: []string{},
+	}
+	for field := range payload.Changes {
+		event.ChangedFields = append(event.ChangedFields, field)
+	}
+	sort.Strings(event.ChangedFields)
+	return event, nil
+}
@@ -0,0 +1,69 @@
+package rpc
+
+import (
+	"context"
+	"strings"
+
+	"todo_project/common/actor"
+	"todo_project/constant"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Metadata keys carrying the API key and the acting user, the gRPC
+// counterparts of the X-API-KEY and X-User-ID headers.
+const (
+	apiKeyMetadata = "x-api-key"
+	userIDMetadata = "x-user-id"
+
+	reflectionPrefix = "/grpc.reflection."
+)
+
+// authenticate checks the API key of an incoming call and returns ctx with
+// the acting user.
+func authenticate(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx, ok := actor.Authenticate(ctx, first(md.Get(apiKeyMetadata)), first(md.Get(userIDMetadata)))
+	if !ok {
+		return nil, statusError(constant.ERR_UNAUTHORIZED, "Authorization failed")
+	}
+	return ctx, nil
+}
+
+func unaryAuth(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// streamAuth authenticates streaming calls. Reflection is left open since it
+// only describes the schema.
+func streamAuth(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if strings.HasPrefix(info.FullMethod, reflectionPrefix) {
+		return handler(srv, stream)
+	}
+	ctx, err := authenticate(stream.Context())
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authenticatedStream{ServerStream: stream, ctx: ctx})
+}
+
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
@@ -0,0 +1,68 @@
+package rpc
+
+import (
+	"errors"
+
+	"todo_project/constant"
+	"todo_project/service"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+// errorDomain is the domain of the ErrorInfo detail attached to every error.
+const errorDomain = "todo_project"
+
+// grpcCodes maps the API error codes to gRPC status codes. The error code
+// itself travels as the reason of an ErrorInfo detail.
+var grpcCodes = map[constant.ERROR_CODE]codes.Code{
+	constant.ERR_REQUEST_INVALID:       codes.InvalidArgument,
+	constant.ERR_REQUEST_NOTFOUND:      codes.NotFound,
+	constant.ERR_UNAUTHORIZED:          codes.Unauthenticated,
+	constant.ERR_CONFLICT:              codes.Aborted,
+	constant.ERR_INTERNAL_SERVER_ERROR: codes.Internal,
+	constant.ERR_DB_CONNECTION_ERROR:   codes.Unavailable,
+	constant.ERR_SERVICE_UNAVAILABLE:   codes.Unavailable,
+}
+
+// errorCode classifies a service error.
+func errorCode(err error) constant.ERROR_CODE {
+	switch {
+	case errors.Is(err, service.ErrInvalidTodo):
+		return constant.ERR_REQUEST_INVALID
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return constant.ERR_REQUEST_NOTFOUND
+	case errors.Is(err, service.ErrVersionConflict), errors.Is(err, service.ErrWIPLimitReached):
+		return constant.ERR_CONFLICT
+	default:
+		return constant.ERR_INTERNAL_SERVER_ERROR
+	}
+}
+
+// toStatus turns a service error into a gRPC status. Unexpected errors are
+// logged by the caller and reported with message only.
+func toStatus(err error, message string) error {
+	code := errorCode(err)
+	switch code {
+	case constant.ERR_REQUEST_NOTFOUND:
+		return statusError(code, "Todo not found")
+	case constant.ERR_INTERNAL_SERVER_ERROR:
+		return statusError(code, message)
+	default:
+		return statusError(code, err.Error())
+	}
+}
+
+func statusError(code constant.ERROR_CODE, message string) error {
+	grpcCode, ok := grpcCodes[code]
+	if !ok {
+		grpcCode = codes.Unknown
+	}
+	st := status.New(grpcCode, message)
+	if detailed, err := st.WithDetails(&errdetails.ErrorInfo{Reason: string(code), Domain: errorDomain}); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
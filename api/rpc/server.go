@@ -0,0 +1,55 @@
+package rpc
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"todo_project/common/log"
+	"todo_project/internal/redis"
+	todov1 "todo_project/proto/todo/v1"
+	"todo_project/service"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+// NewServer returns a gRPC server exposing the todo service behind the API
+// key, with server reflection enabled.
+func NewServer(todoService service.TodoService, events service.TodoEventSource, redisClient redis.IRedis) *grpc.Server {
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(unaryAuth),
+		grpc.StreamInterceptor(streamAuth),
+	)
+	todov1.RegisterTodoServiceServer(server, NewTodoServer(todoService, events, redisClient))
+	reflection.Register(server)
+	return server
+}
+
+// shutdownTimeout is how long running calls get to finish on shutdown before
+// they are cut off, which always happens to open WatchTodos streams.
+const shutdownTimeout = 30 * time.Second
+
+// ListenAndServe serves gRPC on port until ctx is cancelled, then lets
+// running calls finish.
+func ListenAndServe(ctx context.Context, port string, server *grpc.Server) error {
+	listener, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		return err
+	}
+	go func() {
+		<-ctx.Done()
+		stopped := make(chan struct{})
+		go func() {
+			server.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-time.After(shutdownTimeout):
+			server.Stop()
+		}
+	}()
+	log.Infof("gRPC server listening on %s", listener.Addr())
+	return server.Serve(listener)
+}
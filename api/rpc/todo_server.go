@@ -0,0 +1,293 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"time"
+
+	"todo_project/api/todocache"
+	"todo_project/common/eventbus"
+	"todo_project/common/filter"
+	"todo_project/common/log"
+	"todo_project/constant"
+	"todo_project/internal/redis"
+	"todo_project/model"
+	todov1 "todo_project/proto/todo/v1"
+	"todo_project/service"
+
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// TodoServer implements todov1.TodoServiceServer on top of the same services
+// as the REST API.
+type TodoServer struct {
+	todov1.UnimplementedTodoServiceServer
+	todoService service.TodoService
+	events      service.TodoEventSource
+	redisClient redis.IRedis
+}
+
+// NewTodoServer serves todos from todoService. events feeds WatchTodos and may
+// be nil, and redisClient is used to drop the copies the REST API cached.
+func NewTodoServer(todoService service.TodoService, events service.TodoEventSource, redisClient redis.IRedis) *TodoServer {
+	return &TodoServer{todoService: todoService, events: events, redisClient: redisClient}
+}
+
+func (s *TodoServer) CreateTodo(ctx context.Context, req *todov1.CreateTodoRequest) (*todov1.Todo, error) {
+	todo := &model.Todo{
+		Name:        req.GetName(),
+		Description: req.GetDescription(),
+		ProjectID:   optionalInt(req.ProjectId),
+		ParentID:    optionalInt(req.ParentId),
+		Recurrence:  req.GetRecurrence(),
+		Timezone:    req.GetTimezone(),
+	}
+	if req.DueAt != nil {
+		due := req.DueAt.AsTime()
+		todo.DueAt = &due
+	}
+	if err := s.todoService.CreateTodo(ctx, todo); err != nil {
+		return nil, s.fail(err, "Failed to create todo")
+	}
+	todocache.InvalidateAutocomplete(s.redisClient)
+	return toProtoTodo(todo), nil
+}
+
+func (s *TodoServer) GetTodo(ctx context.Context, req *todov1.GetTodoRequest) (*todov1.Todo, error) {
+	todo, err := s.todoService.GetTodoByID(uint(req.GetId()))
+	if err != nil {
+		return nil, s.fail(err, "Failed to get todo")
+	}
+	return toProtoTodo(todo), nil
+}
+
+func (s *TodoServer) ListTodos(ctx context.Context, req *todov1.ListTodosRequest) (*todov1.ListTodosResponse, error) {
+	query := model.TodoQuery{}
+	if req.GetFilter() != "" {
+		expr, err := filter.Parse(req.GetFilter())
+		if err == nil {
+			err = filter.Validate(expr, model.TodoFilterFields)
+		}
+		if err != nil {
+			return nil, statusError(constant.ERR_REQUEST_INVALID, err.Error())
+		}
+		query.Filter = expr
+	}
+	keys, err := filter.ParseSort(req.GetSort())
+	if err == nil {
+		_, err = filter.CompileSort(keys, model.TodoFilterFields)
+	}
+	if err != nil {
+		return nil, statusError(constant.ERR_REQUEST_INVALID, err.Error())
+	}
+	query.Sort = keys
+
+	pageSize := int(req.GetPageSize())
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+	offset := 0
+	if token := req.GetPageToken(); token != "" {
+		if offset, err = strconv.Atoi(token); err != nil || offset < 0 {
+			return nil, statusError(constant.ERR_REQUEST_INVALID, "Invalid page token")
+		}
+	}
+	// One extra row tells whether there is a next page.
+	query.Limit, query.Offset = pageSize+1, offset
+
+	todos, err := s.todoService.ListTodos(query)
+	if err != nil {
+		return nil, s.fail(err, "Failed to get todos")
+	}
+	resp := &todov1.ListTodosResponse{}
+	if len(todos) > pageSize {
+		todos = todos[:pageSize]
+		resp.NextPageToken = strconv.Itoa(offset + pageSize)
+	}
+	for _, todo := range todos {
+		resp.Todos = append(resp.Todos, toProtoTodo(todo))
+	}
+	return resp, nil
+}
+
+func (s *TodoServer) UpdateTodo(ctx context.Context, req *todov1.UpdateTodoRequest) (*todov1.Todo, error) {
+	changes := map[string]interface{}{}
+	for column, value := range map[string]*string{
+		"name":        req.Name,
+		"description": req.Description,
+		"status":      req.Status,
+		"due_at":      req.DueAt,
+		"recurrence":  req.Recurrence,
+		"timezone":    req.Timezone,
+	} {
+		if value != nil {
+			changes[column] = *value
+		}
+	}
+
+	todo, err := s.todoService.PatchTodo(ctx, uint(req.GetId()), int(req.GetVersion()), changes)
+	if err != nil {
+		return nil, s.fail(err, "Failed to update todo")
+	}
+	todocache.Evict(s.redisClient, todo.ID)
+	return toProtoTodo(todo), nil
+}
+
+func (s *TodoServer) DeleteTodo(ctx context.Context, req *todov1.DeleteTodoRequest) (*emptypb.Empty, error) {
+	if err := s.todoService.DeleteTodo(ctx, uint(req.GetId())); err != nil {
+		return nil, s.fail(err, "Failed to delete todo")
+	}
+	todocache.Evict(s.redisClient, int(req.GetId()))
+	return &emptypb.Empty{}, nil
+}
+
+// WatchTodos streams todo changes, optionally only those of one project. A
+// watcher passing the id of the last event it received in after first gets
+// the events it missed.
+func (s *TodoServer) WatchTodos(req *todov1.WatchTodosRequest, stream todov1.TodoService_WatchTodosServer) error {
+	if s.events == nil {
+		return statusError(constant.ERR_SERVICE_UNAVAILABLE, "Event stream is not available")
+	}
+
+	// Subscribe before catching up so nothing published meanwhile is lost.
+	live, stop := s.events.Subscribe()
+	defer stop()
+
+	last := req.GetAfter()
+	var backlog []eventbus.Message
+	if last != "" {
+		var err error
+		backlog, err = s.events.Since(last, service.MaxResumeEvents)
+		if err != nil {
+			log.Errorf("Failed to replay todo events after %s: %v", last, err)
+		}
+	}
+
+	send := func(message eventbus.Message) error {
+		if last != "" && !eventbus.After(message.ID, last) {
+			return nil
+		}
+		last = message.ID
+		event, err := toProtoEvent(message)
+		if err != nil {
+			log.Errorf("Failed to decode todo event %s: %v", message.ID, err)
+			return nil
+		}
+		if req.ProjectId != nil && (event.Todo == nil || event.Todo.ProjectId == nil || *event.Todo.ProjectId != *req.ProjectId) {
+			return nil
+		}
+		return stream.Send(event)
+	}
+	for _, message := range backlog {
+		if err := send(message); err != nil {
+			return err
+		}
+	}
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case message, ok := <-live:
+			if !ok {
+				// Dropped for falling behind; the watcher resumes with after.
+				return statusError(constant.ERR_SERVICE_UNAVAILABLE, "Watcher fell behind, resume from the last event")
+			}
+			if err := send(message); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// fail converts err to a status, logging the errors clients are not told
+// about.
+func (s *TodoServer) fail(err error, message string) error {
+	if errorCode(err) == constant.ERR_INTERNAL_SERVER_ERROR {
+		log.Errorf("%s: %v", message, err)
+	}
+	return toStatus(err, message)
+}
+
+func toProtoTodo(todo *model.Todo) *todov1.Todo {
+	pb := &todov1.Todo{
+		Id:          int64(todo.ID),
+		Name:        todo.Name,
+		Description: todo.Description,
+		Status:      todo.Status,
+		Version:     int64(todo.Version),
+		ProjectId:   optionalInt64(todo.ProjectID),
+		ParentId:    optionalInt64(todo.ParentID),
+		Position:    todo.Position,
+		Recurrence:  todo.Recurrence,
+		Timezone:    todo.Timezone,
+		Occurrence:  int64(todo.Occurrence),
+		CreatedAt:   timestamp(todo.CreatedAt),
+		UpdatedAt:   timestamp(todo.UpdatedAt),
+	}
+	if todo.DueAt != nil {
+		pb.DueAt = timestamppb.New(*todo.DueAt)
+	}
+	return pb
+}
+
+// toProtoEvent decodes the JSON payload of a todo event.
+func toProtoEvent(message eventbus.Message) (*todov1.TodoEvent, error) {
+	var payload struct {
+		Actor      string             `json:"actor"`
+		OccurredAt time.Time          `json:"occurred_at"`
+		Todo       *model.Todo        `json:"todo"`
+		Changes    model.FieldChanges `json:"changes"`
+	}
+	if err := json.Unmarshal(message.Payload, &payload); err != nil {
+		return nil, err
+	}
+	event := &todov1.TodoEvent{
+		Id:         message.ID,
+		EventId:    message.EventID,
+		Type:       message.Type,
+		Actor:      payload.Actor,
+		OccurredAt: timestamp(payload.OccurredAt),
+	}
+	if payload.Todo != nil {
+		event.Todo = toProtoTodo(payload.Todo)
+	}
+	for field := range payload.Changes {
+		event.ChangedFields = append(event.ChangedFields, field)
+	}
+	sort.Strings(event.ChangedFields)
+	return event, nil
+}
+
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func optionalInt(value *int64) *int {
+	if value == nil {
+		return nil
+	}
+	v := int(*value)
+	return &v
+}
+
+func optionalInt64(value *int) *int64 {
+	if value == nil {
+		return nil
+	}
+	v := int64(*value)
+	return &v
+}
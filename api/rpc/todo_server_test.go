@@ -0,0 +1,167 @@
+package rpc
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"todo_project/constant"
+	"todo_project/model"
+	todov1 "todo_project/proto/todo/v1"
+	"todo_project/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"gorm.io/gorm"
+)
+
+// Mock todo service; only the methods the gRPC server calls are mocked.
+type mockTodoService struct {
+	service.TodoService
+	mock.Mock
+}
+
+func (m *mockTodoService) CreateTodo(ctx context.Context, todo *model.Todo) error {
+	args := m.Called(todo)
+	return args.Error(0)
+}
+
+func (m *mockTodoService) GetTodoByID(id uint) (*model.Todo, error) {
+	args := m.Called(id)
+	var result *model.Todo
+	if args.Get(0) != nil {
+		result = args.Get(0).(*model.Todo)
+	}
+
+	return result, args.Error(1)
+}
+
+func (m *mockTodoService) ListTodos(query model.TodoQuery) ([]*model.Todo, error) {
+	args := m.Called(query.Limit, query.Offset)
+	var result []*model.Todo
+	if args.Get(0) != nil {
+		result = args.Get(0).([]*model.Todo)
+	}
+
+	return result, args.Error(1)
+}
+
+func (m *mockTodoService) PatchTodo(ctx context.Context, id uint, version int, changes map[string]interface{}) (*model.Todo, error) {
+	args := m.Called(id, version, changes)
+	var result *model.Todo
+	if args.Get(0) != nil {
+		result = args.Get(0).(*model.Todo)
+	}
+
+	return result, args.Error(1)
+}
+
+func newTestClient(t *testing.T, todoService service.TodoService) todov1.TodoServiceClient {
+	listener := bufconn.Listen(1 << 20)
+	server := NewServer(todoService, nil, nil)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return todov1.NewTodoServiceClient(conn)
+}
+
+func errorReason(err error) string {
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			return info.Reason
+		}
+	}
+	return ""
+}
+
+func TestTodoServer(t *testing.T) {
+	t.Setenv("API_KEY", "secret")
+	mockTs := new(mockTodoService)
+	client := newTestClient(t, mockTs)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "secret", "x-user-id", "alice")
+
+	t.Run("missing API key", func(t *testing.T) {
+		_, err := client.GetTodo(context.Background(), &todov1.GetTodoRequest{Id: 1})
+
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+		assert.Equal(t, string(constant.ERR_UNAUTHORIZED), errorReason(err))
+	})
+
+	t.Run("create", func(t *testing.T) {
+		mockTs.On("CreateTodo", mock.MatchedBy(func(todo *model.Todo) bool {
+			return todo.Name == "Ship gRPC" && *todo.ProjectID == 3
+		})).Run(func(args mock.Arguments) {
+			todo := args.Get(0).(*model.Todo)
+			todo.ID = 12
+			todo.Version = 1
+		}).Return(nil).Once()
+
+		project := int64(3)
+		todo, err := client.CreateTodo(ctx, &todov1.CreateTodoRequest{Name: "Ship gRPC", Description: "Alongside REST", ProjectId: &project})
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(12), todo.Id)
+		assert.Equal(t, int64(3), todo.GetProjectId())
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mockTs.On("GetTodoByID", uint(99)).Return(nil, gorm.ErrRecordNotFound).Once()
+
+		_, err := client.GetTodo(ctx, &todov1.GetTodoRequest{Id: 99})
+
+		assert.Equal(t, codes.NotFound, status.Code(err))
+		assert.Equal(t, string(constant.ERR_REQUEST_NOTFOUND), errorReason(err))
+	})
+
+	t.Run("list pages", func(t *testing.T) {
+		mockTs.On("ListTodos", 3, 2).Return([]*model.Todo{{ID: 3}, {ID: 4}, {ID: 5}}, nil).Once()
+
+		resp, err := client.ListTodos(ctx, &todov1.ListTodosRequest{PageSize: 2, PageToken: "2"})
+
+		assert.NoError(t, err)
+		assert.Len(t, resp.Todos, 2)
+		assert.Equal(t, "4", resp.NextPageToken)
+	})
+
+	t.Run("list with invalid filter", func(t *testing.T) {
+		_, err := client.ListTodos(ctx, &todov1.ListTodosRequest{Filter: "priority:high"})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("update conflict", func(t *testing.T) {
+		done := model.TodoStatusDone
+		mockTs.On("PatchTodo", uint(5), 2, map[string]interface{}{"status": "done"}).Return(nil, service.ErrVersionConflict).Once()
+
+		_, err := client.UpdateTodo(ctx, &todov1.UpdateTodoRequest{Id: 5, Version: 2, Status: &done})
+
+		assert.Equal(t, codes.Aborted, status.Code(err))
+		assert.Equal(t, string(constant.ERR_CONFLICT), errorReason(err))
+	})
+
+	t.Run("watch without event stream", func(t *testing.T) {
+		stream, err := client.WatchTodos(ctx, &todov1.WatchTodosRequest{})
+		assert.NoError(t, err)
+		_, err = stream.Recv()
+
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+	})
+
+	mockTs.AssertExpectations(t)
+}
@@ -1,39 +1,101 @@
 package api
 
 import (
+	"todo_project/api/graph"
 	v2 "todo_project/api/v2"
-	"todo_project/repository"
+	v3 "todo_project/api/v3"
+	"todo_project/common/log"
 	"todo_project/service"
 	"todo_project/internal/redis"
-	"todo_project/internal"
 
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/adapters/humagin"
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
-func SetupRoutes(r *gin.RouterGroup, redisClient redis.IRedis) {
-	todoRepo := repository.NewTodoRepository(internal.GormSqlClient.GetDB())
-	todoService := service.NewTodoService(todoRepo)
+func SetupRoutes(r *gin.RouterGroup, todoService service.TodoService, viewService service.SavedViewService, reminderService service.ReminderService, webhookService service.WebhookService, syncService service.SyncService, events service.TodoEventSource, presence service.PresenceSource, redisClient redis.IRedis) {
 	todoHandler := v2.NewTodoHandler(todoService, redisClient)
+	viewHandler := v2.NewSavedViewHandler(viewService)
+	reminderHandler := v2.NewReminderHandler(reminderService)
+	webhookHandler := v2.NewWebhookHandler(webhookService)
+	eventsHandler := v2.NewTodoEventsHandler(events)
+	socketHandler := v2.NewTodoSocketHandler(todoHandler, events, presence)
+	syncHandler := v2.NewSyncHandler(syncService, todoHandler)
+	executor, err := graph.NewExecutor(todoService, events)
+	if err != nil {
+		log.Fatal("Failed to build the GraphQL schema: ", err)
+	}
+	graphqlHandler := v2.NewGraphQLHandler(executor)
 	r.POST("/todo", todoHandler.CreateTodo)
+	r.POST("/todo/bulk", todoHandler.BulkTodos)
+	r.GET("/todo/search", todoHandler.SearchTodos)
+	r.GET("/todo/autocomplete", todoHandler.AutocompleteTodos)
+	r.GET("/todo/events", eventsHandler.StreamTodoEvents)
+	r.GET("/ws", socketHandler.ServeTodoSocket)
+	r.GET("/sync", syncHandler.GetChanges)
+	r.POST("/sync", syncHandler.PushChanges)
+	r.GET("/graphql", graphqlHandler.ServeGraphQL)
+	r.POST("/graphql", graphqlHandler.ServeGraphQL)
 	r.GET("/todo/:id", todoHandler.GetTodo)
 	r.GET("/todo", todoHandler.GetAllTodos)
 	r.PUT("/todo/:id", todoHandler.UpdateTodo)
+	r.PATCH("/todo/:id", todoHandler.PatchTodo)
 	r.DELETE("/todo/:id", todoHandler.DeleteTodo)
+	r.POST("/todo/:id/restore", todoHandler.RestoreTodo)
+	r.GET("/todo/:id/history", todoHandler.GetTodoHistory)
+	r.POST("/todo/:id/revert", todoHandler.RevertTodo)
+	r.POST("/todo/:id/move", todoHandler.MoveTodo)
+	r.GET("/todo/:id/occurrences", todoHandler.PreviewOccurrences)
+	r.POST("/todo/:id/reminders", reminderHandler.CreateReminder)
+	r.GET("/todo/:id/reminders", reminderHandler.GetReminders)
+	r.DELETE("/reminders/:id", reminderHandler.CancelReminder)
+	r.GET("/projects/:id/board", todoHandler.GetBoard)
+	r.GET("/trash", todoHandler.GetTrash)
+	r.DELETE("/trash/:id", todoHandler.PurgeTodo)
+	r.POST("/views", viewHandler.CreateView)
+	r.GET("/views", viewHandler.GetViews)
+	r.GET("/views/:id", viewHandler.GetView)
+	r.PUT("/views/:id", viewHandler.UpdateView)
+	r.DELETE("/views/:id", viewHandler.DeleteView)
+	r.GET("/views/:id/todos", viewHandler.GetViewTodos)
+	r.POST("/webhooks", webhookHandler.CreateWebhook)
+	r.GET("/webhooks", webhookHandler.GetWebhooks)
+	r.GET("/webhooks/:id", webhookHandler.GetWebhook)
+	r.PUT("/webhooks/:id", webhookHandler.UpdateWebhook)
+	r.DELETE("/webhooks/:id", webhookHandler.DeleteWebhook)
+	r.GET("/webhooks/:id/deliveries", webhookHandler.GetDeliveries)
+	r.POST("/webhooks/:id/deliveries/:delivery_id/redeliver", webhookHandler.Redeliver)
 
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
-	r.GET("/openapi.json", func(c *gin.Context) {
-		c.File("./docs/swagger.json")
-	})
 
 }
 
+// SetupV3Routes serves the todo API on r through Huma, which generates the
+// OpenAPI 3.1 spec at /openapi.json and its documentation at /docs.
+func SetupV3Routes(engine *gin.Engine, r *gin.RouterGroup, todoService service.TodoService, redisClient redis.IRedis) huma.API {
+	config := huma.DefaultConfig("todo_project API", "3.0.0")
+	config.Servers = []*huma.Server{{URL: r.BasePath()}}
+	config.Components.SecuritySchemes = map[string]*huma.SecurityScheme{
+		"apiKey": {Type: "apiKey", In: "header", Name: "X-API-KEY"},
+	}
+	config.Security = []map[string][]string{{"apiKey": {}}}
+	api := humagin.NewWithGroup(engine, r, config)
+
+	v3.NewTodoHandler(todoService, redisClient).Register(api)
+	return api
+}
+
 //@Summary Lấy tất cả todo
 //@Description Trả về thông tin danh sách todo
 //@Tags todo
 //@Produce json 
 //@Param id path int true "todo ID" 
+//@Param filter query string false "biểu thức lọc, ví dụ status:doing AND created>-7d"
+//@Param sort query string false "trường sắp xếp, thêm - để giảm dần, ví dụ position hoặc -updated"
+//@Param fields query string false "chỉ trả về các trường này, ví dụ id,name,status"
+//@Param include query string false "nhúng các quan hệ, hiện chỉ có children"
 //@Success 200 {object} model.Todo 
 //@Router /todo [get] 
 func GetAllTodos(c *gin.Context) {}
@@ -43,6 +105,8 @@ func GetAllTodos(c *gin.Context) {}
 // @Tags todo
 // @Produce json
 // @Param id path int true "todo ID"
+// @Param fields query string false "chỉ trả về các trường này, ví dụ id,name,status"
+// @Param include query string false "nhúng các quan hệ, hiện chỉ có children"
 // @Success 200 {object} model.Todo
 // @Router /todo/{id} [get]
 func GetTodo(c *gin.Context) {}
@@ -75,4 +139,328 @@ func DeleteTodo(c *gin.Context) {}
 // @Param data body model.Todo true "todo info"
 // @Success 200 {object} model.Todo
 // @Router /todo/{id} [put]
-func UpdateTodo(c *gin.Context) {}
\ No newline at end of file
+func UpdateTodo(c *gin.Context) {}
+
+// @Summary Cập nhật một phần todo
+// @Description Chỉ cập nhật các trường thay đổi, hỗ trợ JSON Merge Patch và JSON Patch
+// @Tags todo
+// @Accept application/merge-patch+json
+// @Accept application/json-patch+json
+// @Produce json
+// @Param id path int true "todo ID"
+// @Param data body dto.PatchTodoDocument true "patch document"
+// @Success 200 {object} dto.TodoResponse
+// @Router /todo/{id} [patch]
+func PatchTodo(c *gin.Context) {}
+
+// @Summary Thao tác hàng loạt trên todo
+// @Description Tạo, cập nhật, xoá hoặc đổi trạng thái nhiều todo trong một request (atomic hoặc best_effort)
+// @Tags todo
+// @Accept json
+// @Produce json
+// @Param data body dto.BulkTodoRequest true "bulk operations"
+// @Success 200 {object} dto.BulkTodoResponse
+// @Success 207 {object} dto.BulkTodoResponse
+// @Router /todo/bulk [post]
+func BulkTodos(c *gin.Context) {}
+
+// @Summary Lấy danh sách todo trong thùng rác
+// @Description Trả về các todo đã bị xoá mềm, mới xoá nhất trước
+// @Tags trash
+// @Produce json
+// @Success 200 {array} dto.TrashedTodoResponse
+// @Router /trash [get]
+func GetTrash(c *gin.Context) {}
+
+// @Summary Khôi phục todo
+// @Description Khôi phục một todo từ thùng rác
+// @Tags trash
+// @Produce json
+// @Param id path int true "todo ID"
+// @Success 200 {object} dto.TodoResponse
+// @Router /todo/{id}/restore [post]
+func RestoreTodo(c *gin.Context) {}
+
+// @Summary Xoá vĩnh viễn todo
+// @Description Xoá vĩnh viễn một todo đang nằm trong thùng rác
+// @Tags trash
+// @Produce json
+// @Param id path int true "todo ID"
+// @Success 200
+// @Router /trash/{id} [delete]
+func PurgeTodo(c *gin.Context) {}
+
+// @Summary Lịch sử thay đổi của todo
+// @Description Trả về toàn bộ lịch sử thay đổi (người thực hiện, thời gian, các trường thay đổi) của một todo
+// @Tags history
+// @Produce json
+// @Param id path int true "todo ID"
+// @Success 200 {array} dto.TodoHistoryResponse
+// @Router /todo/{id}/history [get]
+func GetTodoHistory(c *gin.Context) {}
+
+// @Summary Khôi phục todo về phiên bản trước
+// @Description Đưa tên, mô tả và trạng thái của todo về giá trị tại một phiên bản trong lịch sử
+// @Tags history
+// @Accept json
+// @Produce json
+// @Param id path int true "todo ID"
+// @Param data body dto.RevertTodoRequest true "target version"
+// @Success 200 {object} dto.TodoResponse
+// @Router /todo/{id}/revert [post]
+func RevertTodo(c *gin.Context) {}
+
+// @Summary Tìm kiếm todo
+// @Description Tìm kiếm toàn văn theo tên và mô tả, kết quả được xếp hạng và đánh dấu đoạn khớp
+// @Tags todo
+// @Produce json
+// @Param q query string true "từ khoá, mỗi từ được khớp theo tiền tố"
+// @Param status query string false "lọc theo trạng thái"
+// @Param lang query string false "cấu hình ngôn ngữ tìm kiếm của Postgres"
+// @Param mode query string false "fulltext (mặc định) hoặc fuzzy để khớp gần đúng theo tên"
+// @Param threshold query number false "độ tương đồng tối thiểu khi mode=fuzzy (0..1)"
+// @Param filter query string false "biểu thức lọc, ví dụ status:doing AND created>-7d"
+// @Param page query int false "trang, bắt đầu từ 1"
+// @Param page_size query int false "số todo mỗi trang (tối đa 100)"
+// @Success 200 {object} dto.TodoSearchResponse
+// @Router /todo/search [get]
+func SearchTodos(c *gin.Context) {}
+
+// @Summary Gợi ý tên todo
+// @Description Trả về các tên todo bắt đầu bằng tiền tố, dùng nhiều nhất xếp trước
+// @Tags todo
+// @Produce json
+// @Param prefix query string true "tiền tố cần hoàn thành"
+// @Param limit query int false "số gợi ý tối đa (mặc định 10, tối đa 50)"
+// @Success 200 {object} dto.TodoAutocompleteResponse
+// @Router /todo/autocomplete [get]
+func AutocompleteTodos(c *gin.Context) {}
+
+// @Summary Di chuyển todo
+// @Description Đặt todo ngay trước hoặc ngay sau một todo khác trong cùng danh sách, chỉ vị trí của todo này được ghi lại
+// @Tags todo
+// @Accept json
+// @Produce json
+// @Param id path int true "todo ID"
+// @Param data body dto.MoveTodoRequest true "vị trí mới"
+// @Success 200 {object} dto.TodoResponse
+// @Router /todo/{id}/move [post]
+func MoveTodo(c *gin.Context) {}
+
+// @Summary Xem trước các lần lặp lại
+// @Description Trả về hạn của N lần lặp tiếp theo của một todo định kỳ, tính theo múi giờ của todo
+// @Tags todo
+// @Produce json
+// @Param id path int true "todo ID"
+// @Param count query int false "số lần lặp (mặc định 5, tối đa 50)"
+// @Success 200 {object} dto.OccurrencePreviewResponse
+// @Router /todo/{id}/occurrences [get]
+func PreviewOccurrences(c *gin.Context) {}
+
+// @Summary Luồng sự kiện thay đổi todo
+// @Description Server-Sent Events cho mỗi lần todo được tạo, cập nhật, hoàn thành hoặc xoá. Gửi Last-Event-ID để nhận lại các sự kiện bị lỡ
+// @Tags todo
+// @Produce text/event-stream
+// @Param project query int false "chỉ nhận sự kiện của dự án này"
+// @Param Last-Event-ID header string false "id của sự kiện cuối cùng đã nhận"
+// @Success 200 {string} string "luồng sự kiện"
+// @Router /todo/events [get]
+func StreamTodoEvents(c *gin.Context) {}
+
+// @Summary Kênh WebSocket cộng tác thời gian thực
+// @Description Đăng ký chủ đề project:<id> hoặc todo:<id> để nhận sự kiện và trạng thái hiện diện, gửi trạng thái đang xem/đang sửa và chỉnh sửa todo qua todoService
+// @Tags todo
+// @Success 101 {string} string "Switching Protocols"
+// @Router /ws [get]
+func ServeTodoSocket(c *gin.Context) {}
+
+// @Summary Lấy thay đổi kể từ token đồng bộ
+// @Description Trả về các todo được tạo, cập nhật và bị xoá (kể cả xoá mềm) kể từ token. Bỏ trống since để đồng bộ toàn bộ
+// @Tags sync
+// @Produce json
+// @Param since query string false "token nhận được từ lần đồng bộ trước"
+// @Success 200 {object} dto.SyncResponse
+// @Router /sync [get]
+func GetSyncChanges(c *gin.Context) {}
+
+// @Summary Gửi các thay đổi ngoại tuyến
+// @Description Áp dụng từng thay đổi trong giao dịch riêng. Thay đổi của server thắng theo từng trường, chỉnh sửa thắng thao tác xoá
+// @Tags sync
+// @Accept json
+// @Produce json
+// @Param data body dto.SyncRequest true "các thay đổi của client"
+// @Success 200 {object} dto.SyncPushResponse
+// @Router /sync [post]
+func PushSyncChanges(c *gin.Context) {}
+
+// @Summary Truy vấn GraphQL
+// @Description Truy vấn todo cùng todo cha, todo con và dự án trong một lần gọi. Subscription todoChanged được trả về dưới dạng Server-Sent Events
+// @Tags graphql
+// @Accept json
+// @Produce json
+// @Param data body dto.GraphQLRequest true "truy vấn GraphQL"
+// @Success 200 {object} map[string]interface{}
+// @Router /graphql [post]
+func ServeGraphQL(c *gin.Context) {}
+
+// @Summary Tạo nhắc nhở cho todo
+// @Description Nhắc vào remind_at hoặc offset phút so với hạn của todo (âm là trước hạn), gửi qua kênh log, webhook hoặc smtp
+// @Tags reminders
+// @Accept json
+// @Produce json
+// @Param id path int true "todo ID"
+// @Param data body dto.ReminderRequest true "reminder"
+// @Success 201 {object} dto.ReminderResponse
+// @Router /todo/{id}/reminders [post]
+func CreateReminder(c *gin.Context) {}
+
+// @Summary Lấy nhắc nhở của todo
+// @Description Trả về các nhắc nhở cùng trạng thái gửi, số lần thử và lỗi gần nhất
+// @Tags reminders
+// @Produce json
+// @Param id path int true "todo ID"
+// @Success 200 {array} dto.ReminderResponse
+// @Router /todo/{id}/reminders [get]
+func GetReminders(c *gin.Context) {}
+
+// @Summary Huỷ nhắc nhở
+// @Description Huỷ một nhắc nhở chưa được gửi
+// @Tags reminders
+// @Produce json
+// @Param id path int true "reminder ID"
+// @Success 200 {object} map[string]string
+// @Router /reminders/{id} [delete]
+func CancelReminder(c *gin.Context) {}
+
+// @Summary Lấy bảng Kanban của dự án
+// @Description Trả về các cột theo trạng thái, mỗi cột gồm số lượng, giới hạn WIP và các thẻ đã sắp xếp
+// @Tags board
+// @Produce json
+// @Param id path int true "project ID"
+// @Success 200 {object} dto.BoardResponse
+// @Router /projects/{id}/board [get]
+func GetBoard(c *gin.Context) {}
+
+// @Summary Tạo view đã lưu
+// @Description Lưu một bộ lọc và thứ tự sắp xếp có tên cho người dùng hiện tại
+// @Tags views
+// @Accept json
+// @Produce json
+// @Param data body dto.SavedViewRequest true "view"
+// @Success 201 {object} dto.SavedViewResponse
+// @Router /views [post]
+func CreateView(c *gin.Context) {}
+
+// @Summary Lấy danh sách view
+// @Description Trả về các view của người dùng hiện tại và các view được chia sẻ
+// @Tags views
+// @Produce json
+// @Success 200 {array} dto.SavedViewResponse
+// @Router /views [get]
+func GetViews(c *gin.Context) {}
+
+// @Summary Lấy view theo ID
+// @Tags views
+// @Produce json
+// @Param id path int true "view ID"
+// @Success 200 {object} dto.SavedViewResponse
+// @Router /views/{id} [get]
+func GetView(c *gin.Context) {}
+
+// @Summary Cập nhật view
+// @Description Chỉ chủ sở hữu được thay đổi view
+// @Tags views
+// @Accept json
+// @Produce json
+// @Param id path int true "view ID"
+// @Param data body dto.SavedViewRequest true "view"
+// @Success 200 {object} dto.SavedViewResponse
+// @Router /views/{id} [put]
+func UpdateView(c *gin.Context) {}
+
+// @Summary Xoá view
+// @Description Chỉ chủ sở hữu được xoá view
+// @Tags views
+// @Produce json
+// @Param id path int true "view ID"
+// @Success 200 {object} map[string]string
+// @Router /views/{id} [delete]
+func DeleteView(c *gin.Context) {}
+
+// @Summary Lấy todo theo view
+// @Description Thực thi bộ lọc và thứ tự sắp xếp đã lưu của view
+// @Tags views
+// @Produce json
+// @Param id path int true "view ID"
+// @Param page query int false "trang, bắt đầu từ 1"
+// @Param page_size query int false "số todo mỗi trang (tối đa 100)"
+// @Success 200 {array} dto.TodoResponse
+// @Router /views/{id}/todos [get]
+func GetViewTodos(c *gin.Context) {}
+
+// @Summary Đăng ký webhook
+// @Description Gửi các sự kiện todo.created, todo.updated, todo.completed, todo.deleted tới URL, ký bằng HMAC-SHA256. Secret chỉ được trả về một lần khi tạo
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param data body dto.WebhookRequest true "webhook"
+// @Success 201 {object} dto.WebhookResponse
+// @Router /webhooks [post]
+func CreateWebhook(c *gin.Context) {}
+
+// @Summary Lấy danh sách webhook
+// @Description Trả về các webhook của người dùng hiện tại
+// @Tags webhooks
+// @Produce json
+// @Success 200 {array} dto.WebhookResponse
+// @Router /webhooks [get]
+func GetWebhooks(c *gin.Context) {}
+
+// @Summary Lấy webhook theo ID
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "webhook ID"
+// @Success 200 {object} dto.WebhookResponse
+// @Router /webhooks/{id} [get]
+func GetWebhook(c *gin.Context) {}
+
+// @Summary Cập nhật webhook
+// @Description Secret để trống sẽ giữ nguyên secret hiện tại
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path int true "webhook ID"
+// @Param data body dto.WebhookRequest true "webhook"
+// @Success 200 {object} dto.WebhookResponse
+// @Router /webhooks/{id} [put]
+func UpdateWebhook(c *gin.Context) {}
+
+// @Summary Xoá webhook
+// @Description Xoá webhook cùng nhật ký gửi của nó
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "webhook ID"
+// @Success 200 {object} map[string]string
+// @Router /webhooks/{id} [delete]
+func DeleteWebhook(c *gin.Context) {}
+
+// @Summary Lấy nhật ký gửi của webhook
+// @Description Trả về các lần gửi, mới nhất trước, kèm trạng thái, mã phản hồi và lỗi gần nhất
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "webhook ID"
+// @Param page query int false "trang, bắt đầu từ 1"
+// @Param page_size query int false "số bản ghi mỗi trang (tối đa 100)"
+// @Success 200 {array} dto.WebhookDeliveryResponse
+// @Router /webhooks/{id}/deliveries [get]
+func GetWebhookDeliveries(c *gin.Context) {}
+
+// @Summary Gửi lại sự kiện
+// @Description Tạo một lần gửi mới với cùng nội dung và event ID
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "webhook ID"
+// @Param delivery_id path int true "delivery ID"
+// @Success 202 {object} dto.WebhookDeliveryResponse
+// @Router /webhooks/{id}/deliveries/{delivery_id}/redeliver [post]
+func RedeliverWebhook(c *gin.Context) {}
@@ -0,0 +1,48 @@
+// Package todocache manages the copies of todos the APIs cache in Redis.
+package todocache
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"todo_project/common/log"
+	"todo_project/internal/redis"
+)
+
+// autocompleteGenerationKey holds the generation of the cached autocomplete
+// answers. Every answer is cached under the generation current when it was
+// computed, so dropping the generation invalidates them all at once; the
+// orphaned answers expire on their own.
+const autocompleteGenerationKey = "autocomplete_generation"
+
+// AutocompleteKey is the Redis key of the cached autocomplete answer for
+// prefix and limit. It starts a new generation when there is none, and must
+// be read before the answer is computed so that a later invalidation covers it.
+func AutocompleteKey(client redis.IRedis, limit int, prefix string) (string, error) {
+	generation, err := client.Get(autocompleteGenerationKey)
+	if err != nil {
+		generation = strconv.FormatInt(time.Now().UnixNano(), 36)
+		ok, err := client.SetNX(autocompleteGenerationKey, generation, 0)
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			if generation, err = client.Get(autocompleteGenerationKey); err != nil {
+				return "", err
+			}
+		}
+	}
+	return "autocomplete_" + generation + "_" + strconv.Itoa(limit) + "_" + strings.ToLower(prefix), nil
+}
+
+// InvalidateAutocomplete drops every cached autocomplete answer. It is called
+// whenever a todo is created, changed or deleted.
+func InvalidateAutocomplete(client redis.IRedis) {
+	if client == nil {
+		return
+	}
+	if _, err := client.Delete(autocompleteGenerationKey); err != nil {
+		log.Errorf("Failed to delete %s from Redis cache: %v", autocompleteGenerationKey, err)
+	}
+}
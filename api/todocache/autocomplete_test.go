@@ -0,0 +1,61 @@
+package todocache
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"todo_project/internal/redis"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Fake Redis holding plain keys in memory; only the methods the cache calls
+// are implemented.
+type fakeRedis struct {
+	redis.IRedis
+	data map[string]string
+}
+
+func (f *fakeRedis) Get(key string) (string, error) {
+	value, ok := f.data[key]
+	if !ok {
+		return "", errors.New("redis: nil")
+	}
+	return value, nil
+}
+
+func (f *fakeRedis) SetNX(key string, value interface{}, expiration time.Duration) (bool, error) {
+	if _, ok := f.data[key]; ok {
+		return false, nil
+	}
+	f.data[key] = value.(string)
+	return true, nil
+}
+
+func (f *fakeRedis) Delete(key string) (int64, error) {
+	if _, ok := f.data[key]; !ok {
+		return 0, nil
+	}
+	delete(f.data, key)
+	return 1, nil
+}
+
+func TestAutocompleteKey(t *testing.T) {
+	client := &fakeRedis{data: map[string]string{}}
+
+	first, err := AutocompleteKey(client, 10, "Dep")
+	assert.NoError(t, err)
+	again, err := AutocompleteKey(client, 10, "dep")
+	assert.NoError(t, err)
+	assert.Equal(t, first, again)
+	other, err := AutocompleteKey(client, 5, "dep")
+	assert.NoError(t, err)
+	assert.NotEqual(t, first, other)
+
+	InvalidateAutocomplete(client)
+	time.Sleep(time.Microsecond)
+	invalidated, err := AutocompleteKey(client, 10, "dep")
+	assert.NoError(t, err)
+	assert.NotEqual(t, first, invalidated)
+}
@@ -0,0 +1,27 @@
+package todocache
+
+import (
+	"strconv"
+
+	"todo_project/common/log"
+	"todo_project/internal/redis"
+)
+
+// TodoKey is the Redis key of the copy of a todo cached by the v2 API.
+func TodoKey(id int) string {
+	return "todo_" + strconv.Itoa(id)
+}
+
+// Evict drops the cached copy of a todo, so the next GET reads the database
+// again, and the autocomplete answers it may appear in. Every API calls it
+// after changing a todo; client may be nil.
+func Evict(client redis.IRedis, id int) {
+	if client == nil {
+		return
+	}
+	key := TodoKey(id)
+	if _, err := client.Delete(key); err != nil {
+		log.Errorf("Failed to delete %s from Redis cache: %v", key, err)
+	}
+	InvalidateAutocomplete(client)
+}
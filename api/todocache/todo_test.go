@@ -0,0 +1,20 @@
+package todocache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvict(t *testing.T) {
+	client := &fakeRedis{data: map[string]string{
+		TodoKey(1):                "{}",
+		TodoKey(2):                "{}",
+		autocompleteGenerationKey: "g1",
+	}}
+
+	Evict(client, 1)
+
+	assert.Equal(t, map[string]string{TodoKey(2): "{}"}, client.data)
+	assert.NotPanics(t, func() { Evict(nil, 1) })
+}
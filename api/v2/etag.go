@@ -0,0 +1,18 @@
+package v2
+
+import "strings"
+
+// etagMatches reports whether an If-Match / If-None-Match header value lists
+// etag. Weak validators are compared by their opaque tag.
+func etagMatches(header string, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package v2
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"todo_project/api/graph"
+	"todo_project/dto"
+
+	"github.com/gin-gonic/gin"
+	"github.com/graphql-go/graphql/gqlerrors"
+)
+
+type GraphQLHandler struct {
+	executor *graph.Executor
+}
+
+func NewGraphQLHandler(executor *graph.Executor) *GraphQLHandler {
+	return &GraphQLHandler{executor: executor}
+}
+
+// ServeGraphQL runs a GraphQL operation. Queries are answered with a JSON
+// result; subscriptions are streamed as Server-Sent Events, one "next" event
+// per result and a final "complete" event.
+func (h *GraphQLHandler) ServeGraphQL(c *gin.Context) {
+	var req dto.GraphQLRequest
+	if c.Request.Method == http.MethodGet {
+		req.Query = c.Query("query")
+		req.OperationName = c.Query("operationName")
+		if variables := c.Query("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				writeGraphQLErrors(c, gqlerrors.FormatErrors(fmt.Errorf("variables must be a JSON object")))
+				return
+			}
+		}
+	} else if err := c.ShouldBindJSON(&req); err != nil {
+		writeGraphQLErrors(c, gqlerrors.FormatErrors(fmt.Errorf("Invalid request")))
+		return
+	}
+	if req.Query == "" {
+		writeGraphQLErrors(c, gqlerrors.FormatErrors(fmt.Errorf("query is required")))
+		return
+	}
+
+	op, errs := h.executor.Prepare(req.Query, req.OperationName, req.Variables)
+	if errs != nil {
+		writeGraphQLErrors(c, errs)
+		return
+	}
+	if !op.IsSubscription() {
+		c.JSON(http.StatusOK, h.executor.Execute(c.Request.Context(), op))
+		return
+	}
+
+	results := h.executor.Subscribe(c.Request.Context(), op)
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(EventHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case result, ok := <-results:
+			if !ok {
+				fmt.Fprint(c.Writer, "event: complete\ndata:\n\n")
+				c.Writer.Flush()
+				return
+			}
+			data, err := json.Marshal(result)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(c.Writer, "event: next\ndata: %s\n\n", data)
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": heartbeat\n\n")
+		}
+		c.Writer.Flush()
+	}
+}
+
+// writeGraphQLErrors rejects a request that could not be run at all.
+func writeGraphQLErrors(c *gin.Context, errs []gqlerrors.FormattedError) {
+	c.JSON(http.StatusBadRequest, gin.H{"errors": errs})
+}
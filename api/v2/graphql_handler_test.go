@@ -0,0 +1,156 @@
+package v2
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"todo_project/api/graph"
+	"todo_project/common/eventbus"
+	"todo_project/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestServeGraphQL(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newRouter := func(t *testing.T, mockSv *mockTodoService, mockEs *mockTodoEventSource) *gin.Engine {
+		executor, err := graph.NewExecutor(mockSv, mockEs)
+		assert.NoError(t, err)
+		handler := NewGraphQLHandler(executor)
+		r := gin.Default()
+		r.GET("/test/graphql", handler.ServeGraphQL)
+		r.POST("/test/graphql", handler.ServeGraphQL)
+		return r
+	}
+	post := func(r *gin.Engine, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPost, "/test/graphql", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("children are batched", func(t *testing.T) {
+		mockSv := new(mockTodoService)
+		r := newRouter(t, mockSv, nil)
+
+		parent := 1
+		mockSv.On("ListTodos", mock.MatchedBy(func(query model.TodoQuery) bool {
+			return query.Limit == 2
+		})).Return([]*model.Todo{
+			{ID: 1, Name: "Release"},
+			{ID: 2, Name: "Write docs"},
+		}, nil).Once()
+		mockSv.On("ListTodos", mock.MatchedBy(func(query model.TodoQuery) bool {
+			return query.GroupBy == "parent" && query.Limit == 20 && query.Offset == 0
+		})).Return([]*model.Todo{
+			{ID: 3, Name: "Tag version", ParentID: &parent},
+			{ID: 4, Name: "Publish notes", ParentID: &parent},
+		}, nil).Once()
+
+		w := post(r, `{"query":"{ todos(first: 2) { id children { id name } } }"}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp struct {
+			Data struct {
+				Todos []struct {
+					ID       int `json:"id"`
+					Children []struct {
+						ID int `json:"id"`
+					} `json:"children"`
+				} `json:"todos"`
+			} `json:"data"`
+			Errors []interface{} `json:"errors"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Empty(t, resp.Errors)
+		assert.Len(t, resp.Data.Todos, 2)
+		assert.Len(t, resp.Data.Todos[0].Children, 2)
+		assert.Empty(t, resp.Data.Todos[1].Children)
+		mockSv.AssertNumberOfCalls(t, "ListTodos", 2)
+	})
+
+	t.Run("project todos are paged by the database", func(t *testing.T) {
+		mockSv := new(mockTodoService)
+		r := newRouter(t, mockSv, nil)
+
+		project := 3
+		mockSv.On("ListTodos", mock.MatchedBy(func(query model.TodoQuery) bool {
+			return query.GroupBy == "project" && query.Limit == 2 && query.Offset == 4
+		})).Return([]*model.Todo{
+			{ID: 5, Name: "Fifth", ProjectID: &project},
+			{ID: 6, Name: "Sixth", ProjectID: &project},
+		}, nil).Once()
+
+		w := post(r, `{"query":"{ project(id: 3) { todos(first: 2, offset: 4) { id } } }"}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"data":{"project":{"todos":[{"id":5},{"id":6}]}}}`, w.Body.String())
+		mockSv.AssertExpectations(t)
+	})
+
+	t.Run("too deep", func(t *testing.T) {
+		mockSv := new(mockTodoService)
+		r := newRouter(t, mockSv, nil)
+
+		query := "{ todo(id: 1) { " + strings.Repeat("children { ", graph.MaxDepth) + "id" + strings.Repeat(" }", graph.MaxDepth) + " } }"
+		body, _ := json.Marshal(map[string]string{"query": query})
+		w := post(r, string(body))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "levels deep")
+		mockSv.AssertNotCalled(t, "ListTodos", mock.Anything)
+	})
+
+	t.Run("too complex", func(t *testing.T) {
+		mockSv := new(mockTodoService)
+		r := newRouter(t, mockSv, nil)
+
+		w := post(r, `{"query":"{ todos(first: 100) { children(first: 100) { id } } }"}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "complexity")
+		mockSv.AssertNotCalled(t, "ListTodos", mock.Anything)
+	})
+
+	t.Run("invalid query", func(t *testing.T) {
+		r := newRouter(t, new(mockTodoService), nil)
+
+		w := post(r, `{"query":"{ todos { title } }"}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "title")
+	})
+
+	t.Run("subscription", func(t *testing.T) {
+		mockEs := new(mockTodoEventSource)
+		r := newRouter(t, new(mockTodoService), mockEs)
+
+		live := make(chan eventbus.Message, 2)
+		live <- todoEventMessage("1700000000000-1", "todo.created", `{"id":1,"name":"Release","project_id":3}`)
+		live <- todoEventMessage("1700000000000-2", "todo.created", `{"id":2,"name":"Other","project_id":4}`)
+		close(live)
+		mockEs.On("Subscribe").Return(live).Once()
+
+		req, _ := http.NewRequest(http.MethodGet, "/test/graphql?query="+
+			"subscription+%7B+todoChanged(projectId%3A+3)+%7B+id+type+todo+%7B+name+%7D+%7D+%7D", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+		body := w.Body.String()
+		assert.Equal(t, 1, strings.Count(body, "event: next\n"))
+		assert.Contains(t, body, `"name":"Release"`)
+		assert.NotContains(t, body, `"name":"Other"`)
+		assert.True(t, strings.HasSuffix(body, "event: complete\ndata:\n\n"))
+		mockEs.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,35 @@
+package v2
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+var errInvalidPage = errors.New("page and page_size must be positive integers")
+
+// parsePage reads the page and page_size query parameters, defaulting to the
+// first page of defaultPageSize items and capping page_size at maxPageSize.
+func parsePage(c *gin.Context) (page int, pageSize int, err error) {
+	page, pageSize = 1, defaultPageSize
+	if raw := c.Query("page"); raw != "" {
+		if page, err = strconv.Atoi(raw); err != nil || page < 1 {
+			return 0, 0, errInvalidPage
+		}
+	}
+	if raw := c.Query("page_size"); raw != "" {
+		if pageSize, err = strconv.Atoi(raw); err != nil || pageSize < 1 {
+			return 0, 0, errInvalidPage
+		}
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+	return page, pageSize, nil
+}
@@ -0,0 +1,119 @@
+package v2
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"todo_project/dto"
+	"todo_project/model"
+	"todo_project/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ReminderHandler struct {
+	reminderService service.ReminderService
+}
+
+func NewReminderHandler(reminderService service.ReminderService) *ReminderHandler {
+	return &ReminderHandler{reminderService: reminderService}
+}
+
+func (h *ReminderHandler) CreateReminder(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var req dto.ReminderRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Channel == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	reminder := &model.Reminder{
+		TodoID:   id,
+		RemindAt: req.RemindAt,
+		Offset:   req.Offset,
+		Channel:  req.Channel,
+		Target:   req.Target,
+	}
+	if err := h.reminderService.CreateReminder(c.Request.Context(), reminder); err != nil {
+		writeReminderError(c, err, "Failed to create reminder")
+		return
+	}
+	c.JSON(http.StatusCreated, toReminderResponse(reminder))
+}
+
+// GetReminders lists the reminders of a todo with their delivery status.
+func (h *ReminderHandler) GetReminders(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	reminders, err := h.reminderService.GetReminders(c.Request.Context(), uint(id))
+	if err != nil {
+		writeReminderError(c, err, "Failed to get reminders")
+		return
+	}
+
+	resp := make([]dto.ReminderResponse, 0, len(reminders))
+	for _, reminder := range reminders {
+		resp = append(resp, toReminderResponse(reminder))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *ReminderHandler) CancelReminder(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	if err := h.reminderService.CancelReminder(c.Request.Context(), uint(id)); err != nil {
+		writeReminderError(c, err, "Failed to cancel reminder")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Reminder cancelled successfully"})
+}
+
+func writeReminderError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidReminder):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrReminderNotPending):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
+
+func toReminderResponse(reminder *model.Reminder) dto.ReminderResponse {
+	resp := dto.ReminderResponse{
+		ID:          reminder.ID,
+		TodoID:      reminder.TodoID,
+		Owner:       reminder.Owner,
+		RemindAt:    reminder.RemindAt,
+		Offset:      reminder.Offset,
+		FireAt:      reminder.FireAt,
+		Channel:     reminder.Channel,
+		Target:      reminder.Target,
+		Status:      reminder.Status,
+		Attempts:    reminder.Attempts,
+		LastError:   reminder.LastError,
+		DeliveredAt: reminder.DeliveredAt,
+		CreatedAt:   reminder.CreatedAt,
+	}
+	if reminder.Status == model.ReminderStatusPending && reminder.Attempts > 0 {
+		next := reminder.NextAttemptAt
+		resp.NextAttemptAt = &next
+	}
+	return resp
+}
@@ -0,0 +1,163 @@
+package v2
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"todo_project/dto"
+	"todo_project/model"
+	"todo_project/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// Mock reminder service
+type mockReminderService struct {
+	mock.Mock
+}
+
+func (m *mockReminderService) CreateReminder(ctx context.Context, reminder *model.Reminder) error {
+	args := m.Called(reminder)
+	return args.Error(0)
+}
+
+func (m *mockReminderService) GetReminders(ctx context.Context, todoID uint) ([]*model.Reminder, error) {
+	args := m.Called(todoID)
+	var result []*model.Reminder
+	if args.Get(0) != nil {
+		result = args.Get(0).([]*model.Reminder)
+	}
+
+	return result, args.Error(1)
+}
+
+func (m *mockReminderService) CancelReminder(ctx context.Context, id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *mockReminderService) DueReminders(before time.Time, limit int) ([]*model.Reminder, error) {
+	args := m.Called(before, limit)
+	var result []*model.Reminder
+	if args.Get(0) != nil {
+		result = args.Get(0).([]*model.Reminder)
+	}
+
+	return result, args.Error(1)
+}
+
+func (m *mockReminderService) DeliverReminder(ctx context.Context, id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func TestReminders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockRs := new(mockReminderService)
+	handler := NewReminderHandler(mockRs)
+
+	r := gin.Default()
+	r.POST("/test/todo/:id/reminders", handler.CreateReminder)
+	r.GET("/test/todo/:id/reminders", handler.GetReminders)
+	r.DELETE("/test/reminders/:id", handler.CancelReminder)
+
+	due := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+
+	t.Run("create relative to due", func(t *testing.T) {
+		mockRs.On("CreateReminder", mock.MatchedBy(func(reminder *model.Reminder) bool {
+			return reminder.TodoID == 1 && reminder.Offset != nil && *reminder.Offset == -60 &&
+				reminder.RemindAt == nil && reminder.Channel == "webhook"
+		})).Run(func(args mock.Arguments) {
+			reminder := args.Get(0).(*model.Reminder)
+			reminder.ID = 5
+			reminder.FireAt = due.Add(-time.Hour)
+			reminder.Status = model.ReminderStatusPending
+		}).Return(nil).Once()
+
+		offset := -60
+		body, _ := json.Marshal(dto.ReminderRequest{Offset: &offset, Channel: "webhook", Target: "https://example.com/hook"})
+		req, _ := http.NewRequest(http.MethodPost, "/test/todo/1/reminders", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var resp dto.ReminderResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, uint(5), resp.ID)
+		assert.True(t, resp.FireAt.Equal(due.Add(-time.Hour)))
+		assert.Equal(t, model.ReminderStatusPending, resp.Status)
+	})
+
+	t.Run("create without due date", func(t *testing.T) {
+		mockRs.On("CreateReminder", mock.Anything).
+			Return(fmt.Errorf("%w: a relative reminder needs the todo to have a due_at", service.ErrInvalidReminder)).Once()
+
+		offset := -60
+		body, _ := json.Marshal(dto.ReminderRequest{Offset: &offset, Channel: "log"})
+		req, _ := http.NewRequest(http.MethodPost, "/test/todo/2/reminders", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	})
+
+	t.Run("create without channel", func(t *testing.T) {
+		body, _ := json.Marshal(dto.ReminderRequest{RemindAt: &due})
+		req, _ := http.NewRequest(http.MethodPost, "/test/todo/1/reminders", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("list with delivery status", func(t *testing.T) {
+		delivered := due.Add(-time.Hour)
+		mockRs.On("GetReminders", uint(1)).Return([]*model.Reminder{
+			{ID: 5, TodoID: 1, Channel: "log", Status: model.ReminderStatusDelivered, Attempts: 1, DeliveredAt: &delivered},
+			{ID: 6, TodoID: 1, Channel: "webhook", Status: model.ReminderStatusPending, Attempts: 2,
+				NextAttemptAt: due.Add(time.Minute), LastError: "webhook responded with 502 Bad Gateway"},
+		}, nil).Once()
+
+		req, _ := http.NewRequest(http.MethodGet, "/test/todo/1/reminders", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp []dto.ReminderResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Len(t, resp, 2)
+		assert.NotNil(t, resp[0].DeliveredAt)
+		assert.Nil(t, resp[0].NextAttemptAt)
+		assert.NotNil(t, resp[1].NextAttemptAt)
+		assert.Equal(t, 2, resp[1].Attempts)
+	})
+
+	t.Run("cancel reminder being delivered", func(t *testing.T) {
+		mockRs.On("CancelReminder", uint(8)).Return(service.ErrReminderNotPending).Once()
+
+		req, _ := http.NewRequest(http.MethodDelete, "/test/reminders/8", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("cancel missing reminder", func(t *testing.T) {
+		mockRs.On("CancelReminder", uint(9)).Return(gorm.ErrRecordNotFound).Once()
+
+		req, _ := http.NewRequest(http.MethodDelete, "/test/reminders/9", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
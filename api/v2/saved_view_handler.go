@@ -0,0 +1,168 @@
+package v2
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"todo_project/common/actor"
+	"todo_project/dto"
+	"todo_project/model"
+	"todo_project/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type SavedViewHandler struct {
+	viewService service.SavedViewService
+}
+
+func NewSavedViewHandler(viewService service.SavedViewService) *SavedViewHandler {
+	return &SavedViewHandler{viewService: viewService}
+}
+
+func (h *SavedViewHandler) CreateView(c *gin.Context) {
+	var req dto.SavedViewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	view := &model.SavedView{
+		Name:   req.Name,
+		Filter: req.Filter,
+		Sort:   req.Sort,
+		Shared: req.Shared,
+	}
+	if err := h.viewService.CreateView(c.Request.Context(), view); err != nil {
+		writeViewError(c, err, "Failed to create view")
+		return
+	}
+
+	c.JSON(http.StatusCreated, toSavedViewResponse(c, view))
+}
+
+func (h *SavedViewHandler) GetViews(c *gin.Context) {
+	views, err := h.viewService.ListViews(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get views"})
+		return
+	}
+
+	resp := make([]dto.SavedViewResponse, 0, len(views))
+	for _, view := range views {
+		resp = append(resp, toSavedViewResponse(c, view))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *SavedViewHandler) GetView(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	view, err := h.viewService.GetView(c.Request.Context(), uint(id))
+	if err != nil {
+		writeViewError(c, err, "Failed to get view")
+		return
+	}
+	c.JSON(http.StatusOK, toSavedViewResponse(c, view))
+}
+
+func (h *SavedViewHandler) UpdateView(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var req dto.SavedViewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	view := &model.SavedView{
+		ID:     uint(id),
+		Name:   req.Name,
+		Filter: req.Filter,
+		Sort:   req.Sort,
+		Shared: req.Shared,
+	}
+	if err := h.viewService.UpdateView(c.Request.Context(), view); err != nil {
+		writeViewError(c, err, "Failed to update view")
+		return
+	}
+	c.JSON(http.StatusOK, toSavedViewResponse(c, view))
+}
+
+func (h *SavedViewHandler) DeleteView(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	if err := h.viewService.DeleteView(c.Request.Context(), uint(id)); err != nil {
+		writeViewError(c, err, "Failed to delete view")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "View deleted successfully"})
+}
+
+// GetViewTodos lists the todos matching a view's filter, in its sort order.
+func (h *SavedViewHandler) GetViewTodos(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	page, pageSize, err := parsePage(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	todos, err := h.viewService.GetViewTodos(c.Request.Context(), uint(id), pageSize, (page-1)*pageSize)
+	if err != nil {
+		writeViewError(c, err, "Failed to get view todos")
+		return
+	}
+
+	resp := make([]dto.TodoResponse, 0, len(todos))
+	for _, todo := range todos {
+		resp = append(resp, dto.NewTodoResponse(todo))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func writeViewError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidView):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrViewReadOnly):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the owner can change this view"})
+	case errors.Is(err, service.ErrViewOwnerUnverified):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Saved views need an API key bound to a user"})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "View not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
+
+func toSavedViewResponse(c *gin.Context, view *model.SavedView) dto.SavedViewResponse {
+	return dto.SavedViewResponse{
+		ID:        view.ID,
+		Name:      view.Name,
+		Owner:     view.Owner,
+		Filter:    view.Filter,
+		Sort:      view.Sort,
+		Shared:    view.Shared,
+		ReadOnly:  !actor.Verified(c.Request.Context()) || view.Owner != actor.FromContext(c.Request.Context()),
+		CreatedAt: view.CreatedAt,
+		UpdatedAt: view.UpdatedAt,
+	}
+}
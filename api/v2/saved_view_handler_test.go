@@ -0,0 +1,177 @@
+package v2
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"todo_project/common/actor"
+	"todo_project/dto"
+	"todo_project/model"
+	"todo_project/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// Mock saved view service
+type mockSavedViewService struct {
+	mock.Mock
+}
+
+func (m *mockSavedViewService) CreateView(ctx context.Context, view *model.SavedView) error {
+	args := m.Called(view)
+	return args.Error(0)
+}
+
+func (m *mockSavedViewService) GetView(ctx context.Context, id uint) (*model.SavedView, error) {
+	args := m.Called(id)
+	var result *model.SavedView
+	if args.Get(0) != nil {
+		result = args.Get(0).(*model.SavedView)
+	}
+
+	return result, args.Error(1)
+}
+
+func (m *mockSavedViewService) ListViews(ctx context.Context) ([]*model.SavedView, error) {
+	args := m.Called()
+	var result []*model.SavedView
+	if args.Get(0) != nil {
+		result = args.Get(0).([]*model.SavedView)
+	}
+
+	return result, args.Error(1)
+}
+
+func (m *mockSavedViewService) UpdateView(ctx context.Context, view *model.SavedView) error {
+	args := m.Called(view)
+	return args.Error(0)
+}
+
+func (m *mockSavedViewService) DeleteView(ctx context.Context, id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *mockSavedViewService) GetViewTodos(ctx context.Context, id uint, limit int, offset int) ([]*model.Todo, error) {
+	args := m.Called(id, limit, offset)
+	var result []*model.Todo
+	if args.Get(0) != nil {
+		result = args.Get(0).([]*model.Todo)
+	}
+
+	return result, args.Error(1)
+}
+
+func TestSavedViews(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSv := new(mockSavedViewService)
+	handler := NewSavedViewHandler(mockSv)
+
+	r := gin.Default()
+	r.Use(func(c *gin.Context) {
+		c.Request = c.Request.WithContext(actor.NewVerifiedContext(c.Request.Context(), "alice"))
+	})
+	r.POST("/test/views", handler.CreateView)
+	r.GET("/test/views/:id", handler.GetView)
+	r.PUT("/test/views/:id", handler.UpdateView)
+	r.GET("/test/views/:id/todos", handler.GetViewTodos)
+
+	t.Run("create", func(t *testing.T) {
+		mockSv.On("CreateView", mock.MatchedBy(func(view *model.SavedView) bool {
+			return view.Name == "My doing" && view.Filter == "status:doing" && view.Shared
+		})).Run(func(args mock.Arguments) {
+			view := args.Get(0).(*model.SavedView)
+			view.ID = 4
+			view.Owner = "alice"
+		}).Return(nil).Once()
+
+		body, _ := json.Marshal(dto.SavedViewRequest{Name: "My doing", Filter: "status:doing", Shared: true})
+		req, _ := http.NewRequest(http.MethodPost, "/test/views", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var resp dto.SavedViewResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, uint(4), resp.ID)
+		assert.False(t, resp.ReadOnly)
+	})
+
+	t.Run("create with invalid filter", func(t *testing.T) {
+		mockSv.On("CreateView", mock.Anything).Return(service.ErrInvalidView).Once()
+
+		body, _ := json.Marshal(dto.SavedViewRequest{Name: "Broken", Filter: "priority>=high"})
+		req, _ := http.NewRequest(http.MethodPost, "/test/views", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	})
+
+	t.Run("create without a user key", func(t *testing.T) {
+		mockSv.On("CreateView", mock.Anything).Return(service.ErrViewOwnerUnverified).Once()
+
+		body, _ := json.Marshal(dto.SavedViewRequest{Name: "Mine"})
+		req, _ := http.NewRequest(http.MethodPost, "/test/views", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("shared view is read-only", func(t *testing.T) {
+		mockSv.On("GetView", uint(7)).Return(&model.SavedView{ID: 7, Owner: "bob", Name: "Sprint", Shared: true}, nil).Once()
+
+		req, _ := http.NewRequest(http.MethodGet, "/test/views/7", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp dto.SavedViewResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.True(t, resp.ReadOnly)
+	})
+
+	t.Run("update by teammate", func(t *testing.T) {
+		mockSv.On("UpdateView", mock.Anything).Return(service.ErrViewReadOnly).Once()
+
+		body, _ := json.Marshal(dto.SavedViewRequest{Name: "Mine now"})
+		req, _ := http.NewRequest(http.MethodPut, "/test/views/7", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("todos", func(t *testing.T) {
+		mockSv.On("GetViewTodos", uint(7), 10, 10).Return([]*model.Todo{
+			{ID: 3, Name: "Deploy backend", Status: model.TodoStatusDoing},
+		}, nil).Once()
+
+		req, _ := http.NewRequest(http.MethodGet, "/test/views/7/todos?page=2&page_size=10", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp []dto.TodoResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Len(t, resp, 1)
+	})
+
+	t.Run("private view of someone else", func(t *testing.T) {
+		mockSv.On("GetViewTodos", uint(8), defaultPageSize, 0).Return(nil, gorm.ErrRecordNotFound).Once()
+
+		req, _ := http.NewRequest(http.MethodGet, "/test/views/8/todos", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -0,0 +1,45 @@
+package v2
+
+import (
+	"net/http"
+	"strconv"
+
+	"todo_project/dto"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetBoard returns the Kanban board of a project: one column per status, in
+// workflow order, with its cards in manual order.
+func (h *TodoHandler) GetBoard(c *gin.Context) {
+	projectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	board, err := h.todoService.GetBoard(projectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get board"})
+		return
+	}
+
+	resp := dto.BoardResponse{
+		ProjectID: board.ProjectID,
+		Columns:   make([]dto.BoardColumnResponse, 0, len(board.Columns)),
+	}
+	for _, column := range board.Columns {
+		cards := make([]dto.TodoResponse, 0, len(column.Cards))
+		for _, todo := range column.Cards {
+			cards = append(cards, dto.NewTodoResponse(todo))
+		}
+		resp.Columns = append(resp.Columns, dto.BoardColumnResponse{
+			Status:   column.Status,
+			Count:    len(cards),
+			WIPLimit: column.WIPLimit,
+			Cards:    cards,
+		})
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
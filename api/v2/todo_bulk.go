@@ -0,0 +1,136 @@
+package v2
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"todo_project/api/todocache"
+	"todo_project/dto"
+	"todo_project/model"
+	"todo_project/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// MaxBulkOperations caps how many operations a single bulk request may carry.
+var MaxBulkOperations = 500
+
+func (h *TodoHandler) BulkTodos(c *gin.Context) {
+	var req dto.BulkTodoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	if req.Mode == "" {
+		req.Mode = dto.BulkModeAtomic
+	}
+	if req.Mode != dto.BulkModeAtomic && req.Mode != dto.BulkModeBestEffort {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mode must be " + dto.BulkModeAtomic + " or " + dto.BulkModeBestEffort})
+		return
+	}
+	if len(req.Operations) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "operations must not be empty"})
+		return
+	}
+	if len(req.Operations) > MaxBulkOperations {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "too many operations, the limit is " + strconv.Itoa(MaxBulkOperations)})
+		return
+	}
+
+	ops := make([]service.BulkOperation, len(req.Operations))
+	for i, item := range req.Operations {
+		ops[i] = toBulkOperation(item)
+	}
+
+	results := h.todoService.BulkApply(c.Request.Context(), ops, req.Mode == dto.BulkModeAtomic)
+
+	resp := dto.BulkTodoResponse{
+		Mode:    req.Mode,
+		Results: make([]dto.BulkTodoResult, len(results)),
+	}
+	httpStatus := http.StatusOK
+	for i, result := range results {
+		item := dto.BulkTodoResult{
+			Index:  i,
+			Op:     result.Op,
+			ID:     int(result.ID),
+			Status: bulkResultStatus(result),
+		}
+		if result.Err != nil {
+			item.Error = result.Err.Error()
+			resp.Failed++
+			// An atomic batch reports the status of the operation that broke it.
+			if req.Mode == dto.BulkModeAtomic && !errors.Is(result.Err, service.ErrBulkRolledBack) && !errors.Is(result.Err, service.ErrBulkSkipped) {
+				httpStatus = item.Status
+			}
+		} else {
+			resp.Succeeded++
+			todocache.Evict(h.redisClient, int(result.ID))
+		}
+		if result.Todo != nil {
+			todo := dto.NewTodoResponse(result.Todo)
+			item.Todo = &todo
+		}
+		resp.Results[i] = item
+	}
+	if req.Mode == dto.BulkModeBestEffort && resp.Failed > 0 {
+		httpStatus = http.StatusMultiStatus
+	}
+
+	c.JSON(httpStatus, resp)
+}
+
+func toBulkOperation(item dto.BulkTodoOperation) service.BulkOperation {
+	op := service.BulkOperation{
+		Op:      item.Op,
+		ID:      uint(item.ID),
+		Version: item.Version,
+	}
+	switch item.Op {
+	case service.BulkOpCreate:
+		op.Todo = &model.Todo{}
+		if item.Name != nil {
+			op.Todo.Name = *item.Name
+		}
+		if item.Description != nil {
+			op.Todo.Description = *item.Description
+		}
+		if item.Status != nil {
+			op.Todo.Status = *item.Status
+		}
+	default:
+		op.Changes = map[string]interface{}{}
+		if item.Name != nil {
+			op.Changes["name"] = *item.Name
+		}
+		if item.Description != nil {
+			op.Changes["description"] = *item.Description
+		}
+		if item.Status != nil {
+			op.Changes["status"] = *item.Status
+		}
+	}
+	return op
+}
+
+func bulkResultStatus(result service.BulkResult) int {
+	switch {
+	case result.Err == nil && result.Op == service.BulkOpCreate:
+		return http.StatusCreated
+	case result.Err == nil:
+		return http.StatusOK
+	case errors.Is(result.Err, service.ErrBulkRolledBack), errors.Is(result.Err, service.ErrBulkSkipped):
+		return http.StatusFailedDependency
+	case errors.Is(result.Err, service.ErrInvalidTodo):
+		return http.StatusUnprocessableEntity
+	case errors.Is(result.Err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(result.Err, service.ErrVersionConflict), errors.Is(result.Err, service.ErrWIPLimitReached):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,115 @@
+package v2
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"todo_project/common/eventbus"
+	"todo_project/common/log"
+	"todo_project/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	// EventHeartbeat is how often an idle event stream sends a comment to keep
+	// proxies from closing it.
+	EventHeartbeat = 15 * time.Second
+)
+
+type TodoEventsHandler struct {
+	events service.TodoEventSource
+}
+
+func NewTodoEventsHandler(events service.TodoEventSource) *TodoEventsHandler {
+	return &TodoEventsHandler{events: events}
+}
+
+// StreamTodoEvents streams todo changes as Server-Sent Events, optionally
+// only those of one project. Each event carries its stream offset as id, so
+// a client reconnecting with Last-Event-ID first receives what it missed.
+func (h *TodoEventsHandler) StreamTodoEvents(c *gin.Context) {
+	if h.events == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Event stream is not available"})
+		return
+	}
+	var project *int
+	if value := c.Query("project"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project"})
+			return
+		}
+		project = &id
+	}
+
+	// Subscribe before catching up so nothing published meanwhile is lost.
+	live, stop := h.events.Subscribe()
+	defer stop()
+
+	last := c.GetHeader("Last-Event-ID")
+	var backlog []eventbus.Message
+	if last != "" {
+		var err error
+		backlog, err = h.events.Since(last, service.MaxResumeEvents)
+		if err != nil {
+			log.Errorf("Failed to replay todo events after %s: %v", last, err)
+		}
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", 3000)
+
+	send := func(event eventbus.Message) {
+		if last != "" && !eventbus.After(event.ID, last) {
+			return
+		}
+		last = event.ID
+		if project != nil && !topicMatches("project", *project, event.AggregateID, eventProject(event)) {
+			return
+		}
+		fmt.Fprintf(c.Writer, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Payload)
+	}
+	for _, event := range backlog {
+		send(event)
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(EventHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-live:
+			if !ok {
+				// Dropped for falling behind; the client reconnects and resumes.
+				return
+			}
+			send(event)
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": heartbeat\n\n")
+		}
+		c.Writer.Flush()
+	}
+}
+
+// eventProject returns the project of the todo an event is about.
+func eventProject(event eventbus.Message) *int {
+	var payload struct {
+		Todo struct {
+			ProjectID *int `json:"project_id"`
+		} `json:"todo"`
+	}
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		return nil
+	}
+	return payload.Todo.ProjectID
+}
@@ -0,0 +1,113 @@
+package v2
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"todo_project/common/eventbus"
+	"todo_project/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock todo event source
+type mockTodoEventSource struct {
+	mock.Mock
+}
+
+func (m *mockTodoEventSource) Subscribe() (<-chan eventbus.Message, func()) {
+	args := m.Called()
+	return args.Get(0).(chan eventbus.Message), func() {}
+}
+
+func (m *mockTodoEventSource) Since(offset string, limit int64) ([]eventbus.Message, error) {
+	args := m.Called(offset, limit)
+	var result []eventbus.Message
+	if args.Get(0) != nil {
+		result = args.Get(0).([]eventbus.Message)
+	}
+
+	return result, args.Error(1)
+}
+
+func todoEventMessage(id string, eventType string, todo string) eventbus.Message {
+	return eventbus.Message{
+		ID:      id,
+		EventID: id + "-" + eventType,
+		Type:    eventType,
+		Payload: json.RawMessage(`{"event":"` + eventType + `","todo":` + todo + `}`),
+	}
+}
+
+func TestStreamTodoEvents(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("resume then live", func(t *testing.T) {
+		mockEs := new(mockTodoEventSource)
+		handler := NewTodoEventsHandler(mockEs)
+		r := gin.Default()
+		r.GET("/test/todo/events", handler.StreamTodoEvents)
+
+		live := make(chan eventbus.Message, 3)
+		// The first live event was also replayed and must not be sent twice.
+		live <- todoEventMessage("1700000000000-1", "todo.updated", `{"id":1}`)
+		live <- todoEventMessage("1700000000001-0", "todo.deleted", `{"id":2}`)
+		close(live)
+		mockEs.On("Subscribe").Return(live).Once()
+		mockEs.On("Since", "1700000000000-0", service.MaxResumeEvents).Return([]eventbus.Message{
+			todoEventMessage("1700000000000-1", "todo.updated", `{"id":1}`),
+		}, nil).Once()
+
+		req, _ := http.NewRequest(http.MethodGet, "/test/todo/events", nil)
+		req.Header.Set("Last-Event-ID", "1700000000000-0")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+		body := w.Body.String()
+		assert.Equal(t, 1, strings.Count(body, "id: 1700000000000-1\n"))
+		assert.Contains(t, body, "id: 1700000000001-0\nevent: todo.deleted\ndata: ")
+		assert.Less(t, strings.Index(body, "1700000000000-1"), strings.Index(body, "1700000000001-0"))
+		mockEs.AssertExpectations(t)
+	})
+
+	t.Run("only one project", func(t *testing.T) {
+		mockEs := new(mockTodoEventSource)
+		handler := NewTodoEventsHandler(mockEs)
+		r := gin.Default()
+		r.GET("/test/todo/events", handler.StreamTodoEvents)
+
+		live := make(chan eventbus.Message, 2)
+		live <- todoEventMessage("1700000000000-1", "todo.created", `{"id":1,"project_id":3}`)
+		live <- todoEventMessage("1700000000000-2", "todo.created", `{"id":2,"project_id":4}`)
+		close(live)
+		mockEs.On("Subscribe").Return(live).Once()
+
+		req, _ := http.NewRequest(http.MethodGet, "/test/todo/events?project=3", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		body := w.Body.String()
+		assert.Contains(t, body, "id: 1700000000000-1\n")
+		assert.NotContains(t, body, "id: 1700000000000-2\n")
+		mockEs.AssertNotCalled(t, "Since", mock.Anything, mock.Anything)
+	})
+
+	t.Run("without redis", func(t *testing.T) {
+		handler := NewTodoEventsHandler(nil)
+		r := gin.Default()
+		r.GET("/test/todo/events", handler.StreamTodoEvents)
+
+		req, _ := http.NewRequest(http.MethodGet, "/test/todo/events", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+}
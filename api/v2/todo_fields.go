@@ -0,0 +1,127 @@
+package v2
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"todo_project/common/filter"
+	"todo_project/dto"
+	"todo_project/model"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// todoSelection is what a client asked to see of each todo: a sparse
+// fieldset from ?fields= and the relations to embed from ?include=.
+type todoSelection struct {
+	fields  []string
+	include []string
+}
+
+// parseTodoSelection reads the fields and include query parameters, both
+// comma separated.
+func parseTodoSelection(c *gin.Context) (todoSelection, error) {
+	var selection todoSelection
+	for _, field := range splitList(c.Query("fields")) {
+		if _, ok := model.TodoColumns[field]; !ok {
+			return selection, fmt.Errorf("unknown field %q, expected one of %s", field, strings.Join(sortedKeys(model.TodoColumns), ", "))
+		}
+		selection.fields = append(selection.fields, field)
+	}
+	for _, relation := range splitList(c.Query("include")) {
+		if relation != model.TodoIncludeChildren {
+			return selection, fmt.Errorf("unknown relation %q, expected %s", relation, model.TodoIncludeChildren)
+		}
+		selection.include = append(selection.include, relation)
+	}
+	return selection, nil
+}
+
+func (s todoSelection) empty() bool {
+	return len(s.fields) == 0 && len(s.include) == 0
+}
+
+// apply narrows query to the columns and relations selected. The ID and
+// version are always loaded, for ETags and to attach relations.
+func (s todoSelection) apply(query *model.TodoQuery) {
+	if len(s.fields) > 0 {
+		columns := []string{"id", "version"}
+		for _, field := range s.fields {
+			if column := model.TodoColumns[field]; column != "id" && column != "version" {
+				columns = append(columns, column)
+			}
+		}
+		query.Columns = columns
+	}
+	query.Include = s.include
+}
+
+// findSelectedTodo loads the todo id with only what selection needs.
+func (h *TodoHandler) findSelectedTodo(id int, selection todoSelection) (*model.Todo, error) {
+	query := model.TodoQuery{Filter: filter.Comparison{Field: "id", Op: filter.OpEqual, Value: strconv.Itoa(id)}}
+	selection.apply(&query)
+	todos, err := h.todoService.ListTodos(query)
+	if err != nil {
+		return nil, err
+	}
+	if len(todos) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return todos[0], nil
+}
+
+// render returns the representation of todo holding the selected fields, in
+// the usual todo representation when nothing was selected. Selected fields
+// without a value are null, and included relations are rendered with the same
+// fields.
+func (s todoSelection) render(todo *model.Todo) interface{} {
+	if s.empty() {
+		return dto.NewTodoResponse(todo)
+	}
+
+	var full map[string]interface{}
+	data, _ := json.Marshal(dto.NewTodoResponse(todo))
+	_ = json.Unmarshal(data, &full)
+	out := full
+	if len(s.fields) > 0 {
+		out = make(map[string]interface{}, len(s.fields)+len(s.include))
+		for _, field := range s.fields {
+			out[field] = full[field]
+		}
+	}
+
+	for _, relation := range s.include {
+		if relation == model.TodoIncludeChildren {
+			children := todoSelection{fields: s.fields}
+			rendered := make([]interface{}, 0, len(todo.Children))
+			for _, child := range todo.Children {
+				rendered = append(rendered, children.render(child))
+			}
+			out[relation] = rendered
+		}
+	}
+	return out
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
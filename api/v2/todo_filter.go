@@ -0,0 +1,39 @@
+package v2
+
+import (
+	"todo_project/common/filter"
+	"todo_project/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseFilter reads the `filter` query parameter and checks it against the
+// fields todos can be filtered on. It returns a nil Expr when the parameter
+// is absent.
+func parseFilter(c *gin.Context) (filter.Expr, error) {
+	text := c.Query("filter")
+	if text == "" {
+		return nil, nil
+	}
+	expr, err := filter.Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	if err := filter.Validate(expr, model.TodoFilterFields); err != nil {
+		return nil, err
+	}
+	return expr, nil
+}
+
+// parseSort reads the `sort` query parameter, a comma-separated list of
+// filterable fields with '-' for descending order.
+func parseSort(c *gin.Context) ([]filter.SortKey, error) {
+	keys, err := filter.ParseSort(c.Query("sort"))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := filter.CompileSort(keys, model.TodoFilterFields); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
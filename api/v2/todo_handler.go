@@ -4,8 +4,10 @@ import (
 	"net/http"
 	"strconv"
 	"fmt"
+	"errors"
 	"encoding/json"
 
+	"todo_project/api/todocache"
 	"todo_project/dto"
 	"todo_project/model"
 	"todo_project/service"
@@ -13,6 +15,7 @@ import (
 
 	"github.com/sirupsen/logrus"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type TodoHandler struct {
@@ -36,18 +39,28 @@ func (h *TodoHandler) CreateTodo(c *gin.Context) {
 	obj := &model.Todo{ // ne : not equal
 		Name: req.Name, // ne : not equal
 		Description: req.Description,
+		ProjectID: req.ProjectID,
+		ParentID: req.ParentID,
+		DueAt: req.DueAt,
+		Recurrence: req.Recurrence,
+		Timezone: req.Timezone,
 	}
 
-	if err := h.todoService.CreateTodo(obj); err != nil {
+	if err := h.todoService.CreateTodo(c.Request.Context(), obj); err != nil {
+		if errors.Is(err, service.ErrWIPLimitReached) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, service.ErrInvalidTodo) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create todo"})
 		return
 	}
 
-	resp := dto.TodoResponse{
-		ID: obj.ID,
-		Name: obj.Name,
-		Description: obj.Description,
-	}
+	resp := dto.NewTodoResponse(obj)
+	todocache.InvalidateAutocomplete(h.redisClient)
 
 	if h.redisClient != nil {
 		// convert struct to json
@@ -55,7 +68,7 @@ func (h *TodoHandler) CreateTodo(c *gin.Context) {
 		if err != nil {
 			fmt.Println("Can not convert data")
 		} else {
-			redisKey := "todo_" + strconv.Itoa(resp.ID)
+			redisKey := todocache.TodoKey(resp.ID)
 			str, err := h.redisClient.Set(redisKey, string(respJSON))
 			if err != nil {
 				fmt.Println("Can not set data to redis cache")
@@ -66,6 +79,7 @@ func (h *TodoHandler) CreateTodo(c *gin.Context) {
 	} else {
 		fmt.Println("Redis is not ready")
 	}
+	c.Header("ETag", dto.TodoETag(obj))
 	c.JSON(http.StatusCreated, resp)
 }
 
@@ -76,28 +90,52 @@ func (h *TodoHandler) GetTodo(c *gin.Context) {
 		return
 	}
 
-	obj, err := h.todoService.GetTodoByID(uint(id))
+	selection, err := parseTodoSelection(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var obj *model.Todo
+	if selection.empty() {
+		obj, err = h.todoService.GetTodoByID(uint(id))
+	} else {
+		obj, err = h.findSelectedTodo(id, selection)
+	}
 	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) && !selection.empty() {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get todo"})
+			return
+		}
 		c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
 		return
 	}
 
-	resp := &dto.TodoResponse{
-		ID: obj.ID,
-		Name: obj.Name,
-		Description: obj.Description,
+	etag := dto.TodoETag(obj)
+	c.Header("ETag", etag)
+	if ifNoneMatch := c.GetHeader("If-None-Match"); ifNoneMatch != "" && etagMatches(ifNoneMatch, etag) {
+		c.Status(http.StatusNotModified)
+		return
 	}
+
+	// The cached copy holds every field, so selections are served as loaded.
+	if !selection.empty() {
+		c.JSON(http.StatusOK, selection.render(obj))
+		return
+	}
+
+	resp := dto.NewTodoResponse(obj)
 	if h.redisClient != nil {
 		_, err := json.Marshal(resp)
 		if err != nil {
 			fmt.Println("Can not convert data")
 		} else {
-			redisKey := "todo_" + strconv.Itoa(resp.ID)
+			redisKey := todocache.TodoKey(resp.ID)
 			str, err := h.redisClient.Get(redisKey)
 			if err != nil {
 				fmt.Println("No data found on cache")
 			} else {
-				err := json.Unmarshal([]byte(str), resp)
+				err := json.Unmarshal([]byte(str), &resp)
 				if err != nil {
 					logrus.Info("Failed to Unmarshal")
 				}
@@ -111,19 +149,40 @@ func (h *TodoHandler) GetTodo(c *gin.Context) {
 }
 
 func (h *TodoHandler) GetAllTodos(c *gin.Context) {
-	list, err := h.todoService.GetAllTodos()
+	expr, err := parseFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sortKeys, err := parseSort(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	selection, err := parseTodoSelection(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var list []*model.Todo
+	if expr != nil || sortKeys != nil || !selection.empty() {
+		query := model.TodoQuery{Filter: expr, Sort: sortKeys}
+		selection.apply(&query)
+		list, err = h.todoService.ListTodos(query)
+	} else {
+		list, err = h.todoService.GetAllTodos()
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get todos"})
 		return
 	}
 
-	var resp []dto.TodoResponse
+	var resp []interface{}
 	for _, s := range list {
-		resp = append(resp, dto.TodoResponse{
-			ID: s.ID,
-			Name: s.Name,
-			Description: s.Description,
-		})
+		resp = append(resp, selection.render(s))
 	}
 
 	c.JSON(http.StatusOK, resp)
@@ -142,32 +201,126 @@ func (h *TodoHandler) UpdateTodo(c *gin.Context) {
 		return
 	}
 
+	if !checkIfMatch(c, existingTodo) {
+		return
+	}
+
 	var req dto.UpdateTodoRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
 		return
 	}
 
-	todo := &model.Todo{
-		ID: existingTodo.ID,
-		Name: req.Name,
-		Description: req.Description,
-	}
+	todo := *existingTodo
+	todo.Name = req.Name
+	todo.Description = req.Description
 
-	if err := h.todoService.UpdateTodo(todo); err != nil {
+	if err := h.todoService.UpdateTodo(c.Request.Context(), &todo); err != nil {
+		if errors.Is(err, service.ErrVersionConflict) {
+			writeVersionConflict(c)
+			return
+		}
+		if errors.Is(err, service.ErrWIPLimitReached) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update todo"})
 		return
 	}
 
-	resp := dto.TodoResponse{
-		ID: todo.ID,
-		Name: todo.Name,
-		Description: todo.Description,
+	todocache.Evict(h.redisClient, id)
+
+	resp := dto.NewTodoResponse(&todo)
+
+	c.Header("ETag", dto.TodoETag(&todo))
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *TodoHandler) PatchTodo(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	existingTodo, err := h.todoService.GetTodoByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
+		return
+	}
+
+	if !checkIfMatch(c, existingTodo) {
+		return
+	}
+
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
 	}
 
+	changes, err := applyTodoPatch(existingTodo, c.ContentType(), body)
+	if err != nil {
+		switch {
+		case errors.Is(err, errUnsupportedPatchType):
+			c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Content-Type must be " + MergePatchContentType + " or " + JSONPatchContentType})
+		case errors.Is(err, errMalformedPatch):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, errPatchConflict):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	todo, err := h.todoService.PatchTodo(c.Request.Context(), uint(id), existingTodo.Version, changes)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrVersionConflict):
+			writeVersionConflict(c)
+		case errors.Is(err, service.ErrWIPLimitReached):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrInvalidTodo):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update todo"})
+		}
+		return
+	}
+
+	todocache.Evict(h.redisClient, id)
+
+	resp := dto.NewTodoResponse(todo)
+
+	c.Header("ETag", dto.TodoETag(todo))
 	c.JSON(http.StatusOK, resp)
 }
 
+// checkIfMatch enforces the If-Match precondition against the current state of
+// todo. It writes a 412 response and returns false when the precondition fails.
+func checkIfMatch(c *gin.Context, todo *model.Todo) bool {
+	ifMatch := c.GetHeader("If-Match")
+	if ifMatch == "" || etagMatches(ifMatch, dto.TodoETag(todo)) {
+		return true
+	}
+	c.Header("ETag", dto.TodoETag(todo))
+	c.JSON(http.StatusPreconditionFailed, gin.H{"error": "Todo has been modified"})
+	return false
+}
+
+// writeVersionConflict reports a write that lost the race against another
+// update. Clients that sent If-Match get 412, everyone else 409.
+func writeVersionConflict(c *gin.Context) {
+	if c.GetHeader("If-Match") != "" {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "Todo has been modified"})
+		return
+	}
+	c.JSON(http.StatusConflict, gin.H{"error": "Todo was modified concurrently, please retry"})
+}
+
 func (h *TodoHandler) DeleteTodo(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
@@ -181,7 +334,7 @@ func (h *TodoHandler) DeleteTodo(c *gin.Context) {
 		return
 	}
 
-	err = h.todoService.DeleteTodo(uint(id))
+	err = h.todoService.DeleteTodo(c.Request.Context(), uint(id))
 	if err != nil {
 		if err.Error() == "id not found" {
 			c.JSON(http.StatusNotFound, gin.H{"error": "ID not found"})
@@ -192,7 +345,7 @@ func (h *TodoHandler) DeleteTodo(c *gin.Context) {
 	}
 
 	if h.redisClient != nil {
-		redisKey := "todo_" + strconv.Itoa(id)
+		redisKey := todocache.TodoKey(id)
 		deletedCount, err := h.redisClient.Delete(redisKey)
 		if err != nil {
 			fmt.Println("❌ Failed to delete from Redis cache:", err.Error())
@@ -2,18 +2,24 @@ package v2
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"todo_project/common/filter"
 	"todo_project/dto"
 	"todo_project/model"
+	"todo_project/service"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
 )
 
 // Mock test service
@@ -21,7 +27,7 @@ type mockTodoService struct {
 	mock.Mock
 }
 
-func (m *mockTodoService) CreateTodo(t *model.Todo) error {
+func (m *mockTodoService) CreateTodo(ctx context.Context, t *model.Todo) error {
 	args := m.Called(t)
 	return args.Error(0)
 }
@@ -47,16 +53,156 @@ func (m *mockTodoService) GetAllTodos() ([]*model.Todo, error) {
 	return result, args.Error(1)
 }
 
-func (m *mockTodoService) UpdateTodo(t *model.Todo) error {
+func (m *mockTodoService) UpdateTodo(ctx context.Context, t *model.Todo) error {
 	args := m.Called(t)
 	return args.Error(0)
 }
 
-func (m *mockTodoService) DeleteTodo(id uint) error {
+func (m *mockTodoService) PatchTodo(ctx context.Context, id uint, version int, changes map[string]interface{}) (*model.Todo, error) {
+	args := m.Called(id, version, changes)
+	var result *model.Todo
+	if args.Get(0) != nil {
+		result = args.Get(0).(*model.Todo)
+	}
+
+	return result, args.Error(1)
+}
+
+func (m *mockTodoService) DeleteTodo(ctx context.Context, id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *mockTodoService) BulkApply(ctx context.Context, ops []service.BulkOperation, atomic bool) []service.BulkResult {
+	args := m.Called(ops, atomic)
+	return args.Get(0).([]service.BulkResult)
+}
+
+func (m *mockTodoService) GetTrash() ([]*model.Todo, error) {
+	args := m.Called()
+	var result []*model.Todo
+	if args.Get(0) != nil {
+		result = args.Get(0).([]*model.Todo)
+	}
+
+	return result, args.Error(1)
+}
+
+func (m *mockTodoService) RestoreTodo(ctx context.Context, id uint) (*model.Todo, error) {
+	args := m.Called(id)
+	var result *model.Todo
+	if args.Get(0) != nil {
+		result = args.Get(0).(*model.Todo)
+	}
+
+	return result, args.Error(1)
+}
+
+func (m *mockTodoService) PurgeTodo(ctx context.Context, id uint) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
 
+func (m *mockTodoService) PurgeTrash(olderThan time.Duration) (int64, error) {
+	args := m.Called(olderThan)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *mockTodoService) GetTodoHistory(id uint) ([]*model.TodoHistory, error) {
+	args := m.Called(id)
+	var result []*model.TodoHistory
+	if args.Get(0) != nil {
+		result = args.Get(0).([]*model.TodoHistory)
+	}
+
+	return result, args.Error(1)
+}
+
+func (m *mockTodoService) RevertTodo(ctx context.Context, id uint, version int, target int) (*model.Todo, error) {
+	args := m.Called(id, version, target)
+	var result *model.Todo
+	if args.Get(0) != nil {
+		result = args.Get(0).(*model.Todo)
+	}
+
+	return result, args.Error(1)
+}
+
+func (m *mockTodoService) SearchTodos(search model.TodoSearch, query model.TodoQuery) ([]*model.TodoSearchResult, int64, error) {
+	args := m.Called(search, query)
+	var result []*model.TodoSearchResult
+	if args.Get(0) != nil {
+		result = args.Get(0).([]*model.TodoSearchResult)
+	}
+
+	return result, args.Get(1).(int64), args.Error(2)
+}
+
+func (m *mockTodoService) SuggestTodoNames(search model.TodoSearch, limit int) ([]string, error) {
+	args := m.Called(search, limit)
+	var result []string
+	if args.Get(0) != nil {
+		result = args.Get(0).([]string)
+	}
+
+	return result, args.Error(1)
+}
+
+func (m *mockTodoService) AutocompleteTodoNames(prefix string, limit int) ([]string, error) {
+	args := m.Called(prefix, limit)
+	var result []string
+	if args.Get(0) != nil {
+		result = args.Get(0).([]string)
+	}
+
+	return result, args.Error(1)
+}
+
+func (m *mockTodoService) ListTodos(query model.TodoQuery) ([]*model.Todo, error) {
+	args := m.Called(query)
+	var result []*model.Todo
+	if args.Get(0) != nil {
+		result = args.Get(0).([]*model.Todo)
+	}
+
+	return result, args.Error(1)
+}
+
+func (m *mockTodoService) MoveTodo(ctx context.Context, id uint, version int, target uint, after bool) (*model.Todo, error) {
+	args := m.Called(id, version, target, after)
+	var result *model.Todo
+	if args.Get(0) != nil {
+		result = args.Get(0).(*model.Todo)
+	}
+
+	return result, args.Error(1)
+}
+
+func (m *mockTodoService) RebalancePositions() (int, error) {
+	args := m.Called()
+	return args.Int(0), args.Error(1)
+}
+
+func (m *mockTodoService) GetBoard(projectID int) (*service.Board, error) {
+	args := m.Called(projectID)
+	var result *service.Board
+	if args.Get(0) != nil {
+		result = args.Get(0).(*service.Board)
+	}
+
+	return result, args.Error(1)
+}
+
+func (m *mockTodoService) PreviewOccurrences(id uint, n int) ([]time.Time, error) {
+	args := m.Called(id, n)
+	var result []time.Time
+	if args.Get(0) != nil {
+		result = args.Get(0).([]time.Time)
+	}
+
+	return result, args.Error(1)
+}
+
 // Mock redis client
 type mockRedisClient struct {
 	mock.Mock
@@ -72,6 +218,16 @@ func (m *mockRedisClient) Set(key string, value interface{}) (string, error) {
 	return args.String(0), args.Error(1)
 }
 
+func (m *mockRedisClient) SetEx(key string, value interface{}, expiration time.Duration) (string, error) {
+	args := m.Called(key, value, expiration)
+	return args.String(0), args.Error(1)
+}
+
+func (m *mockRedisClient) SetNX(key string, value interface{}, expiration time.Duration) (bool, error) {
+	args := m.Called(key, value, expiration)
+	return args.Bool(0), args.Error(1)
+}
+
 func (m *mockRedisClient) Get(key string) (string, error) {
 	args := m.Called(key)
 	return args.String(0), args.Error(1)
@@ -82,6 +238,46 @@ func (m *mockRedisClient) Delete(key string) (int64, error) {
 	return int64(args.Int(0)), args.Error(1)
 }
 
+func (m *mockRedisClient) ZAdd(key string, score float64, member string) error {
+	args := m.Called(key, score, member)
+	return args.Error(0)
+}
+
+func (m *mockRedisClient) ZRangeByScore(key string, max float64, limit int64) ([]string, error) {
+	args := m.Called(key, max, limit)
+	var result []string
+	if args.Get(0) != nil {
+		result = args.Get(0).([]string)
+	}
+
+	return result, args.Error(1)
+}
+
+func (m *mockRedisClient) ZRem(key string, member string) (int64, error) {
+	args := m.Called(key, member)
+	return int64(args.Int(0)), args.Error(1)
+}
+
+func (m *mockRedisClient) XAdd(stream string, maxLen int64, values map[string]interface{}) (string, error) {
+	args := m.Called(stream, maxLen, values)
+	return args.String(0), args.Error(1)
+}
+
+func (m *mockRedisClient) XRange(stream string, start string, end string, count int64) ([]redis.XMessage, error) {
+	args := m.Called(stream, start, end, count)
+	var result []redis.XMessage
+	if args.Get(0) != nil {
+		result = args.Get(0).([]redis.XMessage)
+	}
+
+	return result, args.Error(1)
+}
+
+func (m *mockRedisClient) Publish(channel string, message string) error {
+	args := m.Called(channel, message)
+	return args.Error(0)
+}
+
 func (m *mockRedisClient) GetClient() *redis.Client {
 	args := m.Called()
 	return args.Get(0).(*redis.Client)
@@ -98,6 +294,7 @@ func TestCreate(t *testing.T) {
 
 	mockSvc := new(mockTodoService)
 	mockRc := new(mockRedisClient)
+	mockRc.On("Delete", "autocomplete_generation").Return(1, nil).Maybe()
 	handler := &TodoHandler{
 		todoService: mockSvc,
 		redisClient: mockRc,
@@ -190,6 +387,7 @@ func TestGetAll(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockSv := new(mockTodoService)
 	mockRc := new(mockRedisClient)
+	mockRc.On("Delete", "autocomplete_generation").Return(1, nil).Maybe()
 	handler := &TodoHandler{
 		todoService: mockSv,
 		redisClient: mockRc,
@@ -281,6 +479,7 @@ func TestGetAll(t *testing.T) {
 func TestUpdate(t *testing.T) {
 	mockSv := new(mockTodoService)
 	mockRc := new(mockRedisClient)
+	mockRc.On("Delete", "autocomplete_generation").Return(1, nil).Maybe()
 	handler := &TodoHandler{
 		todoService: mockSv,
 		redisClient: mockRc,
@@ -306,6 +505,8 @@ func TestUpdate(t *testing.T) {
 
 		mockSv.On("UpdateTodo", mock.AnythingOfType("*model.Todo")).Return(nil)
 
+		mockRc.On("Delete", mock.AnythingOfType("string")).Return(1, nil)
+
 		r := gin.Default()
 		r.PUT("/test/:id", handler.UpdateTodo)
 		r.ServeHTTP(w, req)
@@ -376,6 +577,7 @@ func TestDelete(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockSv := new(mockTodoService)
 	mockRc := new(mockRedisClient)
+	mockRc.On("Delete", "autocomplete_generation").Return(1, nil).Maybe()
 	handler := &TodoHandler{
 		todoService: mockSv,
 		redisClient: mockRc,
@@ -437,3 +639,844 @@ func TestDelete(t *testing.T) {
 	})
 }
 
+func TestPatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSv := new(mockTodoService)
+	mockRc := new(mockRedisClient)
+	mockRc.On("Delete", "autocomplete_generation").Return(1, nil).Maybe()
+	handler := &TodoHandler{
+		todoService: mockSv,
+		redisClient: mockRc,
+	}
+
+	existing := &model.Todo{ID: 1, Name: "Write docs", Description: "README", Status: model.TodoStatusDoing, Version: 3}
+	mockSv.On("GetTodoByID", uint(1)).Return(existing, nil)
+	mockRc.On("Delete", "todo_1").Return(1, nil)
+
+	r := gin.Default()
+	r.PATCH("/test/:id", handler.PatchTodo)
+
+	t.Run("merge patch", func(t *testing.T) {
+		changes := map[string]interface{}{"status": model.TodoStatusDone}
+		mockSv.On("PatchTodo", uint(1), 3, changes).Return(&model.Todo{
+			ID: 1, Name: "Write docs", Description: "README", Status: model.TodoStatusDone,
+		}, nil).Once()
+
+		req, _ := http.NewRequest(http.MethodPatch, "/test/1", bytes.NewBufferString(`{"status":"done"}`))
+		req.Header.Set("Content-Type", MergePatchContentType)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp dto.TodoResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, model.TodoStatusDone, resp.Status)
+		assert.Equal(t, "README", resp.Description)
+	})
+
+	t.Run("json patch", func(t *testing.T) {
+		changes := map[string]interface{}{"name": "Write more docs"}
+		mockSv.On("PatchTodo", uint(1), 3, changes).Return(&model.Todo{
+			ID: 1, Name: "Write more docs", Description: "README", Status: model.TodoStatusDoing,
+		}, nil).Once()
+
+		body := `[{"op":"test","path":"/status","value":"doing"},{"op":"replace","path":"/name","value":"Write more docs"}]`
+		req, _ := http.NewRequest(http.MethodPatch, "/test/1", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", JSONPatchContentType)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("json patch test failed", func(t *testing.T) {
+		body := `[{"op":"test","path":"/status","value":"done"}]`
+		req, _ := http.NewRequest(http.MethodPatch, "/test/1", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", JSONPatchContentType)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodPatch, "/test/1", bytes.NewBufferString(`{"id":2}`))
+		req.Header.Set("Content-Type", MergePatchContentType)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	})
+
+	t.Run("unsupported content type", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodPatch, "/test/1", bytes.NewBufferString(`{"status":"done"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+	})
+}
+
+func TestETag(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSv := new(mockTodoService)
+	mockRc := new(mockRedisClient)
+	mockRc.On("Delete", "autocomplete_generation").Return(1, nil).Maybe()
+	handler := &TodoHandler{
+		todoService: mockSv,
+		redisClient: mockRc,
+	}
+
+	existing := &model.Todo{ID: 7, Name: "Ship it", Description: "v1", Status: model.TodoStatusDoing, Version: 2}
+	mockSv.On("GetTodoByID", uint(7)).Return(existing, nil)
+	mockRc.On("Get", "todo_7").Return("", errors.New("cache miss"))
+	mockRc.On("Delete", "todo_7").Return(1, nil)
+
+	r := gin.Default()
+	r.GET("/test/:id", handler.GetTodo)
+	r.PUT("/test/:id", handler.UpdateTodo)
+	r.PATCH("/test/:id", handler.PatchTodo)
+
+	t.Run("get sets etag", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/test/7", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `"7-2"`, w.Header().Get("ETag"))
+	})
+
+	t.Run("if-none-match", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/test/7", nil)
+		req.Header.Set("If-None-Match", `"7-1", "7-2"`)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotModified, w.Code)
+	})
+
+	t.Run("if-match mismatch", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodPut, "/test/7", bytes.NewBufferString(`{"id":7,"name":"a","description":"b"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", `"7-1"`)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+		mockSv.AssertNotCalled(t, "UpdateTodo", mock.Anything)
+	})
+
+	t.Run("if-match lost race", func(t *testing.T) {
+		changes := map[string]interface{}{"description": "v2"}
+		mockSv.On("PatchTodo", uint(7), 2, changes).Return(nil, service.ErrVersionConflict).Once()
+
+		req, _ := http.NewRequest(http.MethodPatch, "/test/7", bytes.NewBufferString(`{"description":"v2"}`))
+		req.Header.Set("Content-Type", MergePatchContentType)
+		req.Header.Set("If-Match", `"7-2"`)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	})
+
+	t.Run("put bumps etag", func(t *testing.T) {
+		mockSv.On("UpdateTodo", mock.AnythingOfType("*model.Todo")).Run(func(args mock.Arguments) {
+			args.Get(0).(*model.Todo).Version++
+		}).Return(nil).Once()
+
+		req, _ := http.NewRequest(http.MethodPut, "/test/7", bytes.NewBufferString(`{"id":7,"name":"a","description":"b"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", `"7-2"`)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `"7-3"`, w.Header().Get("ETag"))
+	})
+}
+
+func TestBulk(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSv := new(mockTodoService)
+	mockRc := new(mockRedisClient)
+	mockRc.On("Delete", "autocomplete_generation").Return(1, nil).Maybe()
+	handler := &TodoHandler{
+		todoService: mockSv,
+		redisClient: mockRc,
+	}
+	mockRc.On("Delete", mock.AnythingOfType("string")).Return(1, nil)
+
+	r := gin.Default()
+	r.POST("/test/bulk", handler.BulkTodos)
+
+	t.Run("atomic success", func(t *testing.T) {
+		mockSv.On("BulkApply", mock.Anything, true).Return([]service.BulkResult{
+			{Op: service.BulkOpCreate, ID: 10, Todo: &model.Todo{ID: 10, Name: "a", Description: "b", Status: model.TodoStatusDoing}},
+			{Op: service.BulkOpDelete, ID: 3},
+		}).Once()
+
+		body := `{"operations":[{"op":"create","name":"a","description":"b"},{"op":"delete","id":3}]}`
+		req, _ := http.NewRequest(http.MethodPost, "/test/bulk", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp dto.BulkTodoResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, 2, resp.Succeeded)
+		assert.Equal(t, http.StatusCreated, resp.Results[0].Status)
+		mockRc.AssertCalled(t, "Delete", "todo_3")
+	})
+
+	t.Run("atomic failure", func(t *testing.T) {
+		mockSv.On("BulkApply", mock.Anything, true).Return([]service.BulkResult{
+			{Op: service.BulkOpSetStatus, ID: 1, Err: service.ErrBulkRolledBack},
+			{Op: service.BulkOpSetStatus, ID: 2, Err: gorm.ErrRecordNotFound},
+		}).Once()
+
+		body := `{"mode":"atomic","operations":[{"op":"set_status","id":1,"status":"done"},{"op":"set_status","id":2,"status":"done"}]}`
+		req, _ := http.NewRequest(http.MethodPost, "/test/bulk", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		var resp dto.BulkTodoResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, http.StatusFailedDependency, resp.Results[0].Status)
+		assert.Equal(t, 2, resp.Failed)
+	})
+
+	t.Run("best effort partial failure", func(t *testing.T) {
+		mockSv.On("BulkApply", mock.Anything, false).Return([]service.BulkResult{
+			{Op: service.BulkOpUpdate, ID: 1, Todo: &model.Todo{ID: 1, Name: "x", Description: "y"}},
+			{Op: service.BulkOpUpdate, ID: 2, Err: service.ErrVersionConflict},
+		}).Once()
+
+		body := `{"mode":"best_effort","operations":[{"op":"update","id":1,"name":"x"},{"op":"update","id":2,"version":4,"name":"x"}]}`
+		req, _ := http.NewRequest(http.MethodPost, "/test/bulk", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusMultiStatus, w.Code)
+	})
+
+	t.Run("too many operations", func(t *testing.T) {
+		limit := MaxBulkOperations
+		MaxBulkOperations = 2
+		defer func() { MaxBulkOperations = limit }()
+		send := func(body string) *httptest.ResponseRecorder {
+			req, _ := http.NewRequest(http.MethodPost, "/test/bulk", bytes.NewBufferString(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			return w
+		}
+
+		// Exactly the limit is accepted.
+		mockSv.On("BulkApply", mock.Anything, true).Return([]service.BulkResult{
+			{Op: service.BulkOpDelete, ID: 1},
+			{Op: service.BulkOpDelete, ID: 2},
+		}).Once()
+		w := send(`{"operations":[{"op":"delete","id":1},{"op":"delete","id":2}]}`)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = send(`{"operations":[{"op":"delete","id":1},{"op":"delete","id":2},{"op":"delete","id":3}]}`)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	})
+}
+
+func TestTrash(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSv := new(mockTodoService)
+	mockRc := new(mockRedisClient)
+	mockRc.On("Delete", "autocomplete_generation").Return(1, nil).Maybe()
+	handler := &TodoHandler{
+		todoService: mockSv,
+		redisClient: mockRc,
+	}
+	mockRc.On("Delete", mock.AnythingOfType("string")).Return(1, nil)
+
+	r := gin.Default()
+	r.GET("/trash", handler.GetTrash)
+	r.POST("/test/:id/restore", handler.RestoreTodo)
+	r.DELETE("/trash/:id", handler.PurgeTodo)
+
+	t.Run("list", func(t *testing.T) {
+		deletedAt := time.Date(2025, 7, 1, 10, 0, 0, 0, time.UTC)
+		mockSv.On("GetTrash").Return([]*model.Todo{
+			{ID: 4, Name: "Old", Description: "gone", DeletedAt: gorm.DeletedAt{Time: deletedAt, Valid: true}},
+		}, nil).Once()
+
+		req, _ := http.NewRequest(http.MethodGet, "/trash", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp []dto.TrashedTodoResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Len(t, resp, 1)
+		assert.True(t, deletedAt.Equal(resp[0].DeletedAt))
+	})
+
+	t.Run("restore", func(t *testing.T) {
+		mockSv.On("RestoreTodo", uint(4)).Return(&model.Todo{ID: 4, Name: "Old", Description: "gone", Version: 2}, nil).Once()
+
+		req, _ := http.NewRequest(http.MethodPost, "/test/4/restore", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockRc.AssertCalled(t, "Delete", "todo_4")
+	})
+
+	t.Run("restore not in trash", func(t *testing.T) {
+		mockSv.On("RestoreTodo", uint(5)).Return(nil, gorm.ErrRecordNotFound).Once()
+
+		req, _ := http.NewRequest(http.MethodPost, "/test/5/restore", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("purge", func(t *testing.T) {
+		mockSv.On("PurgeTodo", uint(4)).Return(nil).Once()
+
+		req, _ := http.NewRequest(http.MethodDelete, "/trash/4", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
+
+func TestHistory(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSv := new(mockTodoService)
+	mockRc := new(mockRedisClient)
+	mockRc.On("Delete", "autocomplete_generation").Return(1, nil).Maybe()
+	handler := &TodoHandler{
+		todoService: mockSv,
+		redisClient: mockRc,
+	}
+	mockRc.On("Delete", mock.AnythingOfType("string")).Return(1, nil)
+
+	r := gin.Default()
+	r.GET("/test/:id/history", handler.GetTodoHistory)
+	r.POST("/test/:id/revert", handler.RevertTodo)
+
+	t.Run("list", func(t *testing.T) {
+		mockSv.On("GetTodoHistory", uint(8)).Return([]*model.TodoHistory{
+			{ID: 1, TodoID: 8, Version: 1, Action: model.HistoryActionCreated, Actor: "alice"},
+			{ID: 2, TodoID: 8, Version: 2, Action: model.HistoryActionTransitioned, Actor: "bob",
+				Changes: model.FieldChanges{"status": {Old: "doing", New: "done"}}},
+		}, nil).Once()
+
+		req, _ := http.NewRequest(http.MethodGet, "/test/8/history", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp []dto.TodoHistoryResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Len(t, resp, 2)
+		assert.Equal(t, "bob", resp[1].Actor)
+		assert.Equal(t, "done", resp[1].Changes["status"].New)
+	})
+
+	t.Run("no history", func(t *testing.T) {
+		mockSv.On("GetTodoHistory", uint(7)).Return([]*model.TodoHistory{}, nil).Once()
+
+		req, _ := http.NewRequest(http.MethodGet, "/test/7/history", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `[]`, w.Body.String())
+	})
+
+	t.Run("unknown todo", func(t *testing.T) {
+		mockSv.On("GetTodoHistory", uint(9)).Return(nil, gorm.ErrRecordNotFound).Once()
+
+		req, _ := http.NewRequest(http.MethodGet, "/test/9/history", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("revert", func(t *testing.T) {
+		mockSv.On("GetTodoByID", uint(8)).Return(&model.Todo{ID: 8, Status: "done", Version: 2}, nil).Once()
+		mockSv.On("RevertTodo", uint(8), 2, 1).Return(&model.Todo{ID: 8, Status: "doing", Version: 3}, nil).Once()
+
+		req, _ := http.NewRequest(http.MethodPost, "/test/8/revert", bytes.NewBufferString(`{"version":1}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `"8-3"`, w.Header().Get("ETag"))
+	})
+
+	t.Run("revert unknown revision", func(t *testing.T) {
+		mockSv.On("GetTodoByID", uint(8)).Return(&model.Todo{ID: 8, Version: 2}, nil).Once()
+		mockSv.On("RevertTodo", uint(8), 2, 7).Return(nil, service.ErrRevisionNotFound).Once()
+
+		req, _ := http.NewRequest(http.MethodPost, "/test/8/revert", bytes.NewBufferString(`{"version":7}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestSearch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSv := new(mockTodoService)
+	handler := &TodoHandler{
+		todoService: mockSv,
+	}
+
+	r := gin.Default()
+	r.GET("/test/search", handler.SearchTodos)
+
+	t.Run("success", func(t *testing.T) {
+		search := model.TodoSearch{Text: "deploy back"}
+		query := model.TodoQuery{Status: model.TodoStatusDoing, Limit: 10, Offset: 10}
+		mockSv.On("SearchTodos", search, query).Return([]*model.TodoSearchResult{
+			{
+				Todo:          model.Todo{ID: 3, Name: "Deploy backend"},
+				Rank:          0.6,
+				NameHighlight: "<mark>Deploy</mark> <mark>backend</mark>",
+			},
+		}, int64(11), nil).Once()
+
+		req, _ := http.NewRequest(http.MethodGet, "/test/search?q=deploy+back&status=doing&page=2&page_size=10", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp dto.TodoSearchResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, int64(11), resp.Total)
+		assert.Equal(t, 2, resp.Page)
+		assert.Equal(t, "<mark>Deploy</mark> <mark>backend</mark>", resp.Items[0].Highlights["name"])
+	})
+
+	t.Run("empty query", func(t *testing.T) {
+		mockSv.On("SearchTodos", model.TodoSearch{Text: "  "}, mock.Anything).Return(nil, int64(0), service.ErrEmptySearch).Once()
+
+		req, _ := http.NewRequest(http.MethodGet, "/test/search?q=++", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("invalid page", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/test/search?q=x&page=0", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("no results suggests names", func(t *testing.T) {
+		search := model.TodoSearch{Text: "deplyo"}
+		mockSv.On("SearchTodos", search, mock.Anything).Return([]*model.TodoSearchResult{}, int64(0), nil).Once()
+		mockSv.On("SuggestTodoNames", search, 3).Return([]string{"Deploy backend"}, nil).Once()
+
+		req, _ := http.NewRequest(http.MethodGet, "/test/search?q=deplyo", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp dto.TodoSearchResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, []string{"Deploy backend"}, resp.Suggestions)
+	})
+
+	t.Run("fuzzy", func(t *testing.T) {
+		search := model.TodoSearch{Text: "deplyo", Fuzzy: true, Threshold: 0.2}
+		mockSv.On("SearchTodos", search, mock.Anything).Return([]*model.TodoSearchResult{
+			{Todo: model.Todo{ID: 3, Name: "Deploy backend"}, Rank: 0.25},
+		}, int64(1), nil).Once()
+
+		req, _ := http.NewRequest(http.MethodGet, "/test/search?q=deplyo&mode=fuzzy&threshold=0.2", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("invalid threshold", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/test/search?q=x&mode=fuzzy&threshold=2", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestAutocomplete(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSv := new(mockTodoService)
+	mockRc := new(mockRedisClient)
+	mockRc.On("Delete", "autocomplete_generation").Return(1, nil).Maybe()
+	handler := &TodoHandler{
+		todoService: mockSv,
+		redisClient: mockRc,
+	}
+
+	r := gin.Default()
+	r.GET("/test/autocomplete", handler.AutocompleteTodos)
+
+	t.Run("cache miss", func(t *testing.T) {
+		mockRc.On("Get", "autocomplete_generation").Return("g1", nil).Once()
+		mockRc.On("Get", "autocomplete_g1_10_dep").Return("", errors.New("redis: nil")).Once()
+		mockSv.On("AutocompleteTodoNames", "Dep", 10).Return([]string{"Deploy backend"}, nil).Once()
+		mockRc.On("SetEx", "autocomplete_g1_10_dep", mock.Anything, AutocompleteCacheTTL).Return("OK", nil).Once()
+
+		req, _ := http.NewRequest(http.MethodGet, "/test/autocomplete?prefix=Dep", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp dto.TodoAutocompleteResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, []string{"Deploy backend"}, resp.Completions)
+	})
+
+	t.Run("cache hit", func(t *testing.T) {
+		mockRc.On("Get", "autocomplete_generation").Return("g1", nil).Once()
+		mockRc.On("Get", "autocomplete_g1_5_dep").Return(`{"prefix":"dep","completions":["Deploy"]}`, nil).Once()
+
+		req, _ := http.NewRequest(http.MethodGet, "/test/autocomplete?prefix=dep&limit=5", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockSv.AssertNotCalled(t, "AutocompleteTodoNames", "dep", 5)
+	})
+
+	t.Run("missing prefix", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/test/autocomplete", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestFilter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSv := new(mockTodoService)
+	handler := &TodoHandler{
+		todoService: mockSv,
+	}
+
+	r := gin.Default()
+	r.GET("/test", handler.GetAllTodos)
+
+	t.Run("success", func(t *testing.T) {
+		expected := filter.And{
+			Left: filter.Comparison{Field: "status", Op: filter.OpMatch, Value: "doing"},
+			Right: filter.Or{
+				Left:  filter.Comparison{Field: "name", Op: filter.OpMatch, Value: "deploy*"},
+				Right: filter.Comparison{Field: "created", Op: filter.OpGreater, Value: "-7d"},
+			},
+		}
+		mockSv.On("ListTodos", model.TodoQuery{Filter: expected}).Return([]*model.Todo{
+			{ID: 3, Name: "Deploy backend", Status: model.TodoStatusDoing},
+		}, nil).Once()
+
+		q := url.Values{"filter": {"status:doing AND (name:deploy* OR created>-7d)"}}
+		req, _ := http.NewRequest(http.MethodGet, "/test?"+q.Encode(), nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockSv.AssertExpectations(t)
+	})
+
+	for name, expr := range map[string]string{
+		"unknown field":     "priority>=high",
+		"bad enum value":    "status:blocked",
+		"bad operator":      "name>abc",
+		"bad time":          "created<soon",
+		"syntax error":      "status:doing AND",
+		"unbalanced parens": "(status:doing",
+	} {
+		t.Run(name, func(t *testing.T) {
+			q := url.Values{"filter": {expr}}
+			req, _ := http.NewRequest(http.MethodGet, "/test?"+q.Encode(), nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+}
+
+func TestFields(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSv := new(mockTodoService)
+	handler := &TodoHandler{
+		todoService: mockSv,
+	}
+
+	r := gin.Default()
+	r.GET("/test", handler.GetAllTodos)
+	r.GET("/test/:id", handler.GetTodo)
+
+	t.Run("list with children", func(t *testing.T) {
+		parent := 1
+		mockSv.On("ListTodos", model.TodoQuery{
+			Columns: []string{"id", "version", "name", "project_id"},
+			Include: []string{model.TodoIncludeChildren},
+		}).Return([]*model.Todo{
+			{ID: 1, Version: 2, Name: "Release", Children: []*model.Todo{
+				{ID: 3, Version: 1, Name: "Tag version", ParentID: &parent},
+			}},
+			{ID: 2, Version: 1, Name: "Write docs", Children: []*model.Todo{}},
+		}, nil).Once()
+
+		req, _ := http.NewRequest(http.MethodGet, "/test?fields=id,name,project_id&include=children", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `[
+			{"id":1,"name":"Release","project_id":null,"children":[{"id":3,"name":"Tag version","project_id":null}]},
+			{"id":2,"name":"Write docs","project_id":null,"children":[]}
+		]`, w.Body.String())
+		mockSv.AssertExpectations(t)
+	})
+
+	t.Run("single todo", func(t *testing.T) {
+		mockSv.On("ListTodos", model.TodoQuery{
+			Filter:  filter.Comparison{Field: "id", Op: filter.OpEqual, Value: "5"},
+			Columns: []string{"id", "version", "status", "updated_at"},
+		}).Return([]*model.Todo{
+			{ID: 5, Version: 4, Status: model.TodoStatusDone, UpdatedAt: time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)},
+		}, nil).Once()
+
+		req, _ := http.NewRequest(http.MethodGet, "/test/5?fields=status,updated_at", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `"5-4"`, w.Header().Get("ETag"))
+		assert.JSONEq(t, `{"status":"done","updated_at":"2024-05-01T08:00:00Z"}`, w.Body.String())
+		mockSv.AssertNotCalled(t, "GetTodoByID", mock.Anything)
+	})
+
+	t.Run("single todo not found", func(t *testing.T) {
+		mockSv.On("ListTodos", model.TodoQuery{
+			Filter:  filter.Comparison{Field: "id", Op: filter.OpEqual, Value: "6"},
+			Include: []string{model.TodoIncludeChildren},
+		}).Return([]*model.Todo{}, nil).Once()
+
+		req, _ := http.NewRequest(http.MethodGet, "/test/6?include=children", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	for name, query := range map[string]string{
+		"unknown field":    "fields=id,priority",
+		"unknown relation": "include=tags",
+	} {
+		t.Run(name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/test?"+query, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+}
+
+func TestMove(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSv := new(mockTodoService)
+	mockRc := new(mockRedisClient)
+	mockRc.On("Delete", "autocomplete_generation").Return(1, nil).Maybe()
+	handler := &TodoHandler{
+		todoService: mockSv,
+		redisClient: mockRc,
+	}
+
+	r := gin.Default()
+	r.POST("/test/:id/move", handler.MoveTodo)
+
+	t.Run("after", func(t *testing.T) {
+		project := 2
+		mockSv.On("GetTodoByID", uint(5)).Return(&model.Todo{ID: 5, Version: 3}, nil).Once()
+		mockSv.On("MoveTodo", uint(5), 3, uint(9), true).Return(&model.Todo{ID: 5, Version: 4, ProjectID: &project, Position: "i8"}, nil).Once()
+		mockRc.On("Delete", "todo_5").Return(1, nil).Once()
+
+		req, _ := http.NewRequest(http.MethodPost, "/test/5/move", bytes.NewBufferString(`{"after": 9}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `"5-4"`, w.Header().Get("ETag"))
+		var resp dto.TodoResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "i8", resp.Position)
+		assert.Equal(t, 2, *resp.ProjectID)
+	})
+
+	t.Run("before and after", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodPost, "/test/5/move", bytes.NewBufferString(`{"before": 1, "after": 9}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("next to itself", func(t *testing.T) {
+		mockSv.On("GetTodoByID", uint(5)).Return(&model.Todo{ID: 5, Version: 3}, nil).Once()
+		mockSv.On("MoveTodo", uint(5), 3, uint(5), false).Return(nil, service.ErrInvalidMove).Once()
+
+		req, _ := http.NewRequest(http.MethodPost, "/test/5/move", bytes.NewBufferString(`{"before": 5}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	})
+
+	t.Run("stale version", func(t *testing.T) {
+		mockSv.On("GetTodoByID", uint(5)).Return(&model.Todo{ID: 5, Version: 3}, nil).Once()
+
+		req, _ := http.NewRequest(http.MethodPost, "/test/5/move", bytes.NewBufferString(`{"before": 1}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", `"5-2"`)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	})
+}
+
+func TestBoard(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSv := new(mockTodoService)
+	handler := &TodoHandler{
+		todoService: mockSv,
+	}
+
+	r := gin.Default()
+	r.GET("/test/projects/:id/board", handler.GetBoard)
+	r.PATCH("/test/:id", handler.PatchTodo)
+
+	t.Run("success", func(t *testing.T) {
+		project := 2
+		mockSv.On("GetBoard", 2).Return(&service.Board{
+			ProjectID: 2,
+			Columns: []service.BoardColumn{
+				{Status: model.TodoStatusTodo, Cards: []*model.Todo{
+					{ID: 1, Name: "Write spec", Status: model.TodoStatusTodo, ProjectID: &project, Position: "i"},
+					{ID: 4, Name: "Review spec", Status: model.TodoStatusTodo, ProjectID: &project, Position: "r"},
+				}},
+				{Status: model.TodoStatusDoing, WIPLimit: 3, Cards: []*model.Todo{}},
+				{Status: model.TodoStatusDone, Cards: []*model.Todo{}},
+			},
+		}, nil).Once()
+
+		req, _ := http.NewRequest(http.MethodGet, "/test/projects/2/board", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp dto.BoardResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Len(t, resp.Columns, 3)
+		assert.Equal(t, 2, resp.Columns[0].Count)
+		assert.Equal(t, 4, resp.Columns[0].Cards[1].ID)
+		assert.Equal(t, 3, resp.Columns[1].WIPLimit)
+		assert.NotNil(t, resp.Columns[2].Cards)
+	})
+
+	t.Run("transition into full column", func(t *testing.T) {
+		mockSv.On("GetTodoByID", uint(1)).Return(&model.Todo{ID: 1, Name: "Write spec", Description: "d", Status: model.TodoStatusTodo, Version: 2}, nil).Once()
+		mockSv.On("PatchTodo", uint(1), 2, map[string]interface{}{"status": model.TodoStatusDoing}).Return(nil, service.ErrWIPLimitReached).Once()
+
+		req, _ := http.NewRequest(http.MethodPatch, "/test/1", bytes.NewBufferString(`{"status": "doing"}`))
+		req.Header.Set("Content-Type", MergePatchContentType)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+}
+
+func TestRecurrence(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSv := new(mockTodoService)
+	handler := &TodoHandler{
+		todoService: mockSv,
+	}
+
+	r := gin.Default()
+	r.GET("/test/:id/occurrences", handler.PreviewOccurrences)
+	r.PATCH("/test/:id", handler.PatchTodo)
+
+	loc, _ := time.LoadLocation("Europe/Berlin")
+	due := time.Date(2026, 3, 27, 9, 0, 0, 0, loc)
+
+	t.Run("preview", func(t *testing.T) {
+		mockSv.On("GetTodoByID", uint(3)).Return(&model.Todo{ID: 3, DueAt: &due, Recurrence: "FREQ=DAILY", Timezone: "Europe/Berlin", Occurrence: 4}, nil).Once()
+		mockSv.On("PreviewOccurrences", uint(3), 2).Return([]time.Time{due.AddDate(0, 0, 1), due.AddDate(0, 0, 2)}, nil).Once()
+
+		req, _ := http.NewRequest(http.MethodGet, "/test/3/occurrences?count=2", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp dto.OccurrencePreviewResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Len(t, resp.Occurrences, 2)
+		assert.Equal(t, 5, resp.Occurrences[0].Occurrence)
+		assert.Equal(t, 9, resp.Occurrences[1].DueAt.In(loc).Hour())
+	})
+
+	t.Run("not recurring", func(t *testing.T) {
+		mockSv.On("GetTodoByID", uint(4)).Return(&model.Todo{ID: 4}, nil).Once()
+		mockSv.On("PreviewOccurrences", uint(4), defaultOccurrencePreview).Return(nil, service.ErrNotRecurring).Once()
+
+		req, _ := http.NewRequest(http.MethodGet, "/test/4/occurrences", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	})
+
+	t.Run("patch recurrence", func(t *testing.T) {
+		mockSv.On("GetTodoByID", uint(3)).Return(&model.Todo{ID: 3, Name: "Water plants", Description: "d", Status: model.TodoStatusTodo, Version: 1}, nil).Once()
+		mockSv.On("PatchTodo", uint(3), 1, map[string]interface{}{
+			"due_at":     "2026-03-27T09:00:00+01:00",
+			"recurrence": "FREQ=WEEKLY;BYDAY=FR",
+			"timezone":   "Europe/Berlin",
+		}).Return(&model.Todo{ID: 3, Version: 2}, nil).Once()
+
+		body := `{"due_at": "2026-03-27T09:00:00+01:00", "recurrence": "FREQ=WEEKLY;BYDAY=FR", "timezone": "Europe/Berlin"}`
+		req, _ := http.NewRequest(http.MethodPatch, "/test/3", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", MergePatchContentType)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockSv.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,99 @@
+package v2
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"todo_project/api/todocache"
+	"todo_project/dto"
+	"todo_project/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func (h *TodoHandler) GetTodoHistory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	entries, err := h.todoService.GetTodoHistory(uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get todo history"})
+		return
+	}
+
+	resp := make([]dto.TodoHistoryResponse, 0, len(entries))
+	for _, entry := range entries {
+		changes := make(map[string]dto.FieldChangeResponse, len(entry.Changes))
+		for field, change := range entry.Changes {
+			changes[field] = dto.FieldChangeResponse{Old: change.Old, New: change.New}
+		}
+		resp = append(resp, dto.TodoHistoryResponse{
+			ID:        entry.ID,
+			TodoID:    entry.TodoID,
+			Version:   entry.Version,
+			Action:    entry.Action,
+			Actor:     entry.Actor,
+			Changes:   changes,
+			CreatedAt: entry.CreatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *TodoHandler) RevertTodo(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	existingTodo, err := h.todoService.GetTodoByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
+		return
+	}
+
+	if !checkIfMatch(c, existingTodo) {
+		return
+	}
+
+	var req dto.RevertTodoRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Version <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	todo, err := h.todoService.RevertTodo(c.Request.Context(), uint(id), existingTodo.Version, req.Version)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrRevisionNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Revision not found"})
+		case errors.Is(err, service.ErrWIPLimitReached):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrVersionConflict):
+			writeVersionConflict(c)
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revert todo"})
+		}
+		return
+	}
+
+	todocache.Evict(h.redisClient, id)
+
+	resp := dto.NewTodoResponse(todo)
+
+	c.Header("ETag", dto.TodoETag(todo))
+	c.JSON(http.StatusOK, resp)
+}
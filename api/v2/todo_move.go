@@ -0,0 +1,70 @@
+package v2
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"todo_project/api/todocache"
+	"todo_project/dto"
+	"todo_project/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func (h *TodoHandler) MoveTodo(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var req dto.MoveTodoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	if (req.Before == nil) == (req.After == nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Exactly one of before and after is required"})
+		return
+	}
+	target, after := req.Before, false
+	if req.After != nil {
+		target, after = req.After, true
+	}
+
+	existingTodo, err := h.todoService.GetTodoByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
+		return
+	}
+	if !checkIfMatch(c, existingTodo) {
+		return
+	}
+	version := req.Version
+	if version == 0 {
+		version = existingTodo.Version
+	}
+
+	todo, err := h.todoService.MoveTodo(c.Request.Context(), uint(id), version, uint(*target), after)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidMove):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
+		case errors.Is(err, service.ErrWIPLimitReached):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrVersionConflict):
+			writeVersionConflict(c)
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to move todo"})
+		}
+		return
+	}
+
+	todocache.Evict(h.redisClient, todo.ID)
+	c.Header("ETag", dto.TodoETag(todo))
+	c.JSON(http.StatusOK, dto.NewTodoResponse(todo))
+}
@@ -0,0 +1,95 @@
+package v2
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"todo_project/dto"
+	"todo_project/model"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+)
+
+const (
+	MergePatchContentType = "application/merge-patch+json"
+	JSONPatchContentType  = "application/json-patch+json"
+)
+
+var (
+	errUnsupportedPatchType = errors.New("unsupported patch content type")
+	errMalformedPatch       = errors.New("malformed patch document")
+	errPatchConflict        = errors.New("patch cannot be applied to the current todo")
+	errInvalidPatchResult   = errors.New("patched todo is not valid")
+)
+
+// applyTodoPatch applies a merge patch (RFC 7396) or JSON patch (RFC 6902) to
+// the patchable view of todo and returns only the columns whose value changed.
+func applyTodoPatch(todo *model.Todo, contentType string, patch []byte) (map[string]interface{}, error) {
+	original := dto.PatchTodoDocument{
+		Name:        todo.Name,
+		Description: todo.Description,
+		Status:      todo.Status,
+		Recurrence:  todo.Recurrence,
+		Timezone:    todo.Timezone,
+	}
+	if todo.DueAt != nil {
+		original.DueAt = todo.DueAt.Format(time.RFC3339)
+	}
+	doc, err := json.Marshal(original)
+	if err != nil {
+		return nil, err
+	}
+
+	var patched []byte
+	switch contentType {
+	case MergePatchContentType:
+		if !json.Valid(patch) || bytes.HasPrefix(bytes.TrimSpace(patch), []byte("[")) {
+			return nil, errMalformedPatch
+		}
+		patched, err = jsonpatch.MergePatch(doc, patch)
+		if err != nil {
+			return nil, errMalformedPatch
+		}
+	case JSONPatchContentType:
+		ops, err := jsonpatch.DecodePatch(patch)
+		if err != nil {
+			return nil, errMalformedPatch
+		}
+		patched, err = ops.Apply(doc)
+		if err != nil {
+			return nil, errPatchConflict
+		}
+	default:
+		return nil, errUnsupportedPatchType
+	}
+
+	var result dto.PatchTodoDocument
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&result); err != nil {
+		return nil, errInvalidPatchResult
+	}
+
+	changes := map[string]interface{}{}
+	if result.Name != original.Name {
+		changes["name"] = result.Name
+	}
+	if result.Description != original.Description {
+		changes["description"] = result.Description
+	}
+	if result.Status != original.Status {
+		changes["status"] = result.Status
+	}
+	if result.DueAt != original.DueAt {
+		changes["due_at"] = result.DueAt
+	}
+	if result.Recurrence != original.Recurrence {
+		changes["recurrence"] = result.Recurrence
+	}
+	if result.Timezone != original.Timezone {
+		changes["timezone"] = result.Timezone
+	}
+	return changes, nil
+}
@@ -0,0 +1,71 @@
+package v2
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"todo_project/dto"
+	"todo_project/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultOccurrencePreview = 5
+	maxOccurrencePreview     = 50
+)
+
+// PreviewOccurrences lists the due dates the next occurrences of a recurring
+// todo will get as each one is completed.
+func (h *TodoHandler) PreviewOccurrences(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	count := defaultOccurrencePreview
+	if value := c.Query("count"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxOccurrencePreview {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "count must be between 1 and " + strconv.Itoa(maxOccurrencePreview)})
+			return
+		}
+		count = n
+	}
+
+	todo, err := h.todoService.GetTodoByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
+		return
+	}
+	dues, err := h.todoService.PreviewOccurrences(uint(id), count)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrNotRecurring):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Todo has no recurrence rule and due date"})
+		case errors.Is(err, service.ErrInvalidTodo):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to preview occurrences"})
+		}
+		return
+	}
+
+	resp := dto.OccurrencePreviewResponse{
+		TodoID:      todo.ID,
+		Recurrence:  todo.Recurrence,
+		Timezone:    todo.Location().String(),
+		Occurrences: make([]dto.OccurrenceResponse, 0, len(dues)),
+	}
+	for i, due := range dues {
+		resp.Occurrences = append(resp.Occurrences, dto.OccurrenceResponse{
+			Occurrence: todo.Occurrence + i + 1,
+			DueAt:      due,
+		})
+	}
+	c.JSON(http.StatusOK, resp)
+}
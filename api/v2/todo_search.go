@@ -0,0 +1,171 @@
+package v2
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"todo_project/api/todocache"
+	"todo_project/dto"
+	"todo_project/model"
+	"todo_project/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	searchModeFullText = "fulltext"
+	searchModeFuzzy    = "fuzzy"
+
+	maxSuggestions           = 3
+	defaultAutocompleteLimit = 10
+	maxAutocompleteLimit     = 50
+)
+
+// AutocompleteCacheTTL is how long autocomplete answers stay in Redis.
+var AutocompleteCacheTTL = time.Minute
+
+func (h *TodoHandler) SearchTodos(c *gin.Context) {
+	page, pageSize, err := parsePage(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	status := c.Query("status")
+	if status != "" && !model.IsValidTodoStatus(status) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+		return
+	}
+
+	search := model.TodoSearch{
+		Text:     c.Query("q"),
+		Language: c.Query("lang"),
+	}
+	switch c.Query("mode") {
+	case "", searchModeFullText:
+	case searchModeFuzzy:
+		search.Fuzzy = true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mode must be " + searchModeFullText + " or " + searchModeFuzzy})
+		return
+	}
+	if value := c.Query("threshold"); value != "" {
+		threshold, err := strconv.ParseFloat(value, 64)
+		if err != nil || threshold <= 0 || threshold > 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "threshold must be a number between 0 and 1"})
+			return
+		}
+		search.Threshold = threshold
+	}
+	expr, err := parseFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := model.TodoQuery{
+		Status: status,
+		Filter: expr,
+		Limit:  pageSize,
+		Offset: (page - 1) * pageSize,
+	}
+
+	results, total, err := h.todoService.SearchTodos(search, query)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrEmptySearch):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Query parameter q must contain at least one word"})
+		case errors.Is(err, service.ErrInvalidLanguage):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid search language"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search todos"})
+		}
+		return
+	}
+
+	resp := dto.TodoSearchResponse{
+		Items:    make([]dto.TodoSearchItem, 0, len(results)),
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	}
+	for _, result := range results {
+		resp.Items = append(resp.Items, dto.TodoSearchItem{
+			TodoResponse: dto.NewTodoResponse(&result.Todo),
+			Rank:         result.Rank,
+			Highlights: map[string]string{
+				"name":        result.NameHighlight,
+				"description": result.DescriptionHighlight,
+			},
+		})
+	}
+	// A fuzzy search already tolerates typos, so only full-text misses get hints.
+	if total == 0 && !search.Fuzzy {
+		suggestions, err := h.todoService.SuggestTodoNames(search, maxSuggestions)
+		if err != nil {
+			logrus.Errorf("Failed to suggest todo names for %q: %v", search.Text, err)
+		}
+		resp.Suggestions = suggestions
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// AutocompleteTodos completes a prefix to the most used todo names. Answers
+// are cached in Redis for AutocompleteCacheTTL since the same prefixes are
+// requested on every keystroke.
+func (h *TodoHandler) AutocompleteTodos(c *gin.Context) {
+	prefix := strings.TrimSpace(c.Query("prefix"))
+	if prefix == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Query parameter prefix is required"})
+		return
+	}
+	limit := defaultAutocompleteLimit
+	if value := c.Query("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxAutocompleteLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and " + strconv.Itoa(maxAutocompleteLimit)})
+			return
+		}
+		limit = n
+	}
+
+	var redisKey string
+	if h.redisClient != nil {
+		var err error
+		if redisKey, err = todocache.AutocompleteKey(h.redisClient, limit, prefix); err != nil {
+			logrus.Errorf("Failed to read the autocomplete cache generation: %v", err)
+		} else if str, err := h.redisClient.Get(redisKey); err == nil {
+			var resp dto.TodoAutocompleteResponse
+			if err := json.Unmarshal([]byte(str), &resp); err == nil {
+				c.JSON(http.StatusOK, resp)
+				return
+			}
+		}
+	}
+
+	names, err := h.todoService.AutocompleteTodoNames(prefix, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to autocomplete todos"})
+		return
+	}
+	resp := dto.TodoAutocompleteResponse{Prefix: prefix, Completions: names}
+	if resp.Completions == nil {
+		resp.Completions = []string{}
+	}
+
+	if redisKey != "" {
+		if data, err := json.Marshal(resp); err == nil {
+			if _, err := h.redisClient.SetEx(redisKey, string(data), AutocompleteCacheTTL); err != nil {
+				logrus.Errorf("Failed to cache %s in Redis: %v", redisKey, err)
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,353 @@
+package v2
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"todo_project/api/todocache"
+	"todo_project/common/actor"
+	"todo_project/common/eventbus"
+	"todo_project/dto"
+	"todo_project/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"gorm.io/gorm"
+)
+
+const (
+	socketWriteWait  = 10 * time.Second
+	socketPongWait   = 60 * time.Second
+	socketPingPeriod = 50 * time.Second
+	socketMaxMessage = 64 << 10
+	socketMaxTopics  = 100
+)
+
+var socketUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+// TodoSocketHandler serves the real-time WebSocket channel. Clients
+// subscribe to "project:<id>" or "todo:<id>" topics, receive the todo events
+// and presences of those topics, report their own presence and edit todos.
+type TodoSocketHandler struct {
+	todos    *TodoHandler
+	events   service.TodoEventSource
+	presence service.PresenceSource
+}
+
+func NewTodoSocketHandler(todos *TodoHandler, events service.TodoEventSource, presence service.PresenceSource) *TodoSocketHandler {
+	return &TodoSocketHandler{todos: todos, events: events, presence: presence}
+}
+
+// ServeTodoSocket upgrades the request to a WebSocket. It sits behind the
+// same authentication as the REST API, and edits are made as the
+// authenticated user.
+func (h *TodoSocketHandler) ServeTodoSocket(c *gin.Context) {
+	conn, err := socketUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return
+	}
+	s := &socketSession{
+		handler: h,
+		conn:    conn,
+		c:       c,
+		send:    make(chan dto.SocketResponse, 64),
+		closed:  make(chan struct{}),
+		topics:  make(map[string]bool),
+		present: make(map[int]*int),
+	}
+	s.run()
+}
+
+// socketSession is one WebSocket connection. Only the write loop writes to
+// the connection; the read loop hands its replies over through send.
+type socketSession struct {
+	handler   *TodoSocketHandler
+	conn      *websocket.Conn
+	c         *gin.Context
+	send      chan dto.SocketResponse
+	closed    chan struct{}
+	closeOnce sync.Once
+
+	mu      sync.Mutex
+	topics  map[string]bool
+	present map[int]*int
+}
+
+func (s *socketSession) run() {
+	var events <-chan eventbus.Message
+	if s.handler.events != nil {
+		var stop func()
+		events, stop = s.handler.events.Subscribe()
+		defer stop()
+	}
+	presences, stopPresences := s.handler.presence.Subscribe()
+	defer stopPresences()
+
+	go s.writeLoop(events, presences)
+	s.readLoop()
+	s.close()
+	s.leave()
+}
+
+func (s *socketSession) close() {
+	s.closeOnce.Do(func() {
+		close(s.closed)
+		s.conn.Close()
+	})
+}
+
+func (s *socketSession) readLoop() {
+	s.conn.SetReadLimit(socketMaxMessage)
+	_ = s.conn.SetReadDeadline(time.Now().Add(socketPongWait))
+	s.conn.SetPongHandler(func(string) error {
+		return s.conn.SetReadDeadline(time.Now().Add(socketPongWait))
+	})
+
+	for {
+		var req dto.SocketRequest
+		if err := s.conn.ReadJSON(&req); err != nil {
+			return
+		}
+		_ = s.conn.SetReadDeadline(time.Now().Add(socketPongWait))
+		s.handle(req)
+	}
+}
+
+func (s *socketSession) writeLoop(events <-chan eventbus.Message, presences <-chan service.Presence) {
+	ping := time.NewTicker(socketPingPeriod)
+	defer ping.Stop()
+	defer s.close()
+
+	for {
+		var msg dto.SocketResponse
+		select {
+		case <-s.closed:
+			return
+		case msg = <-s.send:
+		case event, ok := <-events:
+			if !ok {
+				// Dropped for falling behind; the client reconnects.
+				return
+			}
+			topic, ok := s.eventTopic(event)
+			if !ok {
+				continue
+			}
+			msg = dto.SocketResponse{Type: "event", Topic: topic, Event: &event}
+		case presence, ok := <-presences:
+			if !ok {
+				return
+			}
+			topic, ok := s.presenceTopic(presence)
+			if !ok {
+				continue
+			}
+			msg = dto.SocketResponse{Type: "presence", Topic: topic, Presence: toPresenceResponse(presence)}
+		case <-ping.C:
+			_ = s.conn.SetWriteDeadline(time.Now().Add(socketWriteWait))
+			if err := s.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+			continue
+		}
+
+		_ = s.conn.SetWriteDeadline(time.Now().Add(socketWriteWait))
+		if err := s.conn.WriteJSON(msg); err != nil {
+			return
+		}
+	}
+}
+
+func (s *socketSession) reply(resp dto.SocketResponse) {
+	select {
+	case s.send <- resp:
+	case <-s.closed:
+	}
+}
+
+func (s *socketSession) fail(ref string, status int, message string) {
+	s.reply(dto.SocketResponse{Type: "reply", Ref: ref, Status: status, Error: message})
+}
+
+func (s *socketSession) handle(req dto.SocketRequest) {
+	switch req.Type {
+	case "subscribe":
+		s.subscribe(req)
+	case "unsubscribe":
+		s.mu.Lock()
+		delete(s.topics, req.Topic)
+		s.mu.Unlock()
+		s.reply(dto.SocketResponse{Type: "reply", Ref: req.Ref, Status: http.StatusOK, Topic: req.Topic})
+	case "presence":
+		s.updatePresence(req)
+	case "edit":
+		s.edit(req)
+	default:
+		s.fail(req.Ref, http.StatusBadRequest, "Unknown message type")
+	}
+}
+
+// subscribe adds a topic and sends who is currently present on it.
+func (s *socketSession) subscribe(req dto.SocketRequest) {
+	kind, id, ok := parseTopic(req.Topic)
+	if !ok {
+		s.fail(req.Ref, http.StatusBadRequest, "Topic must be project:<id> or todo:<id>")
+		return
+	}
+	s.mu.Lock()
+	if len(s.topics) >= socketMaxTopics && !s.topics[req.Topic] {
+		s.mu.Unlock()
+		s.fail(req.Ref, http.StatusBadRequest, "Too many topics")
+		return
+	}
+	s.topics[req.Topic] = true
+	s.mu.Unlock()
+
+	s.reply(dto.SocketResponse{Type: "reply", Ref: req.Ref, Status: http.StatusOK, Topic: req.Topic})
+	present := s.handler.presence.Snapshot(func(p service.Presence) bool {
+		return topicMatches(kind, id, p.TodoID, p.ProjectID)
+	})
+	for _, presence := range present {
+		s.reply(dto.SocketResponse{Type: "presence", Topic: req.Topic, Presence: toPresenceResponse(presence)})
+	}
+}
+
+func (s *socketSession) updatePresence(req dto.SocketRequest) {
+	todo, err := s.handler.todos.todoService.GetTodoByID(uint(req.TodoID))
+	if err != nil {
+		status, message := socketTodoError(err)
+		s.fail(req.Ref, status, message)
+		return
+	}
+	err = s.handler.presence.Update(service.Presence{
+		TodoID:    todo.ID,
+		ProjectID: todo.ProjectID,
+		User:      actor.FromContext(s.c.Request.Context()),
+		State:     req.State,
+	})
+	if errors.Is(err, service.ErrInvalidPresence) {
+		s.fail(req.Ref, http.StatusUnprocessableEntity, "State must be viewing, editing or left")
+		return
+	}
+	if err != nil {
+		s.fail(req.Ref, http.StatusInternalServerError, "Failed to update presence")
+		return
+	}
+
+	s.mu.Lock()
+	if req.State == service.PresenceLeft {
+		delete(s.present, todo.ID)
+	} else {
+		s.present[todo.ID] = todo.ProjectID
+	}
+	s.mu.Unlock()
+	s.reply(dto.SocketResponse{Type: "reply", Ref: req.Ref, Status: http.StatusOK})
+}
+
+// edit applies changes like PATCH /todo/:id, with the same validation.
+func (s *socketSession) edit(req dto.SocketRequest) {
+	if req.TodoID <= 0 || len(req.Changes) == 0 {
+		s.fail(req.Ref, http.StatusBadRequest, "Invalid request")
+		return
+	}
+	todo, err := s.handler.todos.todoService.PatchTodo(s.c.Request.Context(), uint(req.TodoID), req.Version, req.Changes)
+	if err != nil {
+		status, message := socketTodoError(err)
+		s.fail(req.Ref, status, message)
+		return
+	}
+	todocache.Evict(s.handler.todos.redisClient, todo.ID)
+
+	resp := dto.NewTodoResponse(todo)
+	s.reply(dto.SocketResponse{Type: "reply", Ref: req.Ref, Status: http.StatusOK, Todo: &resp})
+}
+
+// leave announces that the user left every todo they were present on.
+func (s *socketSession) leave() {
+	s.mu.Lock()
+	present := s.present
+	s.present = nil
+	s.mu.Unlock()
+
+	user := actor.FromContext(s.c.Request.Context())
+	for todoID, projectID := range present {
+		_ = s.handler.presence.Update(service.Presence{
+			TodoID:    todoID,
+			ProjectID: projectID,
+			User:      user,
+			State:     service.PresenceLeft,
+		})
+	}
+}
+
+// eventTopic returns the first subscribed topic an event belongs to.
+func (s *socketSession) eventTopic(event eventbus.Message) (string, bool) {
+	return s.matchTopic(event.AggregateID, eventProject(event))
+}
+
+func (s *socketSession) presenceTopic(presence service.Presence) (string, bool) {
+	return s.matchTopic(presence.TodoID, presence.ProjectID)
+}
+
+func (s *socketSession) matchTopic(todoID int, projectID *int) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for topic := range s.topics {
+		kind, id, _ := parseTopic(topic)
+		if topicMatches(kind, id, todoID, projectID) {
+			return topic, true
+		}
+	}
+	return "", false
+}
+
+func parseTopic(topic string) (string, int, bool) {
+	kind, value, ok := strings.Cut(topic, ":")
+	if !ok || (kind != "project" && kind != "todo") {
+		return "", 0, false
+	}
+	id, err := strconv.Atoi(value)
+	if err != nil || id <= 0 {
+		return "", 0, false
+	}
+	return kind, id, true
+}
+
+func topicMatches(kind string, id int, todoID int, projectID *int) bool {
+	if kind == "todo" {
+		return todoID == id
+	}
+	return projectID != nil && *projectID == id
+}
+
+func socketTodoError(err error) (int, string) {
+	switch {
+	case errors.Is(err, service.ErrVersionConflict):
+		return http.StatusConflict, "Todo was modified concurrently, please retry"
+	case errors.Is(err, service.ErrWIPLimitReached):
+		return http.StatusConflict, err.Error()
+	case errors.Is(err, service.ErrInvalidTodo):
+		return http.StatusUnprocessableEntity, err.Error()
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound, "Todo not found"
+	default:
+		return http.StatusInternalServerError, "Failed to update todo"
+	}
+}
+
+func toPresenceResponse(presence service.Presence) *dto.PresenceResponse {
+	return &dto.PresenceResponse{
+		TodoID:    presence.TodoID,
+		ProjectID: presence.ProjectID,
+		User:      presence.User,
+		State:     presence.State,
+		At:        presence.At,
+	}
+}
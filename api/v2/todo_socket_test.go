@@ -0,0 +1,127 @@
+package v2
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"todo_project/common/actor"
+	"todo_project/common/eventbus"
+	"todo_project/dto"
+	"todo_project/model"
+	"todo_project/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func dialTodoSocket(t *testing.T, handler *TodoSocketHandler) *websocket.Conn {
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Request = c.Request.WithContext(actor.NewContext(c.Request.Context(), "alice"))
+	})
+	r.GET("/test/ws", handler.ServeTodoSocket)
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/test/ws", nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func readSocket(t *testing.T, conn *websocket.Conn) dto.SocketResponse {
+	var resp dto.SocketResponse
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+	require.NoError(t, conn.ReadJSON(&resp))
+	return resp
+}
+
+func TestTodoSocket(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("edit", func(t *testing.T) {
+		mockTs := new(mockTodoService)
+		mockRedis := new(mockRedisClient)
+		mockRedis.On("Delete", "autocomplete_generation").Return(1, nil).Maybe()
+		handler := NewTodoSocketHandler(NewTodoHandler(mockTs, mockRedis), nil, service.NewPresenceTracker(nil, ""))
+		conn := dialTodoSocket(t, handler)
+
+		changes := map[string]interface{}{"status": "done"}
+		mockTs.On("PatchTodo", uint(5), 3, changes).
+			Return(&model.Todo{ID: 5, Name: "Ship it", Status: model.TodoStatusDone, Version: 4}, nil).Once()
+		mockRedis.On("Delete", "todo_5").Return(1, nil).Once()
+
+		require.NoError(t, conn.WriteJSON(dto.SocketRequest{Type: "edit", Ref: "e1", TodoID: 5, Version: 3, Changes: changes}))
+		resp := readSocket(t, conn)
+		assert.Equal(t, "e1", resp.Ref)
+		assert.Equal(t, http.StatusOK, resp.Status)
+		require.NotNil(t, resp.Todo)
+		assert.Equal(t, model.TodoStatusDone, resp.Todo.Status)
+		mockTs.AssertExpectations(t)
+		mockRedis.AssertExpectations(t)
+	})
+
+	t.Run("edit with stale version", func(t *testing.T) {
+		mockTs := new(mockTodoService)
+		handler := NewTodoSocketHandler(NewTodoHandler(mockTs, nil), nil, service.NewPresenceTracker(nil, ""))
+		conn := dialTodoSocket(t, handler)
+
+		changes := map[string]interface{}{"name": "Renamed"}
+		mockTs.On("PatchTodo", uint(5), 2, changes).Return(nil, service.ErrVersionConflict).Once()
+
+		require.NoError(t, conn.WriteJSON(dto.SocketRequest{Type: "edit", Ref: "e2", TodoID: 5, Version: 2, Changes: changes}))
+		resp := readSocket(t, conn)
+		assert.Equal(t, http.StatusConflict, resp.Status)
+		assert.Nil(t, resp.Todo)
+	})
+
+	t.Run("presence and events of subscribed topics", func(t *testing.T) {
+		mockTs := new(mockTodoService)
+		mockEs := new(mockTodoEventSource)
+		live := make(chan eventbus.Message, 2)
+		mockEs.On("Subscribe").Return(live).Once()
+		handler := NewTodoSocketHandler(NewTodoHandler(mockTs, nil), mockEs, service.NewPresenceTracker(nil, ""))
+		conn := dialTodoSocket(t, handler)
+
+		project := 3
+		mockTs.On("GetTodoByID", uint(5)).Return(&model.Todo{ID: 5, ProjectID: &project}, nil).Once()
+
+		require.NoError(t, conn.WriteJSON(dto.SocketRequest{Type: "subscribe", Ref: "s1", Topic: "project:3"}))
+		resp := readSocket(t, conn)
+		assert.Equal(t, "s1", resp.Ref)
+		assert.Equal(t, http.StatusOK, resp.Status)
+
+		require.NoError(t, conn.WriteJSON(dto.SocketRequest{Type: "presence", Ref: "p1", TodoID: 5, State: service.PresenceEditing}))
+		var presence *dto.PresenceResponse
+		for i := 0; i < 2; i++ {
+			resp = readSocket(t, conn)
+			if resp.Type == "presence" {
+				presence = resp.Presence
+			}
+		}
+		require.NotNil(t, presence)
+		assert.Equal(t, "alice", presence.User)
+		assert.Equal(t, service.PresenceEditing, presence.State)
+
+		live <- todoEventMessage("1700000000000-1", "todo.updated", `{"id":9,"project_id":4}`)
+		live <- todoEventMessage("1700000000000-2", "todo.updated", `{"id":5,"project_id":3}`)
+		resp = readSocket(t, conn)
+		assert.Equal(t, "event", resp.Type)
+		assert.Equal(t, "project:3", resp.Topic)
+		assert.Equal(t, "1700000000000-2", resp.Event.ID)
+	})
+
+	t.Run("bad topic", func(t *testing.T) {
+		handler := NewTodoSocketHandler(NewTodoHandler(new(mockTodoService), nil), nil, service.NewPresenceTracker(nil, ""))
+		conn := dialTodoSocket(t, handler)
+
+		require.NoError(t, conn.WriteJSON(dto.SocketRequest{Type: "subscribe", Ref: "s2", Topic: "everything"}))
+		resp := readSocket(t, conn)
+		assert.Equal(t, http.StatusBadRequest, resp.Status)
+	})
+}
@@ -0,0 +1,158 @@
+package v2
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"todo_project/api/todocache"
+	"todo_project/dto"
+	"todo_project/model"
+	"todo_project/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type SyncHandler struct {
+	syncService service.SyncService
+	todos       *TodoHandler
+}
+
+// NewSyncHandler serves delta sync. todos is used to drop cached copies of
+// the todos a push changed.
+func NewSyncHandler(syncService service.SyncService, todos *TodoHandler) *SyncHandler {
+	return &SyncHandler{syncService: syncService, todos: todos}
+}
+
+// GetChanges returns the todos created, updated or deleted since the token in
+// since, or every live todo when since is empty.
+func (h *SyncHandler) GetChanges(c *gin.Context) {
+	changes, err := h.syncService.Changes(c.Request.Context(), c.Query("since"))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidSyncToken) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get changes"})
+		return
+	}
+
+	resp := dto.SyncResponse{
+		Token:   changes.Token,
+		HasMore: changes.HasMore,
+		Changes: make([]dto.SyncChangeResponse, 0, len(changes.Changes)),
+	}
+	for _, change := range changes.Changes {
+		item := dto.SyncChangeResponse{Op: change.Op, ID: change.ID}
+		if change.Todo != nil {
+			item.Version = change.Todo.Version
+			if change.Op == service.SyncOpUpsert {
+				todo := dto.NewTodoResponse(change.Todo)
+				item.Todo = &todo
+			} else {
+				item.DeletedAt = deletedAt(change.Todo)
+			}
+		}
+		resp.Changes = append(resp.Changes, item)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// PushChanges applies a batch of offline mutations. Every mutation stands on
+// its own, so the response is 200 even when some of them were rejected.
+func (h *SyncHandler) PushChanges(c *gin.Context) {
+	var req dto.SyncRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	if len(req.Mutations) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mutations must not be empty"})
+		return
+	}
+	if len(req.Mutations) > MaxBulkOperations {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "too many mutations, the limit is " + strconv.Itoa(MaxBulkOperations)})
+		return
+	}
+
+	mutations := make([]service.SyncMutation, len(req.Mutations))
+	for i, item := range req.Mutations {
+		mutations[i] = toSyncMutation(item)
+	}
+
+	results := h.syncService.Push(c.Request.Context(), mutations)
+
+	resp := dto.SyncPushResponse{Results: make([]dto.SyncResult, len(results))}
+	for i, result := range results {
+		item := dto.SyncResult{
+			Index:     i,
+			ClientID:  result.ClientID,
+			Op:        result.Op,
+			ID:        int(result.ID),
+			Status:    result.Status,
+			Conflicts: result.Conflicts,
+		}
+		if result.Err != nil {
+			item.Error = syncErrorMessage(result.Err)
+		}
+		if result.Todo != nil {
+			item.Version = result.Todo.Version
+			if result.Todo.DeletedAt.Valid {
+				item.DeletedAt = deletedAt(result.Todo)
+			} else {
+				todo := dto.NewTodoResponse(result.Todo)
+				item.Todo = &todo
+			}
+		}
+		if result.Status == service.SyncApplied || result.Status == service.SyncMerged {
+			todocache.Evict(h.todos.redisClient, int(result.ID))
+		}
+		resp.Results[i] = item
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func toSyncMutation(item dto.SyncMutationRequest) service.SyncMutation {
+	mutation := service.SyncMutation{
+		ClientID:    item.ClientID,
+		Op:          item.Op,
+		ID:          uint(item.ID),
+		BaseVersion: item.BaseVersion,
+		Changes:     item.Changes,
+	}
+	if item.Todo != nil {
+		mutation.Todo = &model.Todo{
+			Name:        item.Todo.Name,
+			Description: item.Todo.Description,
+			ProjectID:   item.Todo.ProjectID,
+			ParentID:    item.Todo.ParentID,
+			DueAt:       item.Todo.DueAt,
+			Recurrence:  item.Todo.Recurrence,
+			Timezone:    item.Todo.Timezone,
+		}
+	}
+	return mutation
+}
+
+// syncErrorMessage hides unexpected errors from clients the same way the
+// single-todo endpoints do.
+func syncErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, service.ErrInvalidTodo), errors.Is(err, service.ErrWIPLimitReached), errors.Is(err, service.ErrVersionConflict):
+		return err.Error()
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return "Todo not found"
+	default:
+		return "Failed to apply mutation"
+	}
+}
+
+func deletedAt(todo *model.Todo) *time.Time {
+	if !todo.DeletedAt.Valid {
+		return nil
+	}
+	at := todo.DeletedAt.Time
+	return &at
+}
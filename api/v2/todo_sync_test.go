@@ -0,0 +1,138 @@
+package v2
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"todo_project/dto"
+	"todo_project/model"
+	"todo_project/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// Mock sync service
+type mockSyncService struct {
+	mock.Mock
+}
+
+func (m *mockSyncService) Changes(ctx context.Context, since string) (*service.SyncChanges, error) {
+	args := m.Called(since)
+	var result *service.SyncChanges
+	if args.Get(0) != nil {
+		result = args.Get(0).(*service.SyncChanges)
+	}
+
+	return result, args.Error(1)
+}
+
+func (m *mockSyncService) Push(ctx context.Context, mutations []service.SyncMutation) []service.SyncResult {
+	args := m.Called(mutations)
+	return args.Get(0).([]service.SyncResult)
+}
+
+func TestSync(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSs := new(mockSyncService)
+	mockRedis := new(mockRedisClient)
+	mockRedis.On("Delete", "autocomplete_generation").Return(1, nil).Maybe()
+	handler := NewSyncHandler(mockSs, NewTodoHandler(new(mockTodoService), mockRedis))
+
+	r := gin.Default()
+	r.GET("/test/sync", handler.GetChanges)
+	r.POST("/test/sync", handler.PushChanges)
+
+	t.Run("changes with tombstones", func(t *testing.T) {
+		deletedAt := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+		mockSs.On("Changes", "1200").Return(&service.SyncChanges{
+			Token:   "1250",
+			HasMore: true,
+			Changes: []service.SyncChange{
+				{Op: service.SyncOpUpsert, ID: 1, Todo: &model.Todo{ID: 1, Name: "Write docs", Status: model.TodoStatusDoing, Version: 4}},
+				{Op: service.SyncOpDelete, ID: 2, Todo: &model.Todo{ID: 2, Version: 2, DeletedAt: gorm.DeletedAt{Time: deletedAt, Valid: true}}},
+				{Op: service.SyncOpDelete, ID: 3},
+			},
+		}, nil).Once()
+
+		req, _ := http.NewRequest(http.MethodGet, "/test/sync?since=1200", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp dto.SyncResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "1250", resp.Token)
+		assert.True(t, resp.HasMore)
+		assert.Len(t, resp.Changes, 3)
+		assert.Equal(t, 4, resp.Changes[0].Version)
+		assert.Equal(t, "Write docs", resp.Changes[0].Todo.Name)
+		assert.Nil(t, resp.Changes[1].Todo)
+		assert.True(t, deletedAt.Equal(*resp.Changes[1].DeletedAt))
+		assert.Nil(t, resp.Changes[2].DeletedAt)
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		mockSs.On("Changes", "yesterday").Return(nil, fmt.Errorf("%w: %q", service.ErrInvalidSyncToken, "yesterday")).Once()
+
+		req, _ := http.NewRequest(http.MethodGet, "/test/sync?since=yesterday", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("push", func(t *testing.T) {
+		mockRedis.On("Delete", "todo_5").Return(1, nil).Once()
+		mockSs.On("Push", mock.MatchedBy(func(mutations []service.SyncMutation) bool {
+			return len(mutations) == 3 &&
+				mutations[0].Op == service.BulkOpCreate && mutations[0].Todo.Name == "Offline todo" &&
+				mutations[1].ClientID == "m-2" && mutations[1].BaseVersion == 3 && mutations[1].Changes["status"] == "done" &&
+				mutations[2].Op == service.BulkOpDelete && mutations[2].ID == 9
+		})).Return([]service.SyncResult{
+			{ClientID: "m-1", Op: service.BulkOpCreate, Status: service.SyncError, Err: fmt.Errorf("%w: field %q is required", service.ErrInvalidTodo, "description")},
+			{ClientID: "m-2", Op: service.BulkOpUpdate, ID: 5, Status: service.SyncMerged, Conflicts: []string{"name"},
+				Todo: &model.Todo{ID: 5, Name: "Server name", Status: model.TodoStatusDone, Version: 6}},
+			{ClientID: "m-3", Op: service.BulkOpDelete, ID: 9, Status: service.SyncConflict,
+				Todo: &model.Todo{ID: 9, Name: "Edited meanwhile", Version: 2}},
+		}).Once()
+
+		body, _ := json.Marshal(dto.SyncRequest{Mutations: []dto.SyncMutationRequest{
+			{ClientID: "m-1", Op: "create", Todo: &dto.CreateTodoRequest{Name: "Offline todo"}},
+			{ClientID: "m-2", Op: "update", ID: 5, BaseVersion: 3, Changes: map[string]interface{}{"name": "Client name", "status": "done"}},
+			{ClientID: "m-3", Op: "delete", ID: 9, BaseVersion: 1},
+		}})
+		req, _ := http.NewRequest(http.MethodPost, "/test/sync", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp dto.SyncPushResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Len(t, resp.Results, 3)
+		assert.Equal(t, service.SyncError, resp.Results[0].Status)
+		assert.Contains(t, resp.Results[0].Error, "description")
+		assert.Equal(t, service.SyncMerged, resp.Results[1].Status)
+		assert.Equal(t, []string{"name"}, resp.Results[1].Conflicts)
+		assert.Equal(t, 6, resp.Results[1].Version)
+		assert.Equal(t, service.SyncConflict, resp.Results[2].Status)
+		assert.Equal(t, "Edited meanwhile", resp.Results[2].Todo.Name)
+		mockRedis.AssertExpectations(t)
+	})
+
+	t.Run("empty push", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodPost, "/test/sync", bytes.NewBufferString(`{"mutations": []}`))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -0,0 +1,82 @@
+package v2
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"todo_project/api/todocache"
+	"todo_project/dto"
+	"todo_project/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func (h *TodoHandler) GetTrash(c *gin.Context) {
+	list, err := h.todoService.GetTrash()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get trash"})
+		return
+	}
+
+	resp := make([]dto.TrashedTodoResponse, 0, len(list))
+	for _, s := range list {
+		resp = append(resp, dto.TrashedTodoResponse{
+			TodoResponse: dto.NewTodoResponse(s),
+			DeletedAt:    s.DeletedAt.Time,
+		})
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *TodoHandler) RestoreTodo(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	todo, err := h.todoService.RestoreTodo(c.Request.Context(), uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found in trash"})
+			return
+		}
+		if errors.Is(err, service.ErrWIPLimitReached) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore todo"})
+		return
+	}
+
+	todocache.Evict(h.redisClient, id)
+
+	resp := dto.NewTodoResponse(todo)
+
+	c.Header("ETag", dto.TodoETag(todo))
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *TodoHandler) PurgeTodo(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	if err := h.todoService.PurgeTodo(c.Request.Context(), uint(id)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found in trash"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to purge todo"})
+		return
+	}
+
+	todocache.Evict(h.redisClient, id)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Todo permanently deleted"})
+}
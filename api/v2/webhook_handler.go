@@ -0,0 +1,215 @@
+package v2
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"todo_project/dto"
+	"todo_project/model"
+	"todo_project/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type WebhookHandler struct {
+	webhookService service.WebhookService
+}
+
+func NewWebhookHandler(webhookService service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{webhookService: webhookService}
+}
+
+// CreateWebhook subscribes to todo events. The response is the only one
+// carrying the signing secret.
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var req dto.WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.URL == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	hook := toWebhook(req)
+	if err := h.webhookService.CreateWebhook(c.Request.Context(), hook); err != nil {
+		writeWebhookError(c, err, "Failed to create webhook")
+		return
+	}
+
+	resp := toWebhookResponse(hook)
+	resp.Secret = hook.Secret
+	c.JSON(http.StatusCreated, resp)
+}
+
+func (h *WebhookHandler) GetWebhooks(c *gin.Context) {
+	hooks, err := h.webhookService.ListWebhooks(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get webhooks"})
+		return
+	}
+
+	resp := make([]dto.WebhookResponse, 0, len(hooks))
+	for _, hook := range hooks {
+		resp = append(resp, toWebhookResponse(hook))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	hook, err := h.webhookService.GetWebhook(c.Request.Context(), uint(id))
+	if err != nil {
+		writeWebhookError(c, err, "Failed to get webhook")
+		return
+	}
+	c.JSON(http.StatusOK, toWebhookResponse(hook))
+}
+
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var req dto.WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.URL == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	hook := toWebhook(req)
+	hook.ID = uint(id)
+	if err := h.webhookService.UpdateWebhook(c.Request.Context(), hook); err != nil {
+		writeWebhookError(c, err, "Failed to update webhook")
+		return
+	}
+	c.JSON(http.StatusOK, toWebhookResponse(hook))
+}
+
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	if err := h.webhookService.DeleteWebhook(c.Request.Context(), uint(id)); err != nil {
+		writeWebhookError(c, err, "Failed to delete webhook")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook deleted successfully"})
+}
+
+// GetDeliveries pages through the delivery log of a webhook, newest first.
+func (h *WebhookHandler) GetDeliveries(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	page, pageSize, err := parsePage(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	deliveries, err := h.webhookService.GetDeliveries(c.Request.Context(), uint(id), pageSize, (page-1)*pageSize)
+	if err != nil {
+		writeWebhookError(c, err, "Failed to get deliveries")
+		return
+	}
+
+	resp := make([]dto.WebhookDeliveryResponse, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		resp = append(resp, toWebhookDeliveryResponse(delivery))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Redeliver queues a past delivery to be sent again.
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	deliveryID, err := strconv.Atoi(c.Param("delivery_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid delivery ID"})
+		return
+	}
+
+	delivery, err := h.webhookService.Redeliver(c.Request.Context(), uint(id), uint(deliveryID))
+	if err != nil {
+		writeWebhookError(c, err, "Failed to redeliver")
+		return
+	}
+	c.JSON(http.StatusAccepted, toWebhookDeliveryResponse(delivery))
+}
+
+func writeWebhookError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidWebhook):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
+
+func toWebhook(req dto.WebhookRequest) *model.Webhook {
+	active := true
+	if req.Active != nil {
+		active = *req.Active
+	}
+	return &model.Webhook{
+		URL:    req.URL,
+		Secret: req.Secret,
+		Events: strings.Join(req.Events, ","),
+		Active: active,
+	}
+}
+
+func toWebhookResponse(hook *model.Webhook) dto.WebhookResponse {
+	events := hook.EventList()
+	if events == nil {
+		events = []string{}
+	}
+	return dto.WebhookResponse{
+		ID:        hook.ID,
+		URL:       hook.URL,
+		Events:    events,
+		Active:    hook.Active,
+		CreatedAt: hook.CreatedAt,
+		UpdatedAt: hook.UpdatedAt,
+	}
+}
+
+func toWebhookDeliveryResponse(delivery *model.WebhookDelivery) dto.WebhookDeliveryResponse {
+	resp := dto.WebhookDeliveryResponse{
+		ID:             delivery.ID,
+		EventID:        delivery.EventID,
+		Event:          delivery.Event,
+		TodoID:         delivery.TodoID,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		ResponseStatus: delivery.ResponseStatus,
+		LastError:      delivery.LastError,
+		DeliveredAt:    delivery.DeliveredAt,
+		CreatedAt:      delivery.CreatedAt,
+		Payload:        json.RawMessage(delivery.Payload),
+	}
+	if delivery.Status == model.DeliveryStatusPending {
+		next := delivery.NextAttemptAt
+		resp.NextAttemptAt = &next
+	}
+	return resp
+}
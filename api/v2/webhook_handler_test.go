@@ -0,0 +1,210 @@
+package v2
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"todo_project/dto"
+	"todo_project/model"
+	"todo_project/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// Mock webhook service
+type mockWebhookService struct {
+	mock.Mock
+}
+
+func (m *mockWebhookService) CreateWebhook(ctx context.Context, hook *model.Webhook) error {
+	args := m.Called(hook)
+	return args.Error(0)
+}
+
+func (m *mockWebhookService) ListWebhooks(ctx context.Context) ([]*model.Webhook, error) {
+	args := m.Called()
+	var result []*model.Webhook
+	if args.Get(0) != nil {
+		result = args.Get(0).([]*model.Webhook)
+	}
+
+	return result, args.Error(1)
+}
+
+func (m *mockWebhookService) GetWebhook(ctx context.Context, id uint) (*model.Webhook, error) {
+	args := m.Called(id)
+	var result *model.Webhook
+	if args.Get(0) != nil {
+		result = args.Get(0).(*model.Webhook)
+	}
+
+	return result, args.Error(1)
+}
+
+func (m *mockWebhookService) UpdateWebhook(ctx context.Context, hook *model.Webhook) error {
+	args := m.Called(hook)
+	return args.Error(0)
+}
+
+func (m *mockWebhookService) DeleteWebhook(ctx context.Context, id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *mockWebhookService) GetDeliveries(ctx context.Context, webhookID uint, limit int, offset int) ([]*model.WebhookDelivery, error) {
+	args := m.Called(webhookID, limit, offset)
+	var result []*model.WebhookDelivery
+	if args.Get(0) != nil {
+		result = args.Get(0).([]*model.WebhookDelivery)
+	}
+
+	return result, args.Error(1)
+}
+
+func (m *mockWebhookService) Redeliver(ctx context.Context, webhookID uint, deliveryID uint) (*model.WebhookDelivery, error) {
+	args := m.Called(webhookID, deliveryID)
+	var result *model.WebhookDelivery
+	if args.Get(0) != nil {
+		result = args.Get(0).(*model.WebhookDelivery)
+	}
+
+	return result, args.Error(1)
+}
+
+func (m *mockWebhookService) DueDeliveries(limit int) ([]*model.WebhookDelivery, error) {
+	args := m.Called(limit)
+	var result []*model.WebhookDelivery
+	if args.Get(0) != nil {
+		result = args.Get(0).([]*model.WebhookDelivery)
+	}
+
+	return result, args.Error(1)
+}
+
+func (m *mockWebhookService) Deliver(ctx context.Context, id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func TestWebhooks(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockWs := new(mockWebhookService)
+	handler := NewWebhookHandler(mockWs)
+
+	r := gin.Default()
+	r.POST("/test/webhooks", handler.CreateWebhook)
+	r.GET("/test/webhooks", handler.GetWebhooks)
+	r.GET("/test/webhooks/:id/deliveries", handler.GetDeliveries)
+	r.POST("/test/webhooks/:id/deliveries/:delivery_id/redeliver", handler.Redeliver)
+
+	t.Run("create returns the secret once", func(t *testing.T) {
+		mockWs.On("CreateWebhook", mock.MatchedBy(func(hook *model.Webhook) bool {
+			return hook.URL == "https://example.com/hook" &&
+				hook.Events == "todo.created,todo.completed" && hook.Active
+		})).Run(func(args mock.Arguments) {
+			hook := args.Get(0).(*model.Webhook)
+			hook.ID = 3
+			hook.Secret = "s3cr3t"
+		}).Return(nil).Once()
+
+		body, _ := json.Marshal(dto.WebhookRequest{
+			URL:    "https://example.com/hook",
+			Events: []string{"todo.created", "todo.completed"},
+		})
+		req, _ := http.NewRequest(http.MethodPost, "/test/webhooks", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var resp dto.WebhookResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, uint(3), resp.ID)
+		assert.Equal(t, "s3cr3t", resp.Secret)
+		assert.Equal(t, []string{"todo.created", "todo.completed"}, resp.Events)
+	})
+
+	t.Run("create with unknown event", func(t *testing.T) {
+		mockWs.On("CreateWebhook", mock.Anything).
+			Return(fmt.Errorf("%w: unknown event %q", service.ErrInvalidWebhook, "todo.archived")).Once()
+
+		body, _ := json.Marshal(dto.WebhookRequest{URL: "https://example.com/hook", Events: []string{"todo.archived"}})
+		req, _ := http.NewRequest(http.MethodPost, "/test/webhooks", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	})
+
+	t.Run("list hides secrets", func(t *testing.T) {
+		mockWs.On("ListWebhooks").Return([]*model.Webhook{
+			{ID: 3, URL: "https://example.com/hook", Secret: "s3cr3t", Active: true},
+		}, nil).Once()
+
+		req, _ := http.NewRequest(http.MethodGet, "/test/webhooks", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), "s3cr3t")
+		var resp []dto.WebhookResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, []string{}, resp[0].Events)
+	})
+
+	t.Run("delivery log", func(t *testing.T) {
+		mockWs.On("GetDeliveries", uint(3), defaultPageSize, 0).Return([]*model.WebhookDelivery{
+			{ID: 12, WebhookID: 3, EventID: "40-todo.updated", Event: "todo.updated", TodoID: 1,
+				Payload: `{"event":"todo.updated"}`, Status: model.DeliveryStatusPending, Attempts: 2,
+				ResponseStatus: 503, LastError: "subscriber responded with 503 Service Unavailable",
+				NextAttemptAt: time.Now().Add(time.Minute)},
+		}, nil).Once()
+
+		req, _ := http.NewRequest(http.MethodGet, "/test/webhooks/3/deliveries", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp []dto.WebhookDeliveryResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Len(t, resp, 1)
+		assert.Equal(t, 503, resp[0].ResponseStatus)
+		assert.NotNil(t, resp[0].NextAttemptAt)
+		assert.JSONEq(t, `{"event":"todo.updated"}`, string(resp[0].Payload))
+	})
+
+	t.Run("redeliver", func(t *testing.T) {
+		mockWs.On("Redeliver", uint(3), uint(12)).Return(&model.WebhookDelivery{
+			ID: 13, WebhookID: 3, EventID: "40-todo.updated", Event: "todo.updated",
+			Payload: `{}`, Status: model.DeliveryStatusPending,
+		}, nil).Once()
+
+		req, _ := http.NewRequest(http.MethodPost, "/test/webhooks/3/deliveries/12/redeliver", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		var resp dto.WebhookDeliveryResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, uint(13), resp.ID)
+		assert.Equal(t, "40-todo.updated", resp.EventID)
+	})
+
+	t.Run("redeliver from another webhook", func(t *testing.T) {
+		mockWs.On("Redeliver", uint(4), uint(12)).Return(nil, gorm.ErrRecordNotFound).Once()
+
+		req, _ := http.NewRequest(http.MethodPost, "/test/webhooks/4/deliveries/12/redeliver", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
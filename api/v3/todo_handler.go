@@ -0,0 +1,328 @@
+// Package v3 serves the todo API with Huma: every operation has typed input
+// and output, requests are validated against them and the OpenAPI 3.1 spec is
+// generated from the same types.
+package v3
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"todo_project/api/todocache"
+	"todo_project/common/filter"
+	"todo_project/common/log"
+	"todo_project/dto"
+	"todo_project/internal/redis"
+	"todo_project/model"
+	"todo_project/service"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/conditional"
+	"gorm.io/gorm"
+)
+
+type TodoHandler struct {
+	todoService service.TodoService
+	redisClient redis.IRedis
+}
+
+// NewTodoHandler serves todos from todoService. redisClient is used to drop
+// the copies the v2 API cached and may be nil.
+func NewTodoHandler(todoService service.TodoService, redisClient redis.IRedis) *TodoHandler {
+	return &TodoHandler{todoService: todoService, redisClient: redisClient}
+}
+
+type TodoIDInput struct {
+	ID int `path:"id" minimum:"1" doc:"ID of the todo"`
+}
+
+type ListTodosInput struct {
+	Filter string `query:"filter" doc:"Filter expression, e.g. status:doing AND name:deploy*"`
+	Sort   string `query:"sort" doc:"Comma separated fields to sort by, - for descending"`
+}
+
+type ListTodosOutput struct {
+	Body []dto.TodoResponse
+}
+
+type GetTodoInput struct {
+	TodoIDInput
+	conditional.Params
+}
+
+type CreateTodoInput struct {
+	Body dto.CreateTodoRequest
+}
+
+type ReplaceTodoInput struct {
+	TodoIDInput
+	conditional.Params
+	Body dto.ReplaceTodoRequest
+}
+
+type PatchTodoInput struct {
+	TodoIDInput
+	conditional.Params
+	Body dto.PatchTodoRequest
+}
+
+type TodoOutput struct {
+	ETag string `header:"ETag"`
+	Body dto.TodoResponse
+}
+
+type TodoHistoryOutput struct {
+	Body []dto.TodoHistoryResponse
+}
+
+// Register adds the todo operations to api.
+func (h *TodoHandler) Register(api huma.API) {
+	huma.Register(api, huma.Operation{
+		OperationID: "list-todos",
+		Method:      http.MethodGet,
+		Path:        "/todo",
+		Summary:     "List todos",
+		Tags:        []string{"todo"},
+	}, h.ListTodos)
+	huma.Register(api, huma.Operation{
+		OperationID:   "create-todo",
+		Method:        http.MethodPost,
+		Path:          "/todo",
+		Summary:       "Create a todo",
+		Tags:          []string{"todo"},
+		DefaultStatus: http.StatusCreated,
+	}, h.CreateTodo)
+	huma.Register(api, huma.Operation{
+		OperationID: "get-todo",
+		Method:      http.MethodGet,
+		Path:        "/todo/{id}",
+		Summary:     "Get a todo",
+		Tags:        []string{"todo"},
+	}, h.GetTodo)
+	huma.Register(api, huma.Operation{
+		OperationID: "replace-todo",
+		Method:      http.MethodPut,
+		Path:        "/todo/{id}",
+		Summary:     "Replace the name and description of a todo",
+		Tags:        []string{"todo"},
+	}, h.ReplaceTodo)
+	huma.Register(api, huma.Operation{
+		OperationID: "patch-todo",
+		Method:      http.MethodPatch,
+		Path:        "/todo/{id}",
+		Summary:     "Change some fields of a todo",
+		Tags:        []string{"todo"},
+	}, h.PatchTodo)
+	huma.Register(api, huma.Operation{
+		OperationID:   "delete-todo",
+		Method:        http.MethodDelete,
+		Path:          "/todo/{id}",
+		Summary:       "Delete a todo",
+		Tags:          []string{"todo"},
+		DefaultStatus: http.StatusNoContent,
+	}, h.DeleteTodo)
+	huma.Register(api, huma.Operation{
+		OperationID: "get-todo-history",
+		Method:      http.MethodGet,
+		Path:        "/todo/{id}/history",
+		Summary:     "List every change made to a todo",
+		Tags:        []string{"todo"},
+	}, h.GetTodoHistory)
+}
+
+func (h *TodoHandler) ListTodos(ctx context.Context, input *ListTodosInput) (*ListTodosOutput, error) {
+	query := model.TodoQuery{}
+	if input.Filter != "" {
+		expr, err := filter.Parse(input.Filter)
+		if err == nil {
+			err = filter.Validate(expr, model.TodoFilterFields)
+		}
+		if err != nil {
+			return nil, huma.Error400BadRequest(err.Error())
+		}
+		query.Filter = expr
+	}
+	keys, err := filter.ParseSort(input.Sort)
+	if err == nil {
+		_, err = filter.CompileSort(keys, model.TodoFilterFields)
+	}
+	if err != nil {
+		return nil, huma.Error400BadRequest(err.Error())
+	}
+	query.Sort = keys
+
+	todos, err := h.todoService.ListTodos(query)
+	if err != nil {
+		return nil, todoError(err, "Failed to get todos")
+	}
+	out := &ListTodosOutput{Body: make([]dto.TodoResponse, 0, len(todos))}
+	for _, todo := range todos {
+		out.Body = append(out.Body, dto.NewTodoResponse(todo))
+	}
+	return out, nil
+}
+
+func (h *TodoHandler) CreateTodo(ctx context.Context, input *CreateTodoInput) (*TodoOutput, error) {
+	todo := &model.Todo{
+		Name:        input.Body.Name,
+		Description: input.Body.Description,
+		ProjectID:   input.Body.ProjectID,
+		ParentID:    input.Body.ParentID,
+		DueAt:       input.Body.DueAt,
+		Recurrence:  input.Body.Recurrence,
+		Timezone:    input.Body.Timezone,
+	}
+	if err := h.todoService.CreateTodo(ctx, todo); err != nil {
+		return nil, todoError(err, "Failed to create todo")
+	}
+	todocache.InvalidateAutocomplete(h.redisClient)
+	return newTodoOutput(todo), nil
+}
+
+// GetTodo answers 304 when If-None-Match lists the current ETag.
+func (h *TodoHandler) GetTodo(ctx context.Context, input *GetTodoInput) (*TodoOutput, error) {
+	todo, err := h.todoService.GetTodoByID(uint(input.ID))
+	if err != nil {
+		return nil, huma.Error404NotFound("Todo not found")
+	}
+	if input.HasConditionalParams() {
+		if err := input.PreconditionFailed(opaqueETag(todo), todo.UpdatedAt); err != nil {
+			return nil, err
+		}
+	}
+	return newTodoOutput(todo), nil
+}
+
+func (h *TodoHandler) ReplaceTodo(ctx context.Context, input *ReplaceTodoInput) (*TodoOutput, error) {
+	existing, err := h.checkPreconditions(input.ID, &input.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	todo := *existing
+	todo.Name = input.Body.Name
+	todo.Description = input.Body.Description
+	if err := h.todoService.UpdateTodo(ctx, &todo); err != nil {
+		return nil, h.writeError(err, &input.Params, "Failed to update todo")
+	}
+	todocache.Evict(h.redisClient, todo.ID)
+	return newTodoOutput(&todo), nil
+}
+
+func (h *TodoHandler) PatchTodo(ctx context.Context, input *PatchTodoInput) (*TodoOutput, error) {
+	existing, err := h.checkPreconditions(input.ID, &input.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := map[string]interface{}{}
+	for column, value := range map[string]*string{
+		"name":        input.Body.Name,
+		"description": input.Body.Description,
+		"status":      input.Body.Status,
+		"due_at":      input.Body.DueAt,
+		"recurrence":  input.Body.Recurrence,
+		"timezone":    input.Body.Timezone,
+	} {
+		if value != nil {
+			changes[column] = *value
+		}
+	}
+
+	todo, err := h.todoService.PatchTodo(ctx, uint(input.ID), existing.Version, changes)
+	if err != nil {
+		return nil, h.writeError(err, &input.Params, "Failed to update todo")
+	}
+	todocache.Evict(h.redisClient, todo.ID)
+	return newTodoOutput(todo), nil
+}
+
+func (h *TodoHandler) DeleteTodo(ctx context.Context, input *TodoIDInput) (*struct{}, error) {
+	if _, err := h.todoService.GetTodoByID(uint(input.ID)); err != nil {
+		return nil, huma.Error404NotFound("Todo not found")
+	}
+	if err := h.todoService.DeleteTodo(ctx, uint(input.ID)); err != nil {
+		return nil, todoError(err, "Failed to delete todo")
+	}
+	todocache.Evict(h.redisClient, input.ID)
+	return nil, nil
+}
+
+func (h *TodoHandler) GetTodoHistory(ctx context.Context, input *TodoIDInput) (*TodoHistoryOutput, error) {
+	entries, err := h.todoService.GetTodoHistory(uint(input.ID))
+	if err != nil {
+		return nil, todoError(err, "Failed to get todo history")
+	}
+
+	out := &TodoHistoryOutput{Body: make([]dto.TodoHistoryResponse, 0, len(entries))}
+	for _, entry := range entries {
+		changes := make(map[string]dto.FieldChangeResponse, len(entry.Changes))
+		for field, change := range entry.Changes {
+			changes[field] = dto.FieldChangeResponse{Old: change.Old, New: change.New}
+		}
+		out.Body = append(out.Body, dto.TodoHistoryResponse{
+			ID:        entry.ID,
+			TodoID:    entry.TodoID,
+			Version:   entry.Version,
+			Action:    entry.Action,
+			Actor:     entry.Actor,
+			Changes:   changes,
+			CreatedAt: entry.CreatedAt,
+		})
+	}
+	return out, nil
+}
+
+// checkPreconditions loads the todo a write applies to and enforces If-Match
+// against it, failing with 412.
+func (h *TodoHandler) checkPreconditions(id int, params *conditional.Params) (*model.Todo, error) {
+	todo, err := h.todoService.GetTodoByID(uint(id))
+	if err != nil {
+		return nil, huma.Error404NotFound("Todo not found")
+	}
+	if params.HasConditionalParams() {
+		if err := params.PreconditionFailed(opaqueETag(todo), todo.UpdatedAt); err != nil {
+			return nil, err
+		}
+	}
+	return todo, nil
+}
+
+// writeError reports a failed write. A write that lost the race against
+// another update is a 412 for clients that sent If-Match, a 409 otherwise.
+func (h *TodoHandler) writeError(err error, params *conditional.Params, message string) error {
+	if errors.Is(err, service.ErrVersionConflict) {
+		if len(params.IfMatch) > 0 {
+			return huma.Error412PreconditionFailed("Todo has been modified")
+		}
+		return huma.Error409Conflict("Todo was modified concurrently, please retry")
+	}
+	return todoError(err, message)
+}
+
+// todoError converts a service error to a response, logging the errors
+// clients are not told about.
+func todoError(err error, message string) error {
+	switch {
+	case errors.Is(err, service.ErrInvalidTodo):
+		return huma.Error422UnprocessableEntity(err.Error())
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return huma.Error404NotFound("Todo not found")
+	case errors.Is(err, service.ErrVersionConflict), errors.Is(err, service.ErrWIPLimitReached):
+		return huma.Error409Conflict(err.Error())
+	default:
+		log.Errorf("%s: %v", message, err)
+		return huma.Error500InternalServerError(message)
+	}
+}
+
+// opaqueETag is the entity tag of a todo without its quotes, as the
+// conditional parameters compare it.
+func opaqueETag(todo *model.Todo) string {
+	return strings.Trim(dto.TodoETag(todo), `"`)
+}
+
+func newTodoOutput(todo *model.Todo) *TodoOutput {
+	return &TodoOutput{ETag: dto.TodoETag(todo), Body: dto.NewTodoResponse(todo)}
+}
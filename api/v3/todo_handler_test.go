@@ -0,0 +1,193 @@
+package v3
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"todo_project/common/err_response"
+	"todo_project/model"
+	"todo_project/service"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/adapters/humagin"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock todo service; only the methods the handler calls are mocked.
+type mockTodoService struct {
+	service.TodoService
+	mock.Mock
+}
+
+func (m *mockTodoService) CreateTodo(ctx context.Context, todo *model.Todo) error {
+	args := m.Called(todo)
+	return args.Error(0)
+}
+
+func (m *mockTodoService) GetTodoByID(id uint) (*model.Todo, error) {
+	args := m.Called(id)
+	var result *model.Todo
+	if args.Get(0) != nil {
+		result = args.Get(0).(*model.Todo)
+	}
+
+	return result, args.Error(1)
+}
+
+func (m *mockTodoService) PatchTodo(ctx context.Context, id uint, version int, changes map[string]interface{}) (*model.Todo, error) {
+	args := m.Called(id, version, changes)
+	var result *model.Todo
+	if args.Get(0) != nil {
+		result = args.Get(0).(*model.Todo)
+	}
+
+	return result, args.Error(1)
+}
+
+func newTestRouter(todoService service.TodoService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	err_response.NewHumaError()
+	r := gin.New()
+	group := r.Group("/api/v3")
+	config := huma.DefaultConfig("todo_project API", "3.0.0")
+	config.Servers = []*huma.Server{{URL: group.BasePath()}}
+	NewTodoHandler(todoService, nil).Register(humagin.NewWithGroup(r, group, config))
+	return r
+}
+
+func TestTodoHandler(t *testing.T) {
+	t.Run("create", func(t *testing.T) {
+		mockSv := new(mockTodoService)
+		r := newTestRouter(mockSv)
+		mockSv.On("CreateTodo", mock.MatchedBy(func(todo *model.Todo) bool {
+			return todo.Name == "Write docs"
+		})).Run(func(args mock.Arguments) {
+			todo := args.Get(0).(*model.Todo)
+			todo.ID, todo.Version, todo.Status = 7, 1, model.TodoStatusTodo
+		}).Return(nil).Once()
+
+		req, _ := http.NewRequest(http.MethodPost, "/api/v3/todo", bytes.NewBufferString(`{"name":"Write docs","description":"API"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, `"7-1"`, w.Header().Get("ETag"))
+		var resp map[string]interface{}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, float64(7), resp["id"])
+		mockSv.AssertExpectations(t)
+	})
+
+	t.Run("create without name", func(t *testing.T) {
+		mockSv := new(mockTodoService)
+		r := newTestRouter(mockSv)
+
+		req, _ := http.NewRequest(http.MethodPost, "/api/v3/todo", bytes.NewBufferString(`{"description":"API"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		var resp map[string]interface{}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "ERR_REQUEST_INVALID", resp["code"])
+		assert.Contains(t, w.Body.String(), "expected required property name to be present")
+		mockSv.AssertNotCalled(t, "CreateTodo", mock.Anything)
+	})
+
+	t.Run("get not modified", func(t *testing.T) {
+		mockSv := new(mockTodoService)
+		r := newTestRouter(mockSv)
+		mockSv.On("GetTodoByID", uint(7)).Return(&model.Todo{ID: 7, Version: 3}, nil).Once()
+
+		req, _ := http.NewRequest(http.MethodGet, "/api/v3/todo/7", nil)
+		req.Header.Set("If-None-Match", `"7-3"`)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotModified, w.Code)
+	})
+
+	t.Run("get not found", func(t *testing.T) {
+		mockSv := new(mockTodoService)
+		r := newTestRouter(mockSv)
+		mockSv.On("GetTodoByID", uint(8)).Return(nil, assert.AnError).Once()
+
+		req, _ := http.NewRequest(http.MethodGet, "/api/v3/todo/8", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Body.String(), `"code":"ERR_REQUEST_NOTFOUND"`)
+	})
+
+	t.Run("patch", func(t *testing.T) {
+		mockSv := new(mockTodoService)
+		r := newTestRouter(mockSv)
+		mockSv.On("GetTodoByID", uint(7)).Return(&model.Todo{ID: 7, Version: 3}, nil).Once()
+		mockSv.On("PatchTodo", uint(7), 3, map[string]interface{}{"status": "done"}).
+			Return(&model.Todo{ID: 7, Version: 4, Status: "done"}, nil).Once()
+
+		req, _ := http.NewRequest(http.MethodPatch, "/api/v3/todo/7", bytes.NewBufferString(`{"status":"done"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", `"7-3"`)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `"7-4"`, w.Header().Get("ETag"))
+		mockSv.AssertExpectations(t)
+	})
+
+	t.Run("patch with stale etag", func(t *testing.T) {
+		mockSv := new(mockTodoService)
+		r := newTestRouter(mockSv)
+		mockSv.On("GetTodoByID", uint(7)).Return(&model.Todo{ID: 7, Version: 4}, nil).Once()
+
+		req, _ := http.NewRequest(http.MethodPatch, "/api/v3/todo/7", bytes.NewBufferString(`{"status":"done"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", `"7-3"`)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+		mockSv.AssertNotCalled(t, "PatchTodo", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("invalid status", func(t *testing.T) {
+		r := newTestRouter(new(mockTodoService))
+
+		req, _ := http.NewRequest(http.MethodPatch, "/api/v3/todo/7", bytes.NewBufferString(`{"status":"later"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	})
+
+	t.Run("openapi spec", func(t *testing.T) {
+		r := newTestRouter(new(mockTodoService))
+
+		req, _ := http.NewRequest(http.MethodGet, "/api/v3/openapi.json", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var spec struct {
+			OpenAPI string                 `json:"openapi"`
+			Paths   map[string]interface{} `json:"paths"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &spec))
+		assert.Equal(t, "3.1.0", spec.OpenAPI)
+		assert.Contains(t, spec.Paths, "/todo")
+		assert.Contains(t, spec.Paths, "/todo/{id}")
+		assert.Contains(t, spec.Paths, "/todo/{id}/history")
+	})
+}
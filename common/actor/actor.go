@@ -0,0 +1,46 @@
+package actor
+
+import "context"
+
+const (
+	// Anonymous is used for authenticated requests that did not say who is
+	// acting on whose behalf.
+	Anonymous = "anonymous"
+	// System is used for changes made by the service itself, e.g. background jobs.
+	System = "system"
+)
+
+type ctxKey struct{}
+
+type verifiedKey struct{}
+
+func NewContext(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, actor)
+}
+
+// NewVerifiedContext stores actor in ctx as authenticated by an API key of
+// its own, see Authenticate.
+func NewVerifiedContext(ctx context.Context, actor string) context.Context {
+	return context.WithValue(NewContext(ctx, actor), verifiedKey{}, true)
+}
+
+// Verified reports whether the actor in ctx was authenticated rather than
+// named by a trusted caller.
+func Verified(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	verified, _ := ctx.Value(verifiedKey{}).(bool)
+	return verified
+}
+
+// FromContext returns the actor stored in ctx, or System when there is none.
+func FromContext(ctx context.Context) string {
+	if ctx == nil {
+		return System
+	}
+	if actor, ok := ctx.Value(ctxKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return System
+}
@@ -0,0 +1,49 @@
+package actor
+
+import (
+	"context"
+	"crypto/subtle"
+	"os"
+	"strings"
+)
+
+// Authenticate checks an API key and returns ctx with the user the request
+// acts for.
+//
+// Keys listed in the API_KEYS environment variable, as comma separated
+// key=user pairs, belong to one user: the actor is that user, Verified, and a
+// userID naming anyone else is refused. The shared API_KEY is a service key
+// trusted to act for any user: the actor is userID as given, or Anonymous, and
+// is not Verified.
+func Authenticate(ctx context.Context, key string, userID string) (context.Context, bool) {
+	if key == "" {
+		return nil, false
+	}
+	if user, ok := userForKey(key); ok {
+		if userID != "" && userID != user {
+			return nil, false
+		}
+		return NewVerifiedContext(ctx, user), true
+	}
+	if !equal(key, os.Getenv("API_KEY")) {
+		return nil, false
+	}
+	if userID == "" {
+		userID = Anonymous
+	}
+	return NewContext(ctx, userID), true
+}
+
+func userForKey(key string) (string, bool) {
+	for _, pair := range strings.Split(os.Getenv("API_KEYS"), ",") {
+		k, user, found := strings.Cut(strings.TrimSpace(pair), "=")
+		if found && k != "" && user != "" && equal(key, k) {
+			return user, true
+		}
+	}
+	return "", false
+}
+
+func equal(a, b string) bool {
+	return b != "" && subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
@@ -0,0 +1,48 @@
+package actor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthenticate(t *testing.T) {
+	t.Setenv("API_KEY", "shared")
+	t.Setenv("API_KEYS", "alice-key=alice, bob-key=bob")
+
+	tests := []struct {
+		name     string
+		key      string
+		userID   string
+		want     string
+		verified bool
+		ok       bool
+	}{
+		{name: "shared key acts for the user given", key: "shared", userID: "carol", want: "carol", ok: true},
+		{name: "shared key without user", key: "shared", want: Anonymous, ok: true},
+		{name: "user key", key: "bob-key", want: "bob", verified: true, ok: true},
+		{name: "user key naming its user", key: "alice-key", userID: "alice", want: "alice", verified: true, ok: true},
+		{name: "user key naming another user", key: "alice-key", userID: "bob"},
+		{name: "unknown key", key: "other", userID: "alice"},
+		{name: "no key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, ok := Authenticate(context.Background(), tt.key, tt.userID)
+
+			assert.Equal(t, tt.ok, ok)
+			if ok {
+				assert.Equal(t, tt.want, FromContext(ctx))
+				assert.Equal(t, tt.verified, Verified(ctx))
+			}
+		})
+	}
+
+	t.Run("no shared key configured", func(t *testing.T) {
+		t.Setenv("API_KEY", "")
+
+		_, ok := Authenticate(context.Background(), "", "")
+		assert.False(t, ok)
+	})
+}
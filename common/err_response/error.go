@@ -68,19 +68,22 @@ func HandleError(err error) huma.StatusError {
 	}
 }
 
+// NewHumaError makes Huma report errors, including its own validation
+// errors, as CustomError with the status Huma chose and the matching code.
 func NewHumaError() {
 	huma.NewError = func(status int, message string, errs ...error) huma.StatusError {
 		details := make([]string, len(errs))
 		for i, err := range errs {
 			details[i] = err.Error()
 		}
-		code := string(constant.ERR_REQUEST_INVALID)
+		code := string(statusCode(status))
 		if message == string(constant.ERR_UNAUTHORIZED) {
+			status = http.StatusUnauthorized
 			code = string(constant.ERR_UNAUTHORIZED)
 			message = "User not authorized"
 		}
 		return &CustomError{
-			Status:  http.StatusOK,
+			Status:  status,
 			Code:    code,
 			Message: message,
 			Details: details,
@@ -88,6 +91,22 @@ func NewHumaError() {
 	}
 }
 
+func statusCode(status int) constant.ERROR_CODE {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return constant.ERR_UNAUTHORIZED
+	case status == http.StatusNotFound:
+		return constant.ERR_REQUEST_NOTFOUND
+	case status == http.StatusConflict || status == http.StatusPreconditionFailed:
+		return constant.ERR_CONFLICT
+	case status == http.StatusServiceUnavailable:
+		return constant.ERR_SERVICE_UNAVAILABLE
+	case status >= http.StatusInternalServerError:
+		return constant.ERR_INTERNAL_SERVER_ERROR
+	}
+	return constant.ERR_REQUEST_INVALID
+}
+
 func ErrBadRequest(message string, locs ...string) *CustomError {
 	details := make([]string, len(locs))
 	for i, loc := range locs {
@@ -0,0 +1,266 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Config names the stream, the consumer group and this member of it. Zero
+// values of the tuning fields fall back to defaults.
+type Config struct {
+	Stream string
+	Group  string
+	Name   string
+	// Count is how many messages are read at a time. Default 10.
+	Count int64
+	// Block is how long a read waits for new messages. Default 5s.
+	Block time.Duration
+	// MinIdle is how long a message must stay unacknowledged before another
+	// member may claim it. Default 1m.
+	MinIdle time.Duration
+	// ClaimInterval is how often Subscribe looks for stuck messages.
+	// Default 30s.
+	ClaimInterval time.Duration
+	// MaxDeliveries is how often a message is handed out before it is moved
+	// to the dead-letter stream. Default 5.
+	MaxDeliveries int64
+	// DeadLetter is the stream messages that keep failing are moved to.
+	// Default Stream + ":dead".
+	DeadLetter string
+}
+
+// Handler processes a message. Returning nil acknowledges it; on an error it
+// stays pending and is retried once it can be claimed again, until it was
+// delivered Config.MaxDeliveries times and is moved to the dead-letter
+// stream.
+type Handler func(ctx context.Context, msg Message) error
+
+// Consumer is a member of a consumer group on a stream.
+type Consumer struct {
+	client redis.UniversalClient
+	cfg    Config
+}
+
+func NewConsumer(client redis.UniversalClient, cfg Config) *Consumer {
+	if cfg.Count <= 0 {
+		cfg.Count = 10
+	}
+	if cfg.Block <= 0 {
+		cfg.Block = 5 * time.Second
+	}
+	if cfg.MinIdle <= 0 {
+		cfg.MinIdle = time.Minute
+	}
+	if cfg.ClaimInterval <= 0 {
+		cfg.ClaimInterval = 30 * time.Second
+	}
+	if cfg.MaxDeliveries <= 0 {
+		cfg.MaxDeliveries = 5
+	}
+	if cfg.DeadLetter == "" {
+		cfg.DeadLetter = cfg.Stream + ":dead"
+	}
+	return &Consumer{client: client, cfg: cfg}
+}
+
+// CreateGroup creates the consumer group, and the stream if needed, starting
+// after offset: "$" for new messages only, "0" for the whole stream. An
+// existing group is left as it is.
+func (c *Consumer) CreateGroup(ctx context.Context, offset string) error {
+	err := c.client.XGroupCreateMkStream(ctx, c.cfg.Stream, c.cfg.Group, offset).Err()
+	if err != nil && strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return nil
+	}
+	return err
+}
+
+// Read returns messages not yet delivered to any member of the group,
+// waiting up to Config.Block for some to arrive.
+func (c *Consumer) Read(ctx context.Context) ([]Message, error) {
+	streams, err := c.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    c.cfg.Group,
+		Consumer: c.cfg.Name,
+		Streams:  []string{c.cfg.Stream, ">"},
+		Count:    c.cfg.Count,
+		Block:    c.cfg.Block,
+	}).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var msgs []Message
+	for _, stream := range streams {
+		msgs = append(msgs, c.decodeAll(ctx, stream.Messages)...)
+	}
+	for i := range msgs {
+		msgs[i].Deliveries = 1
+	}
+	return msgs, nil
+}
+
+// Ack marks messages as processed by the group.
+func (c *Consumer) Ack(ctx context.Context, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return c.client.XAck(ctx, c.cfg.Stream, c.cfg.Group, ids...).Err()
+}
+
+// Claim takes over messages that other members, or this one before a
+// restart, left unacknowledged for at least Config.MinIdle. Messages already
+// delivered Config.MaxDeliveries times, e.g. because handling them keeps
+// crashing the consumer, are moved to the dead-letter stream instead.
+func (c *Consumer) Claim(ctx context.Context) ([]Message, error) {
+	var msgs []Message
+	start := "0-0"
+	for {
+		claimed, next, err := c.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+			Stream:   c.cfg.Stream,
+			Group:    c.cfg.Group,
+			Consumer: c.cfg.Name,
+			MinIdle:  c.cfg.MinIdle,
+			Start:    start,
+			Count:    c.cfg.Count,
+		}).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, msg := range c.decodeAll(ctx, claimed) {
+			if msg.Deliveries, err = c.deliveries(ctx, msg.ID); err != nil {
+				return nil, err
+			}
+			if msg.Deliveries > c.cfg.MaxDeliveries {
+				if err := c.DeadLetter(ctx, msg, ErrMaxDeliveries); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			msgs = append(msgs, msg)
+		}
+		if next == "0-0" || len(claimed) == 0 {
+			return msgs, nil
+		}
+		start = next
+	}
+}
+
+// deliveries returns how often the group handed out the pending message id.
+func (c *Consumer) deliveries(ctx context.Context, id string) (int64, error) {
+	pending, err := c.client.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream: c.cfg.Stream,
+		Group:  c.cfg.Group,
+		Start:  id,
+		End:    id,
+		Count:  1,
+	}).Result()
+	if err != nil || len(pending) == 0 {
+		return 0, err
+	}
+	return pending[0].RetryCount, nil
+}
+
+// DeadLetter moves a message to the dead-letter stream, recording why, and
+// acknowledges it.
+func (c *Consumer) DeadLetter(ctx context.Context, msg Message, reason error) error {
+	values := Values(msg.EventID, msg.Type, msg.AggregateID, string(msg.Payload))
+	values[FieldStreamID] = msg.ID
+	values[FieldDeliveries] = strconv.FormatInt(msg.Deliveries, 10)
+	values[FieldError] = reason.Error()
+	err := c.client.XAdd(ctx, &redis.XAddArgs{Stream: c.cfg.DeadLetter, Values: values}).Err()
+	if err != nil {
+		return err
+	}
+	return c.Ack(ctx, msg.ID)
+}
+
+// Replay returns up to count messages after offset, read directly from the
+// stream without touching the group. Pass "0" to start at the beginning and
+// the ID of the last message returned to continue.
+func (c *Consumer) Replay(ctx context.Context, offset string, count int64) ([]Message, error) {
+	start := "-"
+	if offset != "" && offset != "0" {
+		start = "(" + offset
+	}
+	entries, err := c.client.XRangeN(ctx, c.cfg.Stream, start, "+", count).Result()
+	if err != nil {
+		return nil, err
+	}
+	msgs := make([]Message, 0, len(entries))
+	for _, entry := range entries {
+		msg, err := Decode(entry)
+		if err != nil {
+			continue
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs, nil
+}
+
+// Rewind moves the group back, or forward, so that its next read starts
+// after offset. Messages already pending stay pending.
+func (c *Consumer) Rewind(ctx context.Context, offset string) error {
+	return c.client.XGroupSetID(ctx, c.cfg.Stream, c.cfg.Group, offset).Err()
+}
+
+// Subscribe hands every message of the group to handler until ctx is
+// cancelled, acknowledging those it processed. It claims stuck messages on
+// start and then every Config.ClaimInterval, so failed messages are retried
+// after Config.MinIdle. A message failing on its last delivery is moved to
+// the dead-letter stream.
+func (c *Consumer) Subscribe(ctx context.Context, handler Handler) error {
+	var lastClaim time.Time
+	for ctx.Err() == nil {
+		var msgs []Message
+		var err error
+		if time.Since(lastClaim) >= c.cfg.ClaimInterval {
+			msgs, err = c.Claim(ctx)
+			lastClaim = time.Now()
+		} else {
+			msgs, err = c.Read(ctx)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			return err
+		}
+
+		for _, msg := range msgs {
+			if err := handler(ctx, msg); err != nil {
+				if msg.Deliveries < c.cfg.MaxDeliveries {
+					continue
+				}
+				if err := c.DeadLetter(ctx, msg, err); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := c.Ack(ctx, msg.ID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// decodeAll decodes messages, acknowledging the malformed ones so they are
+// not redelivered forever.
+func (c *Consumer) decodeAll(ctx context.Context, entries []redis.XMessage) []Message {
+	msgs := make([]Message, 0, len(entries))
+	for _, entry := range entries {
+		msg, err := Decode(entry)
+		if err != nil {
+			_ = c.Ack(ctx, entry.ID)
+			continue
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs
+}
@@ -0,0 +1,343 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeStreams keeps Redis streams with one consumer group each in memory;
+// only the commands the consumer sends are implemented. Reads never block.
+type fakeStreams struct {
+	redis.UniversalClient
+	mu      sync.Mutex
+	seq     int
+	streams map[string][]redis.XMessage
+	groups  map[string]*fakeGroup
+}
+
+type fakeGroup struct {
+	last    string
+	pending map[string]*fakePending
+}
+
+type fakePending struct {
+	consumer    string
+	deliveries  int64
+	deliveredAt time.Time
+}
+
+func newFakeStreams() *fakeStreams {
+	return &fakeStreams{streams: map[string][]redis.XMessage{}, groups: map[string]*fakeGroup{}}
+}
+
+func (f *fakeStreams) XAdd(ctx context.Context, a *redis.XAddArgs) *redis.StringCmd {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.seq++
+	id := fmt.Sprintf("%d-0", f.seq)
+	values := map[string]interface{}{}
+	for field, value := range a.Values.(map[string]interface{}) {
+		values[field] = fmt.Sprint(value)
+	}
+	f.streams[a.Stream] = append(f.streams[a.Stream], redis.XMessage{ID: id, Values: values})
+	cmd := redis.NewStringCmd(ctx)
+	cmd.SetVal(id)
+	return cmd
+}
+
+func (f *fakeStreams) XGroupCreateMkStream(ctx context.Context, stream string, group string, start string) *redis.StatusCmd {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	cmd := redis.NewStatusCmd(ctx)
+	if _, ok := f.groups[stream]; ok {
+		cmd.SetErr(errors.New("BUSYGROUP Consumer Group name already exists"))
+		return cmd
+	}
+	if start == "$" {
+		start = "0-0"
+		if entries := f.streams[stream]; len(entries) > 0 {
+			start = entries[len(entries)-1].ID
+		}
+	}
+	f.groups[stream] = &fakeGroup{last: start, pending: map[string]*fakePending{}}
+	cmd.SetVal("OK")
+	return cmd
+}
+
+func (f *fakeStreams) XGroupSetID(ctx context.Context, stream string, group string, start string) *redis.StatusCmd {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.groups[stream].last = start
+	cmd := redis.NewStatusCmd(ctx)
+	cmd.SetVal("OK")
+	return cmd
+}
+
+func (f *fakeStreams) XReadGroup(ctx context.Context, a *redis.XReadGroupArgs) *redis.XStreamSliceCmd {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	stream := a.Streams[0]
+	g := f.groups[stream]
+	var msgs []redis.XMessage
+	for _, entry := range f.streams[stream] {
+		if int64(len(msgs)) < a.Count && After(entry.ID, g.last) {
+			msgs = append(msgs, entry)
+			g.pending[entry.ID] = &fakePending{consumer: a.Consumer, deliveries: 1, deliveredAt: time.Now()}
+		}
+	}
+	cmd := redis.NewXStreamSliceCmd(ctx)
+	if len(msgs) == 0 {
+		cmd.SetErr(redis.Nil)
+		return cmd
+	}
+	g.last = msgs[len(msgs)-1].ID
+	cmd.SetVal([]redis.XStream{{Stream: stream, Messages: msgs}})
+	return cmd
+}
+
+func (f *fakeStreams) XAck(ctx context.Context, stream string, group string, ids ...string) *redis.IntCmd {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var acked int64
+	for _, id := range ids {
+		if _, ok := f.groups[stream].pending[id]; ok {
+			delete(f.groups[stream].pending, id)
+			acked++
+		}
+	}
+	cmd := redis.NewIntCmd(ctx)
+	cmd.SetVal(acked)
+	return cmd
+}
+
+func (f *fakeStreams) XAutoClaim(ctx context.Context, a *redis.XAutoClaimArgs) *redis.XAutoClaimCmd {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	g := f.groups[a.Stream]
+	var msgs []redis.XMessage
+	for _, entry := range f.streams[a.Stream] {
+		p, ok := g.pending[entry.ID]
+		if !ok || After(a.Start, entry.ID) || time.Since(p.deliveredAt) < a.MinIdle || int64(len(msgs)) >= a.Count {
+			continue
+		}
+		p.consumer = a.Consumer
+		p.deliveries++
+		p.deliveredAt = time.Now()
+		msgs = append(msgs, entry)
+	}
+	cmd := redis.NewXAutoClaimCmd(ctx)
+	cmd.SetVal(msgs, "0-0")
+	return cmd
+}
+
+func (f *fakeStreams) XPendingExt(ctx context.Context, a *redis.XPendingExtArgs) *redis.XPendingExtCmd {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var pending []redis.XPendingExt
+	if p, ok := f.groups[a.Stream].pending[a.Start]; ok {
+		pending = append(pending, redis.XPendingExt{ID: a.Start, Consumer: p.consumer, RetryCount: p.deliveries})
+	}
+	cmd := redis.NewXPendingExtCmd(ctx)
+	cmd.SetVal(pending)
+	return cmd
+}
+
+func (f *fakeStreams) XRangeN(ctx context.Context, stream string, start string, stop string, count int64) *redis.XMessageSliceCmd {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var msgs []redis.XMessage
+	for _, entry := range f.streams[stream] {
+		if start != "-" && !After(entry.ID, start[1:]) {
+			continue
+		}
+		if int64(len(msgs)) < count {
+			msgs = append(msgs, entry)
+		}
+	}
+	return redis.NewXMessageSliceCmdResult(msgs, nil)
+}
+
+func (f *fakeStreams) entries(stream string) []redis.XMessage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]redis.XMessage(nil), f.streams[stream]...)
+}
+
+func (f *fakeStreams) pendingIDs(stream string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var ids []string
+	for id := range f.groups[stream].pending {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func publish(f *fakeStreams, eventID string) {
+	f.XAdd(context.Background(), &redis.XAddArgs{Stream: "events", Values: Values(eventID, TodoCreated, 7, `{"id":7}`)})
+}
+
+func eventIDs(msgs []Message) []string {
+	ids := make([]string, len(msgs))
+	for i, msg := range msgs {
+		ids[i] = msg.EventID
+	}
+	return ids
+}
+
+func newTestConsumer(t *testing.T, f *fakeStreams, cfg Config) *Consumer {
+	cfg.Stream, cfg.Group = "events", "workers"
+	if cfg.Name == "" {
+		cfg.Name = "a"
+	}
+	c := NewConsumer(f, cfg)
+	if err := c.CreateGroup(context.Background(), "0"); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestConsumerReadAndAck(t *testing.T) {
+	f := newFakeStreams()
+	publish(f, "e1")
+	f.XAdd(context.Background(), &redis.XAddArgs{Stream: "events", Values: map[string]interface{}{"type": "junk"}})
+	publish(f, "e2")
+	c := newTestConsumer(t, f, Config{})
+	ctx := context.Background()
+
+	msgs, err := c.Read(ctx)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"e1", "e2"}, eventIDs(msgs))
+	assert.Equal(t, int64(1), msgs[0].Deliveries)
+	assert.Equal(t, 7, msgs[0].AggregateID)
+	// The malformed entry is acknowledged right away.
+	assert.Equal(t, []string{"1-0", "3-0"}, f.pendingIDs("events"))
+
+	assert.NoError(t, c.Ack(ctx, msgs[0].ID))
+	assert.Equal(t, []string{"3-0"}, f.pendingIDs("events"))
+
+	msgs, err = c.Read(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, msgs)
+	assert.NoError(t, c.CreateGroup(ctx, "0"), "an existing group is kept")
+}
+
+func TestConsumerClaim(t *testing.T) {
+	f := newFakeStreams()
+	publish(f, "e1")
+	publish(f, "e2")
+	ctx := context.Background()
+	crashed := newTestConsumer(t, f, Config{Name: "crashed"})
+	_, _ = crashed.Read(ctx)
+	c := newTestConsumer(t, f, Config{Name: "b", MinIdle: time.Nanosecond, MaxDeliveries: 2})
+
+	msgs, err := c.Claim(ctx)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"e1", "e2"}, eventIDs(msgs))
+	assert.Equal(t, int64(2), msgs[0].Deliveries)
+	assert.Empty(t, f.entries("events:dead"))
+
+	// Claimed once more, the messages exceed MaxDeliveries and are moved
+	// to the dead-letter stream instead of being returned.
+	msgs, err = c.Claim(ctx)
+
+	assert.NoError(t, err)
+	assert.Empty(t, msgs)
+	assert.Empty(t, f.pendingIDs("events"))
+	dead := f.entries("events:dead")
+	assert.Len(t, dead, 2)
+	assert.Equal(t, "e1", dead[0].Values[FieldEventID])
+	assert.Equal(t, "1-0", dead[0].Values[FieldStreamID])
+	assert.Equal(t, "3", dead[0].Values[FieldDeliveries])
+	assert.Equal(t, ErrMaxDeliveries.Error(), dead[0].Values[FieldError])
+	assert.Equal(t, `{"id":7}`, dead[0].Values[FieldPayload])
+}
+
+func TestConsumerSubscribe(t *testing.T) {
+	t.Run("acknowledges handled messages", func(t *testing.T) {
+		f := newFakeStreams()
+		publish(f, "e1")
+		publish(f, "e2")
+		c := newTestConsumer(t, f, Config{})
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var handled []string
+		err := c.Subscribe(ctx, func(ctx context.Context, msg Message) error {
+			handled = append(handled, msg.EventID)
+			if len(handled) == 2 {
+				cancel()
+			}
+			return nil
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"e1", "e2"}, handled)
+		assert.Empty(t, f.pendingIDs("events"))
+	})
+
+	t.Run("retries failed messages and then dead-letters them", func(t *testing.T) {
+		f := newFakeStreams()
+		publish(f, "e1")
+		c := newTestConsumer(t, f, Config{MinIdle: time.Nanosecond, ClaimInterval: time.Millisecond, MaxDeliveries: 3})
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var mu sync.Mutex
+		var deliveries []int64
+		done := make(chan error)
+		go func() {
+			done <- c.Subscribe(ctx, func(ctx context.Context, msg Message) error {
+				mu.Lock()
+				defer mu.Unlock()
+				deliveries = append(deliveries, msg.Deliveries)
+				return errors.New("handler failed")
+			})
+		}()
+
+		assert.Eventually(t, func() bool { return len(f.entries("events:dead")) == 1 }, time.Second, time.Millisecond)
+		cancel()
+		assert.NoError(t, <-done)
+
+		mu.Lock()
+		assert.Equal(t, []int64{1, 2, 3}, deliveries)
+		mu.Unlock()
+		assert.Empty(t, f.pendingIDs("events"))
+		dead := f.entries("events:dead")[0]
+		assert.Equal(t, "handler failed", dead.Values[FieldError])
+		assert.Equal(t, "3", dead.Values[FieldDeliveries])
+	})
+}
+
+func TestConsumerReplayAndRewind(t *testing.T) {
+	f := newFakeStreams()
+	publish(f, "e1")
+	publish(f, "e2")
+	publish(f, "e3")
+	c := newTestConsumer(t, f, Config{})
+	ctx := context.Background()
+
+	msgs, err := c.Replay(ctx, "0", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"e1", "e2"}, eventIDs(msgs))
+
+	msgs, err = c.Replay(ctx, msgs[1].ID, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"e3"}, eventIDs(msgs))
+
+	assert.NoError(t, c.Rewind(ctx, "2-0"))
+	msgs, err = c.Read(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"e3"}, eventIDs(msgs))
+}
@@ -0,0 +1,107 @@
+// Package eventbus reads the todo domain events the service publishes to a
+// Redis stream. Consumers join a consumer group so every event is handled by
+// one member of the group, acknowledge what they processed, take over
+// messages a crashed member left pending and can replay the stream from any
+// offset. Messages that keep failing end up in a dead-letter stream.
+//
+// Delivery is at-least-once: the same event can arrive more than once, always
+// with the same EventID, which handlers should use to drop duplicates.
+package eventbus
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+
+	"todo_project/model"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Event types published for todo changes.
+const (
+	TodoCreated   = model.EventTodoCreated
+	TodoUpdated   = model.EventTodoUpdated
+	TodoCompleted = model.EventTodoCompleted
+	TodoDeleted   = model.EventTodoDeleted
+)
+
+// Fields of a stream entry.
+const (
+	FieldEventID     = "event_id"
+	FieldType        = "type"
+	FieldAggregateID = "aggregate_id"
+	FieldPayload     = "payload"
+)
+
+// Extra fields of a dead-letter entry.
+const (
+	FieldStreamID   = "stream_id"
+	FieldDeliveries = "deliveries"
+	FieldError      = "error"
+)
+
+var (
+	ErrMalformedMessage = errors.New("eventbus: malformed message")
+	ErrMaxDeliveries    = errors.New("eventbus: delivered too often")
+)
+
+// Message is one event read from the stream. ID is its stream offset.
+// Deliveries is how often the consumer group handed the message out,
+// including this time; it is zero for messages read outside a group.
+type Message struct {
+	ID          string          `json:"id"`
+	EventID     string          `json:"event_id"`
+	Type        string          `json:"type"`
+	AggregateID int             `json:"aggregate_id"`
+	Payload     json.RawMessage `json:"payload"`
+	Deliveries  int64           `json:"-"`
+}
+
+// Values encodes an event as stream entry fields.
+func Values(eventID string, eventType string, aggregateID int, payload string) map[string]interface{} {
+	return map[string]interface{}{
+		FieldEventID:     eventID,
+		FieldType:        eventType,
+		FieldAggregateID: strconv.Itoa(aggregateID),
+		FieldPayload:     payload,
+	}
+}
+
+// Decode reads a stream entry written with Values.
+func Decode(msg redis.XMessage) (Message, error) {
+	field := func(name string) string {
+		value, _ := msg.Values[name].(string)
+		return value
+	}
+	aggregateID, err := strconv.Atoi(field(FieldAggregateID))
+	if err != nil || field(FieldEventID) == "" || field(FieldType) == "" {
+		return Message{ID: msg.ID}, ErrMalformedMessage
+	}
+	return Message{
+		ID:          msg.ID,
+		EventID:     field(FieldEventID),
+		Type:        field(FieldType),
+		AggregateID: aggregateID,
+		Payload:     json.RawMessage(field(FieldPayload)),
+	}, nil
+}
+
+// After reports whether stream offset a comes after b. Offsets are stream
+// entry IDs of the form "<milliseconds>-<sequence>".
+func After(a string, b string) bool {
+	aMs, aSeq := splitStreamID(a)
+	bMs, bSeq := splitStreamID(b)
+	if aMs != bMs {
+		return aMs > bMs
+	}
+	return aSeq > bSeq
+}
+
+func splitStreamID(id string) (uint64, uint64) {
+	ms, seq, _ := strings.Cut(id, "-")
+	msValue, _ := strconv.ParseUint(ms, 10, 64)
+	seqValue, _ := strconv.ParseUint(seq, 10, 64)
+	return msValue, seqValue
+}
@@ -0,0 +1,70 @@
+// Package filter implements the small query language accepted by the
+// `filter` parameter of todo listings, e.g.
+//
+//	status:doing AND (name:deploy* OR created>-7d) AND NOT version=1
+//
+// Expressions are parsed into an AST, checked against a Schema of allowed
+// fields and compiled to a parameterised SQL condition.
+package filter
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidFilter is wrapped by every parse and validation error.
+var ErrInvalidFilter = errors.New("invalid filter")
+
+// Operator compares a field with a value.
+type Operator string
+
+const (
+	// OpMatch is written ':'. It tests equality, and on text fields a value
+	// containing '*' is matched as a case-insensitive wildcard pattern.
+	OpMatch          Operator = ":"
+	OpEqual          Operator = "="
+	OpNotEqual       Operator = "!="
+	OpLess           Operator = "<"
+	OpLessOrEqual    Operator = "<="
+	OpGreater        Operator = ">"
+	OpGreaterOrEqual Operator = ">="
+)
+
+// Expr is a node of a parsed filter.
+type Expr interface {
+	String() string
+}
+
+type And struct {
+	Left, Right Expr
+}
+
+type Or struct {
+	Left, Right Expr
+}
+
+type Not struct {
+	Expr Expr
+}
+
+// Comparison is a single `field op value` term.
+type Comparison struct {
+	Field string
+	Op    Operator
+	Value string
+}
+
+func (e And) String() string { return "(" + e.Left.String() + " AND " + e.Right.String() + ")" }
+
+func (e Or) String() string { return "(" + e.Left.String() + " OR " + e.Right.String() + ")" }
+
+func (e Not) String() string { return "NOT " + e.Expr.String() }
+
+func (e Comparison) String() string {
+	value := e.Value
+	if value == "" || strings.ContainsAny(value, " \t()\"") {
+		value = strconv.Quote(value)
+	}
+	return e.Field + string(e.Op) + value
+}
@@ -0,0 +1,223 @@
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kind is the type of a filterable field; it decides which operators and
+// values the field accepts.
+type Kind int
+
+const (
+	KindString Kind = iota
+	KindInt
+	KindTime
+	KindEnum
+)
+
+// Field maps a filter field to a database column.
+type Field struct {
+	Column string
+	Kind   Kind
+	// Values lists the accepted values of a KindEnum field. For an Ordered
+	// enum they are listed from lowest to highest, which enables <, <=, > and
+	// >= comparisons.
+	Values  []string
+	Ordered bool
+}
+
+// Schema is the allow-list of fields a filter may reference.
+type Schema map[string]Field
+
+// Clause is a compiled filter, ready to be passed to gorm's Where.
+type Clause struct {
+	SQL  string
+	Args []interface{}
+}
+
+// Validate checks expr against schema without compiling it.
+func Validate(expr Expr, schema Schema) error {
+	_, err := Compile(expr, schema, time.Now())
+	return err
+}
+
+// Compile turns expr into a parameterised SQL condition. Column names come
+// from schema only, never from the expression. Relative time values are
+// resolved against now.
+func Compile(expr Expr, schema Schema, now time.Time) (Clause, error) {
+	c := &compiler{schema: schema, now: now}
+	sql, err := c.compile(expr)
+	if err != nil {
+		return Clause{}, err
+	}
+	return Clause{SQL: sql, Args: c.args}, nil
+}
+
+type compiler struct {
+	schema Schema
+	now    time.Time
+	args   []interface{}
+}
+
+func (c *compiler) compile(expr Expr) (string, error) {
+	switch e := expr.(type) {
+	case And:
+		return c.binary(e.Left, "AND", e.Right)
+	case Or:
+		return c.binary(e.Left, "OR", e.Right)
+	case Not:
+		inner, err := c.compile(e.Expr)
+		if err != nil {
+			return "", err
+		}
+		return "NOT (" + inner + ")", nil
+	case Comparison:
+		return c.comparison(e)
+	default:
+		return "", fmt.Errorf("%w: unsupported expression %T", ErrInvalidFilter, expr)
+	}
+}
+
+func (c *compiler) binary(left Expr, op string, right Expr) (string, error) {
+	l, err := c.compile(left)
+	if err != nil {
+		return "", err
+	}
+	r, err := c.compile(right)
+	if err != nil {
+		return "", err
+	}
+	return "(" + l + " " + op + " " + r + ")", nil
+}
+
+func (c *compiler) comparison(e Comparison) (string, error) {
+	field, ok := c.schema[e.Field]
+	if !ok {
+		return "", fmt.Errorf("%w: unknown field %q", ErrInvalidFilter, e.Field)
+	}
+	op := e.Op
+	if op == OpMatch && field.Kind != KindString {
+		op = OpEqual
+	}
+
+	switch field.Kind {
+	case KindString:
+		switch op {
+		case OpMatch:
+			if strings.Contains(e.Value, "*") {
+				c.args = append(c.args, wildcardPattern(e.Value))
+				return "LOWER(" + field.Column + `) LIKE ? ESCAPE '\'`, nil
+			}
+			op = OpEqual
+		case OpEqual, OpNotEqual:
+		default:
+			return "", invalidOperator(e)
+		}
+		c.args = append(c.args, e.Value)
+	case KindInt:
+		n, err := strconv.ParseInt(e.Value, 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("%w: %q expects a whole number, got %q", ErrInvalidFilter, e.Field, e.Value)
+		}
+		c.args = append(c.args, n)
+	case KindTime:
+		t, err := parseTime(e.Value, c.now)
+		if err != nil {
+			return "", fmt.Errorf("%w: %q expects a date, a timestamp or an offset like -7d, got %q", ErrInvalidFilter, e.Field, e.Value)
+		}
+		c.args = append(c.args, t)
+	case KindEnum:
+		return c.enum(e, op, field)
+	}
+	return field.Column + " " + sqlOperator(op) + " ?", nil
+}
+
+func (c *compiler) enum(e Comparison, op Operator, field Field) (string, error) {
+	index := -1
+	for i, value := range field.Values {
+		if value == e.Value {
+			index = i
+		}
+	}
+	if index < 0 {
+		return "", fmt.Errorf("%w: %q must be one of %s, got %q", ErrInvalidFilter, e.Field, strings.Join(field.Values, ", "), e.Value)
+	}
+
+	switch op {
+	case OpEqual, OpNotEqual:
+		c.args = append(c.args, e.Value)
+		return field.Column + " " + sqlOperator(op) + " ?", nil
+	}
+	if !field.Ordered {
+		return "", invalidOperator(e)
+	}
+
+	// Ordered comparisons become a set of the enum values on the wanted side.
+	var values []string
+	for i, value := range field.Values {
+		if (op == OpLess && i < index) || (op == OpLessOrEqual && i <= index) ||
+			(op == OpGreater && i > index) || (op == OpGreaterOrEqual && i >= index) {
+			values = append(values, value)
+		}
+	}
+	if len(values) == 0 {
+		return "1 = 0", nil
+	}
+	c.args = append(c.args, values)
+	return field.Column + " IN ?", nil
+}
+
+func invalidOperator(e Comparison) error {
+	return fmt.Errorf("%w: operator %s is not supported on %q", ErrInvalidFilter, e.Op, e.Field)
+}
+
+func sqlOperator(op Operator) string {
+	if op == OpNotEqual {
+		return "<>"
+	}
+	return string(op)
+}
+
+// wildcardPattern lower-cases value and turns its '*' into LIKE wildcards,
+// escaping the characters LIKE would otherwise interpret.
+func wildcardPattern(value string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(strings.ToLower(value))
+	return strings.ReplaceAll(escaped, "*", "%")
+}
+
+var offsetPattern = regexp.MustCompile(`^([+-]?)(\d+)([mhdw])$`)
+
+var offsetUnits = map[string]time.Duration{
+	"m": time.Minute,
+	"h": time.Hour,
+	"d": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour,
+}
+
+// maxOffset bounds relative times, well within what a time.Duration holds.
+const maxOffset = 100 * 365 * 24 * time.Hour
+
+// parseTime accepts RFC 3339 timestamps, YYYY-MM-DD dates (midnight UTC) and
+// offsets from now such as 7d or -12h, up to 100 years.
+func parseTime(value string, now time.Time) (time.Time, error) {
+	if m := offsetPattern.FindStringSubmatch(value); m != nil {
+		unit := offsetUnits[m[3]]
+		n, err := strconv.ParseInt(m[2], 10, 64)
+		if err != nil || n > int64(maxOffset/unit) {
+			return time.Time{}, fmt.Errorf("offset %s is too large", value)
+		}
+		offset := time.Duration(n) * unit
+		if m[1] == "-" {
+			offset = -offset
+		}
+		return now.Add(offset), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
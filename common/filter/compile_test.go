@@ -0,0 +1,100 @@
+package filter
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testSchema = Schema{
+	"id":      {Column: "id", Kind: KindInt},
+	"name":    {Column: "name", Kind: KindString},
+	"created": {Column: "created_at", Kind: KindTime},
+	"status":  {Column: "status", Kind: KindEnum, Values: []string{"todo", "doing", "done"}, Ordered: true},
+	"kind":    {Column: "kind", Kind: KindEnum, Values: []string{"bug", "task"}},
+}
+
+func TestCompile(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		input string
+		sql   string
+		args  []interface{}
+	}{
+		{name: "string match", input: "name:deploy", sql: "name = ?", args: []interface{}{"deploy"}},
+		{name: "wildcard", input: "name:De*_1%", sql: `LOWER(name) LIKE ? ESCAPE '\'`, args: []interface{}{`de%\_1\%`}},
+		{name: "string not equal", input: "name!=deploy", sql: "name <> ?", args: []interface{}{"deploy"}},
+		{name: "int", input: "id>=3", sql: "id >= ?", args: []interface{}{int64(3)}},
+		{name: "int match", input: "id:3", sql: "id = ?", args: []interface{}{int64(3)}},
+		{name: "date", input: "created<2024-01-02", sql: "created_at < ?", args: []interface{}{time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)}},
+		{name: "timestamp", input: "created>2024-01-02T03:04:05Z", sql: "created_at > ?", args: []interface{}{time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}},
+		{name: "relative time", input: "created>-7d", sql: "created_at > ?", args: []interface{}{now.Add(-7 * 24 * time.Hour)}},
+		{name: "relative time ahead", input: "created<2w", sql: "created_at < ?", args: []interface{}{now.Add(14 * 24 * time.Hour)}},
+		{name: "enum", input: "status:doing", sql: "status = ?", args: []interface{}{"doing"}},
+		{name: "ordered enum", input: "status>todo", sql: "status IN ?", args: []interface{}{[]string{"doing", "done"}}},
+		{name: "ordered enum or equal", input: "status<=doing", sql: "status IN ?", args: []interface{}{[]string{"todo", "doing"}}},
+		{name: "ordered enum out of range", input: "status>done", sql: "1 = 0"},
+		{name: "boolean", input: "NOT (id=1 OR id=2) name:a", sql: "(NOT ((id = ? OR id = ?)) AND name = ?)",
+			args: []interface{}{int64(1), int64(2), "a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := Parse(tt.input)
+			assert.NoError(t, err)
+
+			clause, err := Compile(expr, testSchema, now)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.sql, clause.SQL)
+			assert.Equal(t, tt.args, clause.Args)
+		})
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "unknown field", input: "owner:bob", want: `unknown field "owner"`},
+		{name: "string order", input: "name>abc", want: `operator > is not supported on "name"`},
+		{name: "bad int", input: "id:abc", want: "expects a whole number"},
+		{name: "bad time", input: "created<soon", want: "expects a date"},
+		{name: "offset overflow", input: "created>-99999999999999999999d", want: "expects a date"},
+		{name: "offset too large", input: "created>-40000w", want: "expects a date"},
+		{name: "unknown enum value", input: "status:blocked", want: "must be one of todo, doing, done"},
+		{name: "unordered enum", input: "kind>bug", want: `operator > is not supported on "kind"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := Parse(tt.input)
+			assert.NoError(t, err)
+
+			_, err = Compile(expr, testSchema, time.Now())
+
+			assert.True(t, errors.Is(err, ErrInvalidFilter))
+			assert.ErrorContains(t, err, tt.want)
+		})
+	}
+}
+
+func TestParseSort(t *testing.T) {
+	keys, err := ParseSort("-updated, name")
+	assert.NoError(t, err)
+	assert.Equal(t, []SortKey{{Field: "updated", Desc: true}, {Field: "name"}}, keys)
+
+	_, err = ParseSort("name,,id")
+	assert.ErrorIs(t, err, ErrInvalidFilter)
+
+	order, err := CompileSort([]SortKey{{Field: "created", Desc: true}, {Field: "id"}}, testSchema)
+	assert.NoError(t, err)
+	assert.Equal(t, "created_at DESC, id ASC", order)
+
+	_, err = CompileSort([]SortKey{{Field: "owner"}}, testSchema)
+	assert.ErrorIs(t, err, ErrInvalidFilter)
+}
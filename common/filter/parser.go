@@ -0,0 +1,223 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+const (
+	// MaxLength caps the length of a filter expression.
+	MaxLength = 1000
+	// maxTerms caps the number of comparisons in one expression.
+	maxTerms = 50
+)
+
+// Parse parses a filter expression. Terms are combined with AND, OR and NOT
+// (case-insensitive) and grouped with parentheses; AND binds tighter than OR
+// and may be left out between two terms. Values containing blanks or
+// parentheses must be double-quoted.
+func Parse(input string) (Expr, error) {
+	if len(input) > MaxLength {
+		return nil, fmt.Errorf("%w: expression is longer than %d characters", ErrInvalidFilter, MaxLength)
+	}
+	p := &parser{input: []rune(input)}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if !p.eof() {
+		return nil, p.errorf("unexpected %q", string(p.input[p.pos]))
+	}
+	if expr == nil {
+		return nil, fmt.Errorf("%w: expression is empty", ErrInvalidFilter)
+	}
+	return expr, nil
+}
+
+type parser struct {
+	input []rune
+	pos   int
+	terms int
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: at position %d: %s", ErrInvalidFilter, p.pos+1, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.input)
+}
+
+func (p *parser) skipSpace() {
+	for !p.eof() && unicode.IsSpace(p.input[p.pos]) {
+		p.pos++
+	}
+}
+
+// keyword consumes word when it is the next token.
+func (p *parser) keyword(word string) bool {
+	p.skipSpace()
+	end := p.pos + len(word)
+	if end > len(p.input) || !strings.EqualFold(string(p.input[p.pos:end]), word) {
+		return false
+	}
+	if end < len(p.input) && isFieldRune(p.input[end]) {
+		return false
+	}
+	p.pos = end
+	return true
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil || left == nil {
+		return left, err
+	}
+	for p.keyword("OR") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		if right == nil {
+			return nil, p.errorf("expected a term after OR")
+		}
+		left = Or{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	left, err := p.parseUnary()
+	if err != nil || left == nil {
+		return left, err
+	}
+	for {
+		explicit := p.keyword("AND")
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if right == nil {
+			if explicit {
+				return nil, p.errorf("expected a term after AND")
+			}
+			return left, nil
+		}
+		left = And{Left: left, Right: right}
+	}
+}
+
+// parseUnary returns a nil Expr without consuming input when the next token
+// cannot start a term.
+func (p *parser) parseUnary() (Expr, error) {
+	p.skipSpace()
+	if p.eof() || p.input[p.pos] == ')' {
+		return nil, nil
+	}
+	start := p.pos
+	if p.keyword("OR") {
+		p.pos = start
+		return nil, nil
+	}
+	if p.keyword("NOT") {
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if expr == nil {
+			return nil, p.errorf("expected a term after NOT")
+		}
+		return Not{Expr: expr}, nil
+	}
+	if p.input[p.pos] == '(' {
+		p.pos++
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if p.eof() || p.input[p.pos] != ')' {
+			return nil, p.errorf("expected )")
+		}
+		p.pos++
+		if expr == nil {
+			return nil, p.errorf("empty parentheses")
+		}
+		return expr, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (Expr, error) {
+	start := p.pos
+	for !p.eof() && isFieldRune(p.input[p.pos]) {
+		p.pos++
+	}
+	if p.pos == start {
+		return nil, p.errorf("expected a field name")
+	}
+	field := strings.ToLower(string(p.input[start:p.pos]))
+
+	op, ok := p.parseOperator()
+	if !ok {
+		return nil, p.errorf("expected an operator after %q", field)
+	}
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+
+	p.terms++
+	if p.terms > maxTerms {
+		return nil, p.errorf("more than %d terms", maxTerms)
+	}
+	return Comparison{Field: field, Op: op, Value: value}, nil
+}
+
+func (p *parser) parseOperator() (Operator, bool) {
+	// Longer operators first so "<=" is not read as "<".
+	for _, op := range []Operator{OpNotEqual, OpLessOrEqual, OpGreaterOrEqual, OpMatch, OpEqual, OpLess, OpGreater} {
+		end := p.pos + len(op)
+		if end <= len(p.input) && string(p.input[p.pos:end]) == string(op) {
+			p.pos = end
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *parser) parseValue() (string, error) {
+	if !p.eof() && p.input[p.pos] == '"' {
+		p.pos++
+		var b strings.Builder
+		for !p.eof() {
+			c := p.input[p.pos]
+			p.pos++
+			switch {
+			case c == '"':
+				return b.String(), nil
+			case c == '\\' && !p.eof():
+				b.WriteRune(p.input[p.pos])
+				p.pos++
+			default:
+				b.WriteRune(c)
+			}
+		}
+		return "", p.errorf("unterminated quoted value")
+	}
+
+	start := p.pos
+	for !p.eof() && !unicode.IsSpace(p.input[p.pos]) && p.input[p.pos] != '(' && p.input[p.pos] != ')' {
+		p.pos++
+	}
+	if p.pos == start {
+		return "", p.errorf("expected a value")
+	}
+	return string(p.input[start:p.pos]), nil
+}
+
+func isFieldRune(c rune) bool {
+	return c == '_' || c == '.' || unicode.IsLetter(c) || unicode.IsDigit(c)
+}
@@ -0,0 +1,71 @@
+package filter
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "comparison", input: "status:doing", want: "status:doing"},
+		{name: "operators", input: "a=1 b!=2 c<3 d<=4 e>5 f>=6",
+			want: "(((((a=1 AND b!=2) AND c<3) AND d<=4) AND e>5) AND f>=6)"},
+		{name: "field lower-cased", input: "Status:doing", want: "status:doing"},
+		{name: "keywords case-insensitive", input: "a:1 and b:2 or not c:3", want: "((a:1 AND b:2) OR NOT c:3)"},
+		{name: "AND binds tighter than OR", input: "a:1 OR b:2 AND c:3", want: "(a:1 OR (b:2 AND c:3))"},
+		{name: "implicit AND", input: "a:1 b:2 OR c:3", want: "((a:1 AND b:2) OR c:3)"},
+		{name: "parentheses", input: "(a:1 OR b:2) AND c:3", want: "((a:1 OR b:2) AND c:3)"},
+		{name: "NOT binds tightest", input: "NOT a:1 AND b:2", want: "(NOT a:1 AND b:2)"},
+		{name: "NOT group", input: "NOT (a:1 OR b:2)", want: "NOT (a:1 OR b:2)"},
+		{name: "quoted value", input: `name:"deploy the (backend)"`, want: `name:"deploy the (backend)"`},
+		{name: "escaped quote", input: `name:"say \"hi\""`, want: `name:"say \"hi\""`},
+		{name: "empty quoted value", input: `name:""`, want: `name:""`},
+		{name: "keyword prefix is a field", input: "order:1 android:2", want: "(order:1 AND android:2)"},
+		{name: "relative time", input: "created>-7d", want: "created>-7d"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := Parse(tt.input)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, expr.String())
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "empty", input: "  ", want: "expression is empty"},
+		{name: "dangling AND", input: "a:1 AND", want: "expected a term after AND"},
+		{name: "dangling OR", input: "a:1 OR", want: "expected a term after OR"},
+		{name: "dangling NOT", input: "NOT", want: "expected a term after NOT"},
+		{name: "unclosed parenthesis", input: "(a:1", want: "expected )"},
+		{name: "unopened parenthesis", input: "a:1)", want: `unexpected ")"`},
+		{name: "empty parentheses", input: "()", want: "empty parentheses"},
+		{name: "missing operator", input: "status", want: `expected an operator after "status"`},
+		{name: "missing field", input: ":doing", want: "expected a field name"},
+		{name: "missing value", input: "status:", want: "expected a value"},
+		{name: "unterminated quote", input: `name:"deploy`, want: "unterminated quoted value"},
+		{name: "too long", input: "name:" + strings.Repeat("a", MaxLength), want: "longer than"},
+		{name: "too many terms", input: strings.Repeat("a:1 ", maxTerms+1), want: "more than 50 terms"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.input)
+
+			assert.True(t, errors.Is(err, ErrInvalidFilter))
+			assert.ErrorContains(t, err, tt.want)
+		})
+	}
+}
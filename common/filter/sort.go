@@ -0,0 +1,47 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SortKey orders results by a schema field.
+type SortKey struct {
+	Field string
+	Desc  bool
+}
+
+// ParseSort parses a comma-separated list of fields, each optionally prefixed
+// with '-' for descending order, e.g. "-updated,name".
+func ParseSort(input string) ([]SortKey, error) {
+	if strings.TrimSpace(input) == "" {
+		return nil, nil
+	}
+	var keys []SortKey
+	for _, part := range strings.Split(input, ",") {
+		part = strings.TrimSpace(part)
+		key := SortKey{Field: strings.ToLower(strings.TrimPrefix(part, "-")), Desc: strings.HasPrefix(part, "-")}
+		if key.Field == "" {
+			return nil, fmt.Errorf("%w: empty sort field in %q", ErrInvalidFilter, input)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// CompileSort turns keys into an ORDER BY list of schema columns.
+func CompileSort(keys []SortKey, schema Schema) (string, error) {
+	columns := make([]string, 0, len(keys))
+	for _, key := range keys {
+		field, ok := schema[key.Field]
+		if !ok {
+			return "", fmt.Errorf("%w: unknown sort field %q", ErrInvalidFilter, key.Field)
+		}
+		if key.Desc {
+			columns = append(columns, field.Column+" DESC")
+		} else {
+			columns = append(columns, field.Column+" ASC")
+		}
+	}
+	return strings.Join(columns, ", "), nil
+}
@@ -0,0 +1,93 @@
+// Package rank generates lexicographic ranking keys for manually ordered
+// lists. A key can always be generated between two others, so moving an item
+// only rewrites that item's key. Keys only use 0-9 and a-z, which sort the
+// same byte-wise and under the usual database collations, and never end in
+// '0' so there is room before every key.
+package rank
+
+import (
+	"errors"
+	"strings"
+)
+
+const digits = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+const base = len(digits)
+
+var ErrInvalidRange = errors.New("rank: keys are not in order")
+
+// Between returns a key sorting strictly after a and before b. An empty a
+// means the start of the list and an empty b its end.
+func Between(a, b string) (string, error) {
+	if !valid(a) || !valid(b) || (b != "" && a >= b) {
+		return "", ErrInvalidRange
+	}
+
+	var key strings.Builder
+	upperBounded := b != ""
+	for i := 0; ; i++ {
+		lo := 0
+		if i < len(a) {
+			lo = strings.IndexByte(digits, a[i])
+		}
+		hi := base
+		if upperBounded {
+			hi = strings.IndexByte(digits, b[i])
+		}
+
+		switch {
+		case hi-lo > 1:
+			key.WriteByte(digits[(lo+hi)/2])
+			return key.String(), nil
+		case hi-lo == 1:
+			// No room at this position: keep a's digit, after which the key
+			// is below b whatever follows.
+			key.WriteByte(digits[lo])
+			upperBounded = false
+		default:
+			key.WriteByte(digits[lo])
+		}
+	}
+}
+
+// After returns a key sorting after a.
+func After(a string) (string, error) {
+	return Between(a, "")
+}
+
+// Spread returns n evenly spaced, increasing keys of the shortest length that
+// fits them. It is used to rebalance a list whose keys grew long.
+func Spread(n int) []string {
+	width, capacity := 1, base
+	for capacity <= n {
+		width++
+		capacity *= base
+	}
+	step := capacity / (n + 1)
+
+	keys := make([]string, n)
+	buf := make([]byte, width)
+	for i := range keys {
+		value := (i + 1) * step
+		for j := width - 1; j >= 0; j-- {
+			buf[j] = digits[value%base]
+			value /= base
+		}
+		// Trailing zeros can go: no other key of the same width falls between
+		// the trimmed and the full key.
+		keys[i] = strings.TrimRight(string(buf), "0")
+	}
+	return keys
+}
+
+func valid(key string) bool {
+	if strings.HasSuffix(key, "0") {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if strings.IndexByte(digits, key[i]) < 0 {
+			return false
+		}
+	}
+	return true
+}
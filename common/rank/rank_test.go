@@ -0,0 +1,102 @@
+package rank
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBetween(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{name: "empty list", want: "i"},
+		{name: "start of list", b: "i", want: "9"},
+		{name: "end of list", a: "i", want: "r"},
+		{name: "midpoint", a: "a", b: "c", want: "b"},
+		{name: "adjacent digits", a: "a", b: "b", want: "ai"},
+		{name: "prefix", a: "a", b: "a1", want: "a0i"},
+		{name: "longer lower bound", a: "az", b: "b", want: "azi"},
+		{name: "before the smallest digit", b: "1", want: "0i"},
+		{name: "after the largest digit", a: "z", want: "zi"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Between(tt.a, tt.b)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Greater(t, got, tt.a)
+			if tt.b != "" {
+				assert.Less(t, got, tt.b)
+			}
+			assert.True(t, valid(got))
+		})
+	}
+}
+
+func TestBetweenErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+	}{
+		{name: "equal", a: "b", b: "b"},
+		{name: "reversed", a: "c", b: "b"},
+		{name: "trailing zero", a: "a0"},
+		{name: "invalid digit", a: "A"},
+		{name: "invalid upper bound", b: "b-"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Between(tt.a, tt.b)
+
+			assert.ErrorIs(t, err, ErrInvalidRange)
+		})
+	}
+}
+
+// Repeatedly inserting at the same place must keep the keys ordered and
+// grow them slowly.
+func TestBetweenRepeated(t *testing.T) {
+	lo, hi := "a", "b"
+	for i := 0; i < 200; i++ {
+		key, err := Between(lo, hi)
+		assert.NoError(t, err)
+		assert.True(t, lo < key && key < hi, "%q < %q < %q", lo, key, hi)
+		hi = key
+	}
+	assert.LessOrEqual(t, len(hi), 200/5+2)
+
+	last := ""
+	for i := 0; i < 200; i++ {
+		key, err := After(last)
+		assert.NoError(t, err)
+		assert.Greater(t, key, last)
+		last = key
+	}
+}
+
+func TestSpread(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 35, 36, 100, 1295, 1296, 5000} {
+		keys := Spread(n)
+
+		assert.Len(t, keys, n)
+		assert.True(t, sort.StringsAreSorted(keys), "n=%d", n)
+		for i, key := range keys {
+			assert.True(t, valid(key), "n=%d key %q", n, key)
+			assert.NotEmpty(t, key)
+			if i > 0 {
+				assert.NotEqual(t, keys[i-1], key)
+			}
+		}
+		// Every gap leaves room for a new key.
+		for i := 1; i < len(keys); i++ {
+			_, err := Between(keys[i-1], keys[i])
+			assert.NoError(t, err)
+		}
+	}
+	assert.Len(t, Spread(100)[0], 2)
+}
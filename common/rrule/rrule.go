@@ -0,0 +1,245 @@
+// Package rrule implements the subset of RFC 5545 recurrence rules used for
+// repeating todos: FREQ=DAILY, WEEKLY or MONTHLY with INTERVAL, BYDAY (daily
+// and weekly rules only), and UNTIL or COUNT. Occurrences keep their wall
+// clock time in the location of the first one, across DST changes.
+package rrule
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidRule = errors.New("invalid recurrence rule")
+
+const (
+	Daily   = "DAILY"
+	Weekly  = "WEEKLY"
+	Monthly = "MONTHLY"
+)
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// maxSteps bounds the search for the next occurrence; a valid rule always
+// finds one well within it.
+const maxSteps = 1000
+
+type Rule struct {
+	Freq     string
+	Interval int
+	// ByDay restricts occurrences to these weekdays.
+	ByDay []time.Weekday
+	// Until, when set, is the last instant an occurrence may fall on.
+	Until *time.Time
+	// UntilDate reports that Until was given as a date only: occurrences may
+	// fall on any time of that day in their own location. Until then holds
+	// the date at midnight UTC.
+	UntilDate bool
+	// Count, when positive, is the number of occurrences in the series.
+	Count int
+}
+
+// Parse reads a rule such as "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH;COUNT=10".
+// A leading "RRULE:" is accepted.
+func Parse(text string) (Rule, error) {
+	rule := Rule{Interval: 1}
+	text = strings.TrimPrefix(strings.TrimSpace(text), "RRULE:")
+	if text == "" {
+		return rule, fmt.Errorf("%w: empty rule", ErrInvalidRule)
+	}
+
+	seen := map[string]bool{}
+	for _, part := range strings.Split(text, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		name = strings.ToUpper(strings.TrimSpace(name))
+		value = strings.ToUpper(strings.TrimSpace(value))
+		if !ok || value == "" {
+			return rule, fmt.Errorf("%w: malformed part %q", ErrInvalidRule, part)
+		}
+		if seen[name] {
+			return rule, fmt.Errorf("%w: %s given twice", ErrInvalidRule, name)
+		}
+		seen[name] = true
+
+		switch name {
+		case "FREQ":
+			if value != Daily && value != Weekly && value != Monthly {
+				return rule, fmt.Errorf("%w: FREQ must be DAILY, WEEKLY or MONTHLY", ErrInvalidRule)
+			}
+			rule.Freq = value
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > 1000 {
+				return rule, fmt.Errorf("%w: INTERVAL must be between 1 and 1000", ErrInvalidRule)
+			}
+			rule.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return rule, fmt.Errorf("%w: COUNT must be a positive number", ErrInvalidRule)
+			}
+			rule.Count = n
+		case "UNTIL":
+			until, dateOnly, err := parseUntil(value)
+			if err != nil {
+				return rule, fmt.Errorf("%w: UNTIL must look like 20250131 or 20250131T235959Z", ErrInvalidRule)
+			}
+			rule.Until, rule.UntilDate = &until, dateOnly
+		case "BYDAY":
+			for _, day := range strings.Split(value, ",") {
+				weekday, ok := weekdays[strings.TrimSpace(day)]
+				if !ok {
+					return rule, fmt.Errorf("%w: unsupported BYDAY value %q", ErrInvalidRule, day)
+				}
+				rule.ByDay = append(rule.ByDay, weekday)
+			}
+		case "WKST":
+			if value != "MO" {
+				return rule, fmt.Errorf("%w: only WKST=MO is supported", ErrInvalidRule)
+			}
+		default:
+			return rule, fmt.Errorf("%w: %s is not supported", ErrInvalidRule, name)
+		}
+	}
+
+	switch {
+	case rule.Freq == "":
+		return rule, fmt.Errorf("%w: FREQ is required", ErrInvalidRule)
+	case rule.Count > 0 && rule.Until != nil:
+		return rule, fmt.Errorf("%w: COUNT and UNTIL cannot be combined", ErrInvalidRule)
+	case rule.Freq == Monthly && len(rule.ByDay) > 0:
+		return rule, fmt.Errorf("%w: BYDAY is not supported on MONTHLY rules", ErrInvalidRule)
+	}
+	sort.Slice(rule.ByDay, func(i, j int) bool { return weekIndex(rule.ByDay[i]) < weekIndex(rule.ByDay[j]) })
+	return rule, nil
+}
+
+// Next returns the occurrence following prev, the index-th (1-based)
+// occurrence of the series. It reports false when the series ends first.
+func (r Rule) Next(prev time.Time, index int) (time.Time, bool) {
+	if r.Count > 0 && index >= r.Count {
+		return time.Time{}, false
+	}
+	interval := r.Interval
+	if interval < 1 {
+		interval = 1
+	}
+
+	var next time.Time
+	switch r.Freq {
+	case Daily:
+		next = addDays(prev, interval)
+		for i := 0; !r.onDay(next); i++ {
+			if i == maxSteps {
+				return time.Time{}, false
+			}
+			next = addDays(next, interval)
+		}
+	case Weekly:
+		next = r.nextWeekly(prev, interval)
+	case Monthly:
+		found := false
+		for k := 1; k <= maxSteps && !found; k++ {
+			// Months without the day of prev are skipped, as RFC 5545 does.
+			next = time.Date(prev.Year(), prev.Month()+time.Month(k*interval), prev.Day(),
+				prev.Hour(), prev.Minute(), prev.Second(), prev.Nanosecond(), prev.Location())
+			found = next.Day() == prev.Day()
+		}
+		if !found {
+			return time.Time{}, false
+		}
+	default:
+		return time.Time{}, false
+	}
+
+	if r.Until != nil && next.After(r.until(next.Location())) {
+		return time.Time{}, false
+	}
+	return next, true
+}
+
+// until returns the last instant an occurrence in loc may fall on.
+func (r Rule) until(loc *time.Location) time.Time {
+	if !r.UntilDate {
+		return *r.Until
+	}
+	year, month, day := r.Until.Date()
+	return time.Date(year, month, day+1, 0, 0, 0, 0, loc).Add(-time.Nanosecond)
+}
+
+// Upcoming returns up to n occurrences following prev, the index-th
+// occurrence of the series.
+func (r Rule) Upcoming(prev time.Time, index int, n int) []time.Time {
+	occurrences := make([]time.Time, 0, n)
+	for len(occurrences) < n {
+		next, ok := r.Next(prev, index)
+		if !ok {
+			break
+		}
+		occurrences = append(occurrences, next)
+		prev, index = next, index+1
+	}
+	return occurrences
+}
+
+func (r Rule) nextWeekly(prev time.Time, interval int) time.Time {
+	if len(r.ByDay) == 0 {
+		return addDays(prev, 7*interval)
+	}
+	// Later days in the same week come first, then the first listed day of
+	// the week interval weeks on.
+	current := weekIndex(prev.Weekday())
+	for _, day := range r.ByDay {
+		if weekIndex(day) > current {
+			return addDays(prev, weekIndex(day)-current)
+		}
+	}
+	weekStart := addDays(prev, -current)
+	return addDays(weekStart, 7*interval+weekIndex(r.ByDay[0]))
+}
+
+func (r Rule) onDay(t time.Time) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, day := range r.ByDay {
+		if t.Weekday() == day {
+			return true
+		}
+	}
+	return false
+}
+
+// addDays moves t by n calendar days, keeping its wall clock time.
+func addDays(t time.Time, n int) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day()+n, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+}
+
+// weekIndex numbers weekdays from Monday, the RFC 5545 default week start.
+func weekIndex(day time.Weekday) int {
+	return (int(day) + 6) % 7
+}
+
+// parseUntil reads a UTC timestamp or a date, reporting which it was. A
+// date-only UNTIL includes that whole day wherever the occurrences fall.
+func parseUntil(value string) (time.Time, bool, error) {
+	if t, err := time.Parse("20060102T150405Z", value); err == nil {
+		return t, false, nil
+	}
+	t, err := time.Parse("20060102", value)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return t, true, nil
+}
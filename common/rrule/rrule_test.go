@@ -0,0 +1,164 @@
+package rrule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func date(year int, month time.Month, day, hour, min int, loc *time.Location) time.Time {
+	return time.Date(year, month, day, hour, min, 0, 0, loc)
+}
+
+func TestParse(t *testing.T) {
+	until := time.Date(2025, 1, 31, 23, 59, 59, 0, time.UTC)
+	untilDate := time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		text string
+		want Rule
+	}{
+		{name: "daily", text: "FREQ=DAILY", want: Rule{Freq: Daily, Interval: 1}},
+		{name: "prefix and case", text: "RRULE:freq=weekly;interval=2", want: Rule{Freq: Weekly, Interval: 2}},
+		{name: "byday sorted from monday", text: "FREQ=WEEKLY;BYDAY=SU,TH,MO",
+			want: Rule{Freq: Weekly, Interval: 1, ByDay: []time.Weekday{time.Monday, time.Thursday, time.Sunday}}},
+		{name: "count", text: "FREQ=MONTHLY;COUNT=3", want: Rule{Freq: Monthly, Interval: 1, Count: 3}},
+		{name: "until timestamp", text: "FREQ=DAILY;UNTIL=20250131T235959Z", want: Rule{Freq: Daily, Interval: 1, Until: &until}},
+		{name: "until date", text: "FREQ=DAILY;UNTIL=20250131", want: Rule{Freq: Daily, Interval: 1, Until: &untilDate, UntilDate: true}},
+		{name: "week start", text: "FREQ=WEEKLY;WKST=MO", want: Rule{Freq: Weekly, Interval: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.text)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, rule)
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "empty", text: " ", want: "empty rule"},
+		{name: "malformed", text: "FREQ", want: "malformed part"},
+		{name: "no freq", text: "INTERVAL=2", want: "FREQ is required"},
+		{name: "yearly", text: "FREQ=YEARLY", want: "FREQ must be"},
+		{name: "zero interval", text: "FREQ=DAILY;INTERVAL=0", want: "INTERVAL must be"},
+		{name: "huge interval", text: "FREQ=DAILY;INTERVAL=1001", want: "INTERVAL must be"},
+		{name: "negative count", text: "FREQ=DAILY;COUNT=-1", want: "COUNT must be"},
+		{name: "bad until", text: "FREQ=DAILY;UNTIL=2025-01-31", want: "UNTIL must look like"},
+		{name: "count and until", text: "FREQ=DAILY;COUNT=2;UNTIL=20250131", want: "cannot be combined"},
+		{name: "unknown day", text: "FREQ=WEEKLY;BYDAY=XX", want: "unsupported BYDAY"},
+		{name: "monthly byday", text: "FREQ=MONTHLY;BYDAY=MO", want: "not supported on MONTHLY"},
+		{name: "repeated part", text: "FREQ=DAILY;FREQ=WEEKLY", want: "given twice"},
+		{name: "unsupported part", text: "FREQ=DAILY;BYHOUR=9", want: "BYHOUR is not supported"},
+		{name: "other week start", text: "FREQ=WEEKLY;WKST=SU", want: "only WKST=MO"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.text)
+
+			assert.ErrorIs(t, err, ErrInvalidRule)
+			assert.ErrorContains(t, err, tt.want)
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		rule  string
+		prev  time.Time
+		index int
+		want  time.Time
+		ok    bool
+	}{
+		{name: "daily", rule: "FREQ=DAILY", prev: date(2024, 1, 1, 9, 0, time.UTC), index: 1,
+			want: date(2024, 1, 2, 9, 0, time.UTC), ok: true},
+		{name: "daily interval", rule: "FREQ=DAILY;INTERVAL=3", prev: date(2024, 1, 30, 9, 0, time.UTC), index: 1,
+			want: date(2024, 2, 2, 9, 0, time.UTC), ok: true},
+		{name: "daily on weekdays", rule: "FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR", prev: date(2024, 1, 5, 9, 0, time.UTC), index: 1,
+			want: date(2024, 1, 8, 9, 0, time.UTC), ok: true},
+		{name: "keeps wall clock across DST", rule: "FREQ=DAILY", prev: date(2024, 3, 30, 9, 0, paris), index: 1,
+			want: date(2024, 3, 31, 9, 0, paris), ok: true},
+		{name: "weekly", rule: "FREQ=WEEKLY", prev: date(2024, 1, 3, 9, 0, time.UTC), index: 1,
+			want: date(2024, 1, 10, 9, 0, time.UTC), ok: true},
+		{name: "monthly", rule: "FREQ=MONTHLY", prev: date(2024, 1, 15, 9, 0, time.UTC), index: 1,
+			want: date(2024, 2, 15, 9, 0, time.UTC), ok: true},
+		{name: "monthly skips short months", rule: "FREQ=MONTHLY", prev: date(2024, 1, 31, 9, 0, time.UTC), index: 1,
+			want: date(2024, 3, 31, 9, 0, time.UTC), ok: true},
+		{name: "count reached", rule: "FREQ=DAILY;COUNT=2", prev: date(2024, 1, 2, 9, 0, time.UTC), index: 2},
+		{name: "count not reached", rule: "FREQ=DAILY;COUNT=2", prev: date(2024, 1, 1, 9, 0, time.UTC), index: 1,
+			want: date(2024, 1, 2, 9, 0, time.UTC), ok: true},
+		{name: "until passed", rule: "FREQ=DAILY;UNTIL=20240101T120000Z", prev: date(2024, 1, 1, 9, 0, time.UTC), index: 1},
+		{name: "until date includes the day", rule: "FREQ=DAILY;UNTIL=20240102", prev: date(2024, 1, 1, 23, 0, time.UTC), index: 1,
+			want: date(2024, 1, 2, 23, 0, time.UTC), ok: true},
+		// 23:30 in Paris is already the next day in UTC; a date-only UNTIL
+		// still includes it.
+		{name: "until date in the todo's location", rule: "FREQ=DAILY;UNTIL=20240102", prev: date(2024, 1, 1, 23, 30, paris), index: 1,
+			want: date(2024, 1, 2, 23, 30, paris), ok: true},
+		{name: "until date ends in the todo's location", rule: "FREQ=DAILY;UNTIL=20240102", prev: date(2024, 1, 2, 0, 30, paris), index: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.rule)
+			assert.NoError(t, err)
+
+			got, ok := rule.Next(tt.prev, tt.index)
+
+			assert.Equal(t, tt.ok, ok)
+			if tt.ok {
+				assert.True(t, tt.want.Equal(got), "want %s, got %s", tt.want, got)
+				assert.Equal(t, tt.want.Location(), got.Location())
+			}
+		})
+	}
+}
+
+func TestNextWeekly(t *testing.T) {
+	// 2024-01-01 is a Monday.
+	tests := []struct {
+		name string
+		rule string
+		prev time.Time
+		want time.Time
+	}{
+		{name: "later day in the week", rule: "FREQ=WEEKLY;BYDAY=MO,TH", prev: date(2024, 1, 1, 9, 0, time.UTC), want: date(2024, 1, 4, 9, 0, time.UTC)},
+		{name: "first day of next week", rule: "FREQ=WEEKLY;BYDAY=MO,TH", prev: date(2024, 1, 4, 9, 0, time.UTC), want: date(2024, 1, 8, 9, 0, time.UTC)},
+		{name: "interval", rule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH", prev: date(2024, 1, 4, 9, 0, time.UTC), want: date(2024, 1, 15, 9, 0, time.UTC)},
+		{name: "sunday ends the week", rule: "FREQ=WEEKLY;BYDAY=SA,SU", prev: date(2024, 1, 7, 9, 0, time.UTC), want: date(2024, 1, 13, 9, 0, time.UTC)},
+		{name: "off-rule start", rule: "FREQ=WEEKLY;BYDAY=MO", prev: date(2024, 1, 3, 9, 0, time.UTC), want: date(2024, 1, 8, 9, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.rule)
+			assert.NoError(t, err)
+
+			assert.Equal(t, tt.want, rule.nextWeekly(tt.prev, rule.Interval))
+		})
+	}
+}
+
+func TestUpcoming(t *testing.T) {
+	rule, err := Parse("FREQ=WEEKLY;BYDAY=MO,WE;COUNT=4")
+	assert.NoError(t, err)
+
+	got := rule.Upcoming(date(2024, 1, 1, 9, 0, time.UTC), 1, 10)
+
+	assert.Equal(t, []time.Time{
+		date(2024, 1, 3, 9, 0, time.UTC),
+		date(2024, 1, 8, 9, 0, time.UTC),
+		date(2024, 1, 10, 9, 0, time.UTC),
+	}, got)
+	assert.Len(t, rule.Upcoming(date(2024, 1, 1, 9, 0, time.UTC), 1, 2), 2)
+	assert.Empty(t, rule.Upcoming(date(2024, 1, 1, 9, 0, time.UTC), 4, 2))
+}
@@ -8,8 +8,12 @@ var (
     ERR_UNAUTHORIZED             ERROR_CODE = "ERR_UNAUTHORIZED"
     ERR_INTERNAL_SERVER_ERROR    ERROR_CODE = "ERR_INTERNAL_SERVER_ERROR"
     ERR_DB_CONNECTION_ERROR      ERROR_CODE = "ERR_DB_CONNECTION_ERROR"
+    ERR_CONFLICT                 ERROR_CODE = "ERR_CONFLICT"
+    ERR_SERVICE_UNAVAILABLE      ERROR_CODE = "ERR_SERVICE_UNAVAILABLE"
 )
 
 var MAP_ERROR_CODE = map[ERROR_CODE]string{
 	ERR_UNAUTHORIZED:  "unauthorized",
+	ERR_CONFLICT:  "conflict",
+	ERR_SERVICE_UNAVAILABLE:  "service unavailable",
 }
\ No newline at end of file
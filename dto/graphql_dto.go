@@ -0,0 +1,9 @@
+package dto
+
+// GraphQLRequest is a GraphQL operation sent as JSON, or as the query,
+// operationName and variables query parameters of a GET.
+type GraphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
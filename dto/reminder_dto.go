@@ -0,0 +1,29 @@
+package dto
+
+import "time"
+
+// ReminderRequest schedules a reminder either at RemindAt or Offset minutes
+// from the todo's due date (negative for before).
+type ReminderRequest struct {
+	RemindAt *time.Time `json:"remind_at"`
+	Offset   *int       `json:"offset"`
+	Channel  string     `json:"channel" validate:"required"`
+	Target   string     `json:"target"`
+}
+
+type ReminderResponse struct {
+	ID            uint       `json:"id"`
+	TodoID        int        `json:"todo_id"`
+	Owner         string     `json:"owner"`
+	RemindAt      *time.Time `json:"remind_at,omitempty"`
+	Offset        *int       `json:"offset,omitempty"`
+	FireAt        time.Time  `json:"fire_at"`
+	Channel       string     `json:"channel"`
+	Target        string     `json:"target,omitempty"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	LastError     string     `json:"last_error,omitempty"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
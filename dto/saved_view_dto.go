@@ -0,0 +1,22 @@
+package dto
+
+import "time"
+
+type SavedViewRequest struct {
+	Name   string `json:"name" validate:"required"`
+	Filter string `json:"filter"`
+	Sort   string `json:"sort"`
+	Shared bool   `json:"shared"`
+}
+
+type SavedViewResponse struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	Owner     string    `json:"owner"`
+	Filter    string    `json:"filter"`
+	Sort      string    `json:"sort"`
+	Shared    bool      `json:"shared"`
+	ReadOnly  bool      `json:"read_only"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
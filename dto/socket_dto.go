@@ -0,0 +1,42 @@
+package dto
+
+import (
+	"time"
+
+	"todo_project/common/eventbus"
+)
+
+// SocketRequest is a message sent by a WebSocket client. Type is one of
+// subscribe, unsubscribe, presence or edit; Ref is echoed in the reply.
+type SocketRequest struct {
+	Type    string                 `json:"type"`
+	Ref     string                 `json:"ref,omitempty"`
+	Topic   string                 `json:"topic,omitempty"`
+	TodoID  int                    `json:"todo_id,omitempty"`
+	State   string                 `json:"state,omitempty"`
+	Version int                    `json:"version,omitempty"`
+	Changes map[string]interface{} `json:"changes,omitempty"`
+}
+
+// SocketResponse is a message sent to a WebSocket client: a reply to one of
+// its requests, a todo event or a presence change.
+type SocketResponse struct {
+	Type     string            `json:"type"`
+	Ref      string            `json:"ref,omitempty"`
+	Topic    string            `json:"topic,omitempty"`
+	Status   int               `json:"status,omitempty"`
+	Error    string            `json:"error,omitempty"`
+	Todo     *TodoResponse     `json:"todo,omitempty"`
+	Event    *eventbus.Message `json:"event,omitempty"`
+	Presence *PresenceResponse `json:"presence,omitempty"`
+}
+
+// PresenceResponse tells that User is viewing or editing a todo, or has left
+// it.
+type PresenceResponse struct {
+	TodoID    int       `json:"todo_id"`
+	ProjectID *int      `json:"project_id,omitempty"`
+	User      string    `json:"user"`
+	State     string    `json:"state"`
+	At        time.Time `json:"at"`
+}
@@ -0,0 +1,58 @@
+package dto
+
+import "time"
+
+// SyncChangeResponse is the latest state of a todo changed since the sync
+// token. Op is upsert or delete; a delete without deleted_at means the todo
+// was purged.
+type SyncChangeResponse struct {
+	Op        string        `json:"op"`
+	ID        int           `json:"id"`
+	Version   int           `json:"version,omitempty"`
+	Todo      *TodoResponse `json:"todo,omitempty"`
+	DeletedAt *time.Time    `json:"deleted_at,omitempty"`
+}
+
+// SyncResponse is one page of changes. Token is sent back as since to read
+// the next page while has_more is true, and the next round of changes after.
+type SyncResponse struct {
+	Token   string               `json:"token"`
+	HasMore bool                 `json:"has_more"`
+	Changes []SyncChangeResponse `json:"changes"`
+}
+
+// SyncMutationRequest is a change made offline. Op is create, update or
+// delete. BaseVersion is the version the client last saw; leave it out to
+// overwrite whatever is on the server.
+type SyncMutationRequest struct {
+	ClientID    string                 `json:"client_id,omitempty"`
+	Op          string                 `json:"op"`
+	ID          int                    `json:"id,omitempty"`
+	BaseVersion int                    `json:"base_version,omitempty"`
+	Todo        *CreateTodoRequest     `json:"todo,omitempty"`
+	Changes     map[string]interface{} `json:"changes,omitempty"`
+}
+
+type SyncRequest struct {
+	Mutations []SyncMutationRequest `json:"mutations"`
+}
+
+// SyncResult reports how a mutation was resolved: applied, merged (the
+// fields in conflicts were dropped), conflict (nothing was applied) or error.
+// Todo is the server state after the mutation.
+type SyncResult struct {
+	Index     int           `json:"index"`
+	ClientID  string        `json:"client_id,omitempty"`
+	Op        string        `json:"op"`
+	ID        int           `json:"id,omitempty"`
+	Status    string        `json:"status"`
+	Version   int           `json:"version,omitempty"`
+	Todo      *TodoResponse `json:"todo,omitempty"`
+	DeletedAt *time.Time    `json:"deleted_at,omitempty"`
+	Conflicts []string      `json:"conflicts,omitempty"`
+	Error     string        `json:"error,omitempty"`
+}
+
+type SyncPushResponse struct {
+	Results []SyncResult `json:"results"`
+}
@@ -1,8 +1,15 @@
 package dto
 
+import "time"
+
 type CreateTodoRequest struct {
 	Name string `json:"name" validate:"required"`
 	Description string `json:"description" validate:"required"`
+	ProjectID *int `json:"project_id,omitempty"`
+	ParentID *int `json:"parent_id,omitempty"`
+	DueAt *time.Time `json:"due_at,omitempty"`
+	Recurrence string `json:"recurrence,omitempty"`
+	Timezone string `json:"timezone,omitempty"`
 }
 
 type UpdateTodoRequest struct {
@@ -11,8 +18,163 @@ type UpdateTodoRequest struct {
 	Description string `json:"description" validate:"required"`
 }
 
+// ReplaceTodoRequest is the body of PUT /api/v3/todo/{id}.
+type ReplaceTodoRequest struct {
+	Name        string `json:"name" minLength:"1" doc:"Name of the todo"`
+	Description string `json:"description" doc:"Description of the todo"`
+}
+
+// PatchTodoRequest is the body of PATCH /api/v3/todo/{id}. Only the fields
+// present are changed; an empty due_at or recurrence clears it.
+type PatchTodoRequest struct {
+	Name        *string `json:"name,omitempty" minLength:"1"`
+	Description *string `json:"description,omitempty"`
+	Status      *string `json:"status,omitempty" enum:"todo,doing,done"`
+	DueAt       *string `json:"due_at,omitempty" doc:"RFC 3339 time"`
+	Recurrence  *string `json:"recurrence,omitempty" doc:"RRULE"`
+	Timezone    *string `json:"timezone,omitempty" doc:"IANA time zone"`
+}
+
+// PatchTodoDocument is the JSON document a PATCH request is applied to.
+// Only the fields listed here can be changed through PATCH.
+type PatchTodoDocument struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Status      string `json:"status"`
+	DueAt       string `json:"due_at"`
+	Recurrence  string `json:"recurrence"`
+	Timezone    string `json:"timezone"`
+}
+
 type TodoResponse struct {
 	ID int `json:"id"`
 	Name string `json:"name"`
 	Description string `json:"description"`
+	Status string `json:"status"`
+	ProjectID *int `json:"project_id,omitempty"`
+	ParentID *int `json:"parent_id,omitempty"`
+	Position string `json:"position,omitempty"`
+	DueAt *time.Time `json:"due_at,omitempty"`
+	Recurrence string `json:"recurrence,omitempty"`
+	Timezone string `json:"timezone,omitempty"`
+	Occurrence int `json:"occurrence,omitempty"`
+	Version int `json:"version,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// MoveTodoRequest places a todo right before or right after another one,
+// taking over that todo's project and parent. Exactly one of Before and
+// After must be set.
+type MoveTodoRequest struct {
+	Before *int `json:"before,omitempty"`
+	After *int `json:"after,omitempty"`
+	Version int `json:"version,omitempty"`
+}
+
+type TrashedTodoResponse struct {
+	TodoResponse
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+type FieldChangeResponse struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+type TodoHistoryResponse struct {
+	ID        int                            `json:"id"`
+	TodoID    int                            `json:"todo_id"`
+	Version   int                            `json:"version"`
+	Action    string                         `json:"action"`
+	Actor     string                         `json:"actor"`
+	Changes   map[string]FieldChangeResponse `json:"changes"`
+	CreatedAt time.Time                      `json:"created_at"`
+}
+
+// RevertTodoRequest names the version of a todo, as listed in its history,
+// whose name, description and status should be restored.
+type RevertTodoRequest struct {
+	Version int `json:"version" validate:"required"`
+}
+
+type TodoSearchItem struct {
+	TodoResponse
+	Rank       float64           `json:"rank"`
+	Highlights map[string]string `json:"highlights"`
+}
+
+type TodoSearchResponse struct {
+	Items       []TodoSearchItem `json:"items"`
+	Total       int64            `json:"total"`
+	Page        int              `json:"page"`
+	PageSize    int              `json:"page_size"`
+	Suggestions []string         `json:"suggestions,omitempty"`
+}
+
+type TodoAutocompleteResponse struct {
+	Prefix      string   `json:"prefix"`
+	Completions []string `json:"completions"`
+}
+
+const (
+	BulkModeAtomic     = "atomic"
+	BulkModeBestEffort = "best_effort"
+)
+
+type BulkTodoRequest struct {
+	Mode       string              `json:"mode"`
+	Operations []BulkTodoOperation `json:"operations"`
+}
+
+// BulkTodoOperation is one entry of a bulk request. Op is one of create,
+// update, delete or set_status; Version is optional and, when set, must match
+// the stored version for update and set_status.
+type BulkTodoOperation struct {
+	Op          string  `json:"op"`
+	ID          int     `json:"id,omitempty"`
+	Version     int     `json:"version,omitempty"`
+	Name        *string `json:"name,omitempty"`
+	Description *string `json:"description,omitempty"`
+	Status      *string `json:"status,omitempty"`
+}
+
+type BulkTodoResult struct {
+	Index  int           `json:"index"`
+	Op     string        `json:"op"`
+	ID     int           `json:"id,omitempty"`
+	Status int           `json:"status"`
+	Error  string        `json:"error,omitempty"`
+	Todo   *TodoResponse `json:"todo,omitempty"`
+}
+
+type BulkTodoResponse struct {
+	Mode      string           `json:"mode"`
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+	Results   []BulkTodoResult `json:"results"`
+}
+
+type BoardColumnResponse struct {
+	Status   string         `json:"status"`
+	Count    int            `json:"count"`
+	WIPLimit int            `json:"wip_limit,omitempty"`
+	Cards    []TodoResponse `json:"cards"`
+}
+
+type BoardResponse struct {
+	ProjectID int                   `json:"project_id"`
+	Columns   []BoardColumnResponse `json:"columns"`
+}
+
+type OccurrenceResponse struct {
+	Occurrence int       `json:"occurrence"`
+	DueAt      time.Time `json:"due_at"`
+}
+
+type OccurrencePreviewResponse struct {
+	TodoID      int                  `json:"todo_id"`
+	Recurrence  string               `json:"recurrence"`
+	Timezone    string               `json:"timezone"`
+	Occurrences []OccurrenceResponse `json:"occurrences"`
 }
@@ -0,0 +1,42 @@
+package dto
+
+import (
+	"strconv"
+	"time"
+
+	"todo_project/model"
+)
+
+// NewTodoResponse is the representation of a todo returned by every API.
+func NewTodoResponse(todo *model.Todo) TodoResponse {
+	return TodoResponse{
+		ID:          todo.ID,
+		Name:        todo.Name,
+		Description: todo.Description,
+		Status:      todo.Status,
+		ProjectID:   todo.ProjectID,
+		ParentID:    todo.ParentID,
+		Position:    todo.Position,
+		DueAt:       todo.DueAt,
+		Recurrence:  todo.Recurrence,
+		Timezone:    todo.Timezone,
+		Occurrence:  todo.Occurrence,
+		Version:     todo.Version,
+		CreatedAt:   optionalTime(todo.CreatedAt),
+		UpdatedAt:   optionalTime(todo.UpdatedAt),
+	}
+}
+
+// TodoETag builds the entity tag of a todo, quoted as sent in an ETag header,
+// from its version, which changes on every successful write.
+func TodoETag(todo *model.Todo) string {
+	return `"` + strconv.Itoa(todo.ID) + "-" + strconv.Itoa(todo.Version) + `"`
+}
+
+// optionalTime leaves out timestamps that were not loaded.
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,102 @@
+// Package netguard keeps requests to user supplied URLs, such as webhook
+// and reminder targets, away from the service's own network.
+package netguard
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenTarget is returned for targets that resolve to loopback,
+// private or otherwise non-public addresses.
+var ErrForbiddenTarget = errors.New("target address is not public")
+
+// reserved lists the ranges not covered by the net.IP predicates used in
+// Public that must not be reachable either.
+var reserved = mustParseCIDRs(
+	"0.0.0.0/8",
+	"100.64.0.0/10",
+	"192.0.0.0/24",
+	"198.18.0.0/15",
+	"240.0.0.0/4",
+	"64:ff9b::/96",
+)
+
+// Public reports whether ip is a globally routable unicast address.
+func Public(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, network := range reserved {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckURL checks that raw is an http or https URL whose host resolves to
+// public addresses only. It is meant for validating targets when they are
+// saved; clients from NewClient check again on every connection, as DNS
+// answers may change in between.
+func CheckURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return errors.New("target must be an http or https URL")
+	}
+	host := u.Hostname()
+	ips := []net.IP{net.ParseIP(host)}
+	if ips[0] == nil {
+		if ips, err = net.LookupIP(host); err != nil {
+			return fmt.Errorf("cannot resolve %s: %w", host, err)
+		}
+	}
+	for _, ip := range ips {
+		if !Public(ip) {
+			return fmt.Errorf("%w: %s", ErrForbiddenTarget, host)
+		}
+	}
+	return nil
+}
+
+// NewClient returns an HTTP client that refuses to connect to non-public
+// addresses, whichever URL or redirect led there. It ignores proxy settings,
+// since a proxy would make the connection on the client's behalf.
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout, Control: control}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// control runs after the address was resolved and before connecting.
+func control(_ string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !Public(ip) {
+		return fmt.Errorf("%w: %s", ErrForbiddenTarget, host)
+	}
+	return nil
+}
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks[i] = network
+	}
+	return networks
+}
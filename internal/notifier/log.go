@@ -0,0 +1,24 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+
+	"todo_project/common/log"
+)
+
+// LogNotifier writes notifications to the service log. It takes no target.
+type LogNotifier struct{}
+
+func (LogNotifier) Validate(target string) error {
+	if target != "" {
+		return errors.New("the log channel takes no target")
+	}
+	return nil
+}
+
+func (LogNotifier) Notify(_ context.Context, notification Notification) error {
+	log.Infof("Reminder %d: todo %d %q is due at %v",
+		notification.ReminderID, notification.TodoID, notification.TodoName, notification.DueAt)
+	return nil
+}
@@ -0,0 +1,33 @@
+package notifier
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	ChannelLog     = "log"
+	ChannelWebhook = "webhook"
+	ChannelSMTP    = "smtp"
+)
+
+// Notification is what a notifier delivers when a reminder fires.
+type Notification struct {
+	ReminderID uint       `json:"reminder_id"`
+	TodoID     int        `json:"todo_id"`
+	TodoName   string     `json:"todo_name"`
+	DueAt      *time.Time `json:"due_at"`
+	FireAt     time.Time  `json:"fire_at"`
+	Target     string     `json:"-"`
+}
+
+// Notifier delivers notifications over one channel. Validate checks a
+// target before a reminder is saved; Notify returns an error when delivery
+// should be retried.
+type Notifier interface {
+	Validate(target string) error
+	Notify(ctx context.Context, notification Notification) error
+}
+
+// Registry maps channel names to their notifiers.
+type Registry map[string]Notifier
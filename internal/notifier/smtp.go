@@ -0,0 +1,65 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SMTPNotifier mails notifications to the target address. Auth is only used
+// when a username is configured.
+type SMTPNotifier struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+func (n *SMTPNotifier) Validate(target string) error {
+	if _, err := mail.ParseAddress(target); err != nil {
+		return errors.New("smtp target must be an email address")
+	}
+	return nil
+}
+
+func (n *SMTPNotifier) Notify(_ context.Context, notification Notification) error {
+	to, err := mail.ParseAddress(notification.Target)
+	if err != nil {
+		return err
+	}
+	var auth smtp.Auth
+	if n.Username != "" {
+		auth = smtp.PlainAuth("", n.Username, n.Password, n.Host)
+	}
+	addr := net.JoinHostPort(n.Host, strconv.Itoa(n.Port))
+	return smtp.SendMail(addr, auth, n.From, []string{to.Address}, n.message(to, notification))
+}
+
+func (n *SMTPNotifier) message(to *mail.Address, notification Notification) []byte {
+	subject := fmt.Sprintf("Reminder: %s", notification.TodoName)
+	body := fmt.Sprintf("Todo %d %q", notification.TodoID, notification.TodoName)
+	if notification.DueAt != nil {
+		body += " is due at " + notification.DueAt.Format(time.RFC1123Z)
+	}
+
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", n.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", to)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", headerSafe(subject))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	msg.WriteString(body + ".\r\n")
+	return []byte(msg.String())
+}
+
+// headerSafe keeps line breaks in todo names out of the mail headers.
+func headerSafe(header string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(header)
+}
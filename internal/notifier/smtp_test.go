@@ -0,0 +1,100 @@
+package notifier
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// smtpServer accepts one SMTP session and records the recipients and the
+// message, with line endings as the dot reader returns them.
+type smtpServer struct {
+	listener net.Listener
+	rcpt     []string
+	data     string
+	done     chan struct{}
+}
+
+func newSMTPServer(t *testing.T) *smtpServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &smtpServer{listener: listener, done: make(chan struct{})}
+	go s.serve()
+	t.Cleanup(func() { listener.Close() })
+	return s
+}
+
+func (s *smtpServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *smtpServer) serve() {
+	defer close(s.done)
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	text := textproto.NewConn(conn)
+	_ = text.PrintfLine("220 localhost ready")
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch verb {
+		case "EHLO", "HELO":
+			_ = text.PrintfLine("250 localhost")
+		case "RCPT":
+			s.rcpt = append(s.rcpt, strings.TrimPrefix(line, "RCPT TO:"))
+			_ = text.PrintfLine("250 ok")
+		case "DATA":
+			_ = text.PrintfLine("354 go ahead")
+			data, _ := io.ReadAll(text.DotReader())
+			s.data = string(data)
+			_ = text.PrintfLine("250 ok")
+		case "QUIT":
+			_ = text.PrintfLine("221 bye")
+			return
+		default:
+			_ = text.PrintfLine("250 ok")
+		}
+	}
+}
+
+func TestSMTPNotifierValidate(t *testing.T) {
+	n := &SMTPNotifier{}
+
+	assert.NoError(t, n.Validate("bob@example.com"))
+	assert.NoError(t, n.Validate("Bob <bob@example.com>"))
+	assert.Error(t, n.Validate("bob"))
+}
+
+func TestSMTPNotifierNotify(t *testing.T) {
+	server := newSMTPServer(t)
+	n := &SMTPNotifier{Host: "127.0.0.1", Port: server.port(), From: "todo@example.com"}
+
+	err := n.Notify(context.Background(), Notification{
+		ReminderID: 7,
+		TodoID:     3,
+		TodoName:   "Pay\r\nBcc: eve@example.com",
+		Target:     "Bob <bob@example.com>",
+	})
+	<-server.done
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"<bob@example.com>"}, server.rcpt)
+	assert.Contains(t, server.data, "To: \"Bob\" <bob@example.com>\n")
+	assert.Contains(t, server.data, "Subject: Reminder: Pay  Bcc: eve@example.com\n")
+	assert.NotContains(t, server.data, "\nBcc:")
+	assert.Contains(t, server.data, "Todo 3 "+strconv.Quote("Pay\r\nBcc: eve@example.com"))
+}
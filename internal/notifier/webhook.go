@@ -0,0 +1,52 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"todo_project/internal/netguard"
+)
+
+// WebhookNotifier POSTs notifications as JSON to the target URL. Any status
+// outside 2xx counts as a failed delivery. Targets on loopback, private or
+// otherwise non-public addresses are refused.
+type WebhookNotifier struct {
+	Client *http.Client
+}
+
+func NewWebhookNotifier(timeout time.Duration) *WebhookNotifier {
+	return &WebhookNotifier{Client: netguard.NewClient(timeout)}
+}
+
+func (n *WebhookNotifier) Validate(target string) error {
+	if err := netguard.CheckURL(target); err != nil {
+		return fmt.Errorf("webhook target: %w", err)
+	}
+	return nil
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, notification Notification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, notification.Target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with %s", resp.Status)
+	}
+	return nil
+}
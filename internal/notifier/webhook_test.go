@@ -0,0 +1,72 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"todo_project/internal/netguard"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookNotifierValidate(t *testing.T) {
+	n := NewWebhookNotifier(time.Second)
+
+	assert.NoError(t, n.Validate("https://93.184.216.34/hook"))
+	assert.ErrorIs(t, n.Validate("http://127.0.0.1:8080/hook"), netguard.ErrForbiddenTarget)
+	assert.ErrorIs(t, n.Validate("http://169.254.169.254/latest/meta-data"), netguard.ErrForbiddenTarget)
+	assert.Error(t, n.Validate("mailto:someone@example.com"))
+}
+
+func TestWebhookNotifierNotify(t *testing.T) {
+	due := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	notification := Notification{ReminderID: 7, TodoID: 3, TodoName: "Pay rent", DueAt: &due, FireAt: due.Add(-time.Hour)}
+
+	t.Run("posts the notification", func(t *testing.T) {
+		var got Notification
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodPost, r.Method)
+			assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		}))
+		defer server.Close()
+		n := &WebhookNotifier{Client: server.Client()}
+		notification.Target = server.URL
+
+		err := n.Notify(context.Background(), notification)
+
+		assert.NoError(t, err)
+		assert.Equal(t, uint(7), got.ReminderID)
+		assert.Equal(t, "Pay rent", got.TodoName)
+		assert.True(t, due.Equal(*got.DueAt))
+	})
+
+	t.Run("non 2xx fails", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+		n := &WebhookNotifier{Client: server.Client()}
+		notification.Target = server.URL
+
+		assert.Error(t, n.Notify(context.Background(), notification))
+	})
+
+	t.Run("refuses loopback at connect time", func(t *testing.T) {
+		called := false
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			called = true
+		}))
+		defer server.Close()
+		notification.Target = server.URL
+
+		err := NewWebhookNotifier(time.Second).Notify(context.Background(), notification)
+
+		assert.ErrorIs(t, err, netguard.ErrForbiddenTarget)
+		assert.False(t, called)
+	})
+}
@@ -2,6 +2,7 @@ package redis
 
 import (
 	"context"
+	"strconv"
 	"todo_project/common/log"
 	"time"
 
@@ -16,8 +17,16 @@ type IRedis interface {
 	Ping() error
 	GetClient() *redis.Client
 	Set(key string, value interface{}) (string, error)
+	SetEx(key string, value interface{}, expiration time.Duration) (string, error)
+	SetNX(key string, value interface{}, expiration time.Duration) (bool, error)
 	Get(key string) (string, error)
 	Delete(key string) (int64, error)
+	ZAdd(key string, score float64, member string) error
+	ZRangeByScore(key string, max float64, limit int64) ([]string, error)
+	ZRem(key string, member string) (int64, error)
+	XAdd(stream string, maxLen int64, values map[string]interface{}) (string, error)
+	XRange(stream string, start string, end string, count int64) ([]redis.XMessage, error)
+	Publish(channel string, message string) error
 }
 
 var Redis IRedis
@@ -83,6 +92,16 @@ func (r *RedisClient) Set(key string, value interface{}) (string, error) {
 	return ret, err
 }
 
+func (r *RedisClient) SetEx(key string, value interface{}, expiration time.Duration) (string, error) {
+	ret, err := r.Client.Set(ctx, key, value, expiration).Result()
+	return ret, err
+}
+
+func (r *RedisClient) SetNX(key string, value interface{}, expiration time.Duration) (bool, error) {
+	ret, err := r.Client.SetNX(ctx, key, value, expiration).Result()
+	return ret, err
+}
+
 func (r *RedisClient) Get(key string) (string, error) {
 	ret, err := r.Client.Get(ctx, key).Result()
 	return ret, err
@@ -92,3 +111,40 @@ func (r *RedisClient) Delete(key string) (int64, error) {
 	ret, err := r.Client.Del(ctx, key).Result()
 	return ret, err
 }
+
+func (r *RedisClient) ZAdd(key string, score float64, member string) error {
+	return r.Client.ZAdd(ctx, key, redis.Z{Score: score, Member: member}).Err()
+}
+
+// ZRangeByScore returns up to limit members with a score of at most max,
+// lowest score first.
+func (r *RedisClient) ZRangeByScore(key string, max float64, limit int64) ([]string, error) {
+	return r.Client.ZRangeByScore(ctx, key, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatFloat(max, 'f', -1, 64),
+		Count: limit,
+	}).Result()
+}
+
+func (r *RedisClient) ZRem(key string, member string) (int64, error) {
+	return r.Client.ZRem(ctx, key, member).Result()
+}
+
+// XAdd appends an entry to stream and returns its ID. A positive maxLen caps
+// the stream at roughly that many entries.
+func (r *RedisClient) XAdd(stream string, maxLen int64, values map[string]interface{}) (string, error) {
+	return r.Client.XAdd(ctx, &redis.XAddArgs{
+		Stream: stream,
+		MaxLen: maxLen,
+		Approx: maxLen > 0,
+		Values: values,
+	}).Result()
+}
+
+func (r *RedisClient) XRange(stream string, start string, end string, count int64) ([]redis.XMessage, error) {
+	return r.Client.XRangeN(ctx, stream, start, end, count).Result()
+}
+
+func (r *RedisClient) Publish(channel string, message string) error {
+	return r.Client.Publish(ctx, channel, message).Err()
+}
@@ -0,0 +1,94 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"todo_project/internal/netguard"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"event":"todo.created"}`)
+
+	mac := hmac.New(sha256.New, []byte("s3cr3t"))
+	mac.Write([]byte("1700000000." + string(body)))
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	assert.Equal(t, want, Sign("s3cr3t", 1700000000, body))
+	assert.NotEqual(t, want, Sign("other", 1700000000, body))
+	assert.NotEqual(t, want, Sign("s3cr3t", 1700000001, body))
+	assert.NotEqual(t, want, Sign("s3cr3t", 1700000000, []byte(`{"event":"todo.deleted"}`)))
+}
+
+func TestSend(t *testing.T) {
+	req := Request{
+		Secret:     "s3cr3t",
+		Event:      "todo.created",
+		EventID:    "12-todo.created",
+		DeliveryID: 7,
+		Body:       []byte(`{"event":"todo.created"}`),
+	}
+
+	t.Run("signs the request", func(t *testing.T) {
+		var got *http.Request
+		var body []byte
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = r
+			body, _ = io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+		req.URL = server.URL
+
+		status, err := (&Sender{Client: server.Client()}).Send(context.Background(), req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusNoContent, status)
+		assert.Equal(t, req.Body, body)
+		assert.Equal(t, "todo.created", got.Header.Get(HeaderEvent))
+		assert.Equal(t, "12-todo.created", got.Header.Get(HeaderEventID))
+		assert.Equal(t, "7", got.Header.Get(HeaderDelivery))
+		timestamp, err := strconv.ParseInt(got.Header.Get(HeaderTimestamp), 10, 64)
+		assert.NoError(t, err)
+		assert.InDelta(t, time.Now().Unix(), timestamp, 5)
+		assert.Equal(t, Sign("s3cr3t", timestamp, body), got.Header.Get(HeaderSignature))
+	})
+
+	t.Run("non 2xx fails with the status", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer server.Close()
+		req.URL = server.URL
+
+		status, err := (&Sender{Client: server.Client()}).Send(context.Background(), req)
+
+		assert.Error(t, err)
+		assert.Equal(t, http.StatusBadGateway, status)
+	})
+
+	t.Run("refuses private targets", func(t *testing.T) {
+		called := false
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			called = true
+		}))
+		defer server.Close()
+		req.URL = server.URL
+
+		status, err := NewSender(time.Second).Send(context.Background(), req)
+
+		assert.ErrorIs(t, err, netguard.ErrForbiddenTarget)
+		assert.Zero(t, status)
+		assert.False(t, called)
+	})
+}
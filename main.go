@@ -7,17 +7,27 @@ package main
 
 import (
 
+	"context"
 	"os"
 	"path/filepath"
+	"time"
 
 
 	api "todo_project/api"
+	"todo_project/api/graph"
+	"todo_project/api/rpc"
+	v2 "todo_project/api/v2"
+	"todo_project/common/err_response"
 	"todo_project/common/log"
 	"todo_project/internal"
 	"todo_project/internal/sqlclient"
 	auth "todo_project/middleware"
 	"todo_project/common/limiter"
+	"todo_project/internal/notifier"
 	"todo_project/internal/redis"
+	"todo_project/internal/webhook"
+	"todo_project/repository"
+	"todo_project/service"
 	server "todo_project/server/http"
 
 	"github.com/caarlos0/env/v10"
@@ -35,12 +45,21 @@ import (
 )
 
 type Config struct {
-	Dir     string `env:"CONFIG_DIR" envDefault:"configs/config.json"`
-	Port    string
-	LogType string
-	LogFile string
-	DB      string
-	Redis   string
+	Dir             string `env:"CONFIG_DIR" envDefault:"configs/config.json"`
+	Port            string
+	GRPCPort        string
+	LogType         string
+	LogFile         string
+	DB              string
+	Redis           string
+	IdempotencyTTL  time.Duration
+	TrashRetention  time.Duration
+	TrashPurgeEvery time.Duration
+	RebalanceEvery  time.Duration
+	ReminderEvery   time.Duration
+	WebhookEvery    time.Duration
+	OutboxEvery     time.Duration
+	OutboxRetention time.Duration
 }
 
 var config Config
@@ -60,14 +79,71 @@ func init() {
 	}
 
 	// Set config values from viper
+	viper.SetDefault("idempotency.ttl", 86400)
+	viper.SetDefault("bulk.max_operations", 500)
+	viper.SetDefault("trash.retention_days", 30)
+	viper.SetDefault("trash.purge_interval", 3600)
+	viper.SetDefault("search.language", "english")
+	viper.SetDefault("ordering.max_key_length", 12)
+	viper.SetDefault("ordering.rebalance_interval", 3600)
+	viper.SetDefault("search.fuzzy_threshold", 0.3)
+	viper.SetDefault("search.autocomplete_ttl", 60)
+	viper.SetDefault("reminders.poll_interval", 10)
+	viper.SetDefault("reminders.max_attempts", 5)
+	viper.SetDefault("reminders.retry_backoff", 30)
+	viper.SetDefault("reminders.webhook_timeout", 10)
+	viper.SetDefault("smtp.port", 587)
+	viper.SetDefault("webhooks.poll_interval", 5)
+	viper.SetDefault("webhooks.max_attempts", 8)
+	viper.SetDefault("webhooks.retry_backoff", 30)
+	viper.SetDefault("webhooks.timeout", 10)
+	viper.SetDefault("outbox.poll_interval", 1)
+	viper.SetDefault("outbox.retention_hours", 168)
+	viper.SetDefault("events.stream", "todo_events")
+	viper.SetDefault("events.stream_max_len", 100000)
+	viper.SetDefault("events.channel", "todo_events")
+	viper.SetDefault("events.heartbeat", 15)
+	viper.SetDefault("presence.channel", "todo_presence")
+	viper.SetDefault("presence.ttl", 60)
+	viper.SetDefault("sync.page_size", 500)
+	viper.SetDefault("graphql.max_depth", 8)
+	viper.SetDefault("graphql.max_complexity", 1000)
+	viper.SetDefault("negotiation.max_body_bytes", 1<<20)
 	config = Config{
-		Dir:     config.Dir,
-		Port:    viper.GetString("main.port"),
-		LogType: viper.GetString("main.log_type"),
-		LogFile: viper.GetString("main.log_file"),
-		DB:      viper.GetString("main.db"),
-		Redis:   viper.GetString("main.redis"),
+		Dir:             config.Dir,
+		Port:            viper.GetString("main.port"),
+		GRPCPort:        viper.GetString("main.grpc_port"),
+		LogType:         viper.GetString("main.log_type"),
+		LogFile:         viper.GetString("main.log_file"),
+		DB:              viper.GetString("main.db"),
+		Redis:           viper.GetString("main.redis"),
+		IdempotencyTTL:  time.Duration(viper.GetInt("idempotency.ttl")) * time.Second,
+		TrashRetention:  time.Duration(positive("trash.retention_days")) * 24 * time.Hour,
+		TrashPurgeEvery: interval("trash.purge_interval"),
+		RebalanceEvery:  interval("ordering.rebalance_interval"),
+		ReminderEvery:   interval("reminders.poll_interval"),
+		WebhookEvery:    interval("webhooks.poll_interval"),
+		OutboxEvery:     interval("outbox.poll_interval"),
+		OutboxRetention: time.Duration(viper.GetInt("outbox.retention_hours")) * time.Hour,
 	}
+	v2.MaxBulkOperations = positive("bulk.max_operations")
+	repository.SearchLanguage = viper.GetString("search.language")
+	repository.FuzzyThreshold = viper.GetFloat64("search.fuzzy_threshold")
+	service.MaxPositionLength = viper.GetInt("ordering.max_key_length")
+	for status := range viper.GetStringMap("board.wip_limits") {
+		service.WIPLimits[status] = viper.GetInt("board.wip_limits." + status)
+	}
+	v2.AutocompleteCacheTTL = time.Duration(viper.GetInt("search.autocomplete_ttl")) * time.Second
+	service.ReminderMaxAttempts = viper.GetInt("reminders.max_attempts")
+	service.ReminderRetryBackoff = time.Duration(viper.GetInt("reminders.retry_backoff")) * time.Second
+	v2.EventHeartbeat = interval("events.heartbeat")
+	service.PresenceTTL = interval("presence.ttl")
+	service.SyncPageSize = viper.GetInt("sync.page_size")
+	graph.MaxDepth = viper.GetInt("graphql.max_depth")
+	graph.MaxComplexity = viper.GetInt("graphql.max_complexity")
+	auth.MaxRequestBody = viper.GetInt64("negotiation.max_body_bytes")
+	service.WebhookMaxAttempts = viper.GetInt("webhooks.max_attempts")
+	service.WebhookRetryBackoff = time.Duration(viper.GetInt("webhooks.retry_backoff")) * time.Second
 
 	// Initialize logger
 	if config.LogType == "FILE" {
@@ -114,10 +190,49 @@ func init() {
 	}
 }
 
+// interval reads the number of seconds at key, which must be positive as
+// it paces a ticker.
+func interval(key string) time.Duration {
+	seconds := viper.GetInt(key)
+	if seconds <= 0 {
+		log.Fatalf("Invalid config %s: %d, it must be a positive number of seconds", key, seconds)
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// positive reads the number at key, which must be positive as it sizes a
+// retention or a limit.
+func positive(key string) int {
+	n := viper.GetInt(key)
+	if n <= 0 {
+		log.Fatalf("Invalid config %s: %d, it must be a positive number", key, n)
+	}
+	return n
+}
+
 func initRepo(gormSqlConfig sqlclient.GormSqlConfig) {
 	internal.GormSqlClient = sqlclient.NewGormSqlClient(gormSqlConfig)
 }
 
+// newNotifiers returns the reminder channels. SMTP is only offered when a
+// mail server is configured.
+func newNotifiers() notifier.Registry {
+	notifiers := notifier.Registry{
+		notifier.ChannelLog:     notifier.LogNotifier{},
+		notifier.ChannelWebhook: notifier.NewWebhookNotifier(time.Duration(viper.GetInt("reminders.webhook_timeout")) * time.Second),
+	}
+	if host := viper.GetString("smtp.host"); host != "" {
+		notifiers[notifier.ChannelSMTP] = &notifier.SMTPNotifier{
+			Host:     host,
+			Port:     viper.GetInt("smtp.port"),
+			Username: viper.GetString("smtp.username"),
+			Password: viper.GetString("smtp.password"),
+			From:     viper.GetString("smtp.from"),
+		}
+	}
+	return notifiers
+}
+
 func main() {
 	if config.DB != "enabled" {
 		logrus.Fatal("Database is disabled, cannot start server")
@@ -125,14 +240,62 @@ func main() {
 
 	logrus.Infof("DB name from config: %s", viper.GetString("db.database"))
 
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	todoRepo := repository.NewTodoRepository(internal.GormSqlClient.GetDB())
+	todoService := service.NewTodoService(todoRepo, redisClient)
+	viewService := service.NewSavedViewService(repository.NewSavedViewRepository(internal.GormSqlClient.GetDB()), todoService)
+	reminderService := service.NewReminderService(todoRepo, newNotifiers())
+	webhookService := service.NewWebhookService(todoRepo, webhook.NewSender(time.Duration(viper.GetInt("webhooks.timeout"))*time.Second))
+	syncService := service.NewSyncService(todoRepo, redisClient)
+
+	go service.NewTrashRetentionJob(todoService, config.TrashRetention, config.TrashPurgeEvery).Run(ctx)
+	go service.NewPositionRebalanceJob(todoService, config.RebalanceEvery).Run(ctx)
+	go service.NewReminderScheduler(reminderService, redisClient, config.ReminderEvery).Run(ctx)
+	go service.NewWebhookDispatcher(webhookService, config.WebhookEvery).Run(ctx)
+	var events service.TodoEventSource
+	var sinks []service.OutboxSink
+	if redisClient != nil {
+		stream, channel := viper.GetString("events.stream"), viper.GetString("events.channel")
+		sinks = append(sinks, service.NewRedisStreamSink(redisClient, stream, viper.GetInt64("events.stream_max_len"), channel))
+
+		hub := service.NewEventHub(redisClient, stream, channel)
+		go hub.Run(ctx)
+		events = hub
+	} else {
+		logrus.Warn("Redis is disabled, outbox events will be dropped")
+	}
+	go service.NewOutboxRelay(todoRepo.Outbox(), sinks, config.OutboxEvery, config.OutboxRetention).Run(ctx)
+	presence := service.NewPresenceTracker(redisClient, viper.GetString("presence.channel"))
+	go presence.Run(ctx)
+
 	engine := server.NewEngine()
 
 	engine.Use(auth.AuthMiddleWare())
 
 	apiV2 := engine.Group("/api/v2")
-	api.SetupRoutes(apiV2, redisClient)
+	apiV2.Use(auth.NegotiationMiddleWare(), auth.IdempotencyMiddleWare(redisClient, config.IdempotencyTTL))
+	api.SetupRoutes(apiV2, todoService, viewService, reminderService, webhookService, syncService, events, presence, redisClient)
+
+	err_response.NewHumaError()
+	apiV3 := engine.Group("/api/v3")
+	apiV3.Use(auth.IdempotencyMiddleWare(redisClient, config.IdempotencyTTL))
+	api.SetupV3Routes(engine, apiV3, todoService, redisClient)
+
+	if config.GRPCPort != "" {
+		grpcServer := rpc.NewServer(todoService, events, redisClient)
+		go func() {
+			if err := rpc.ListenAndServe(ctx, config.GRPCPort, grpcServer); err != nil {
+				logrus.Fatalf("gRPC server could not be started: %v", err)
+			}
+		}()
+	}
 
 	appServer := server.New(config.Port, engine)
+	// Stopping the background jobs also ends open event streams, which
+	// Shutdown would otherwise wait for.
+	appServer.RegisterOnShutdown(cancel)
 	if err := appServer.Run(); err != nil {
 		logrus.Fatalf("Server could not be started: %v", err)
 	} 
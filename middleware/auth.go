@@ -2,13 +2,18 @@ package middleware
 
 import (
 	"net/http"
-	"os"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"todo_project/common/actor"
 	"todo_project/common/log"
 )
 
+// UserIDHeader names the user an API key holder is acting for. It is recorded
+// as the actor of every change made by the request. It is only trusted from
+// the shared API key; keys bound to a user act for that user.
+const UserIDHeader = "X-User-ID"
+
 func init() {
 	// Load environment variables
 	if err := godotenv.Load(); err != nil {
@@ -18,15 +23,15 @@ func init() {
 
 func AuthMiddleWare() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var apiKey = os.Getenv("API_KEY")
-		authHeader := c.GetHeader("X-API-KEY")
-		if authHeader == "" || authHeader != apiKey {
+		ctx, ok := actor.Authenticate(c.Request.Context(), c.GetHeader("X-API-KEY"), c.GetHeader(UserIDHeader))
+		if !ok {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"message": "Authorization failed",
 			})
 			c.Abort()
 			return
 		}
+		c.Request = c.Request.WithContext(ctx)
 		c.Next()
 	}
 }
\ No newline at end of file
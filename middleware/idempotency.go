@@ -0,0 +1,190 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"todo_project/common/actor"
+	"todo_project/common/log"
+	"todo_project/internal/redis"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotentReplayedHeader  = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+	idempotencyLockExpiration = 30 * time.Second
+)
+
+// replayedHeaders are the response headers stored with an idempotent response
+// and sent again when it is replayed.
+var replayedHeaders = []string{"Content-Type", "ETag", "Location"}
+
+type idempotencyRecord struct {
+	Fingerprint string            `json:"fingerprint"`
+	Status      int               `json:"status"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Body        []byte            `json:"body,omitempty"`
+}
+
+type idempotencyWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *idempotencyWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *idempotencyWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// IdempotencyMiddleWare makes mutating requests carrying an Idempotency-Key
+// header safe to retry. Keys are scoped to the caller. The first response for
+// a key is stored in Redis for ttl and replayed for every retry with the same
+// method, path and body. A key reused with a different payload is rejected
+// with 422, and a retry that arrives while the first request is still running
+// gets 409.
+func IdempotencyMiddleWare(redisClient redis.IRedis, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" || redisClient == nil || !isMutatingMethod(c.Request.Method) {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key is too long"})
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		fingerprint := requestFingerprint(c.Request, body)
+		redisKey := idempotencyRedisKey(c, key)
+
+		lock, _ := json.Marshal(idempotencyRecord{Fingerprint: fingerprint})
+		acquired, err := redisClient.SetNX(redisKey, string(lock), idempotencyLockExpiration)
+		if err != nil {
+			log.Errorf("Idempotency lookup failed for key %s: %v", key, err)
+			c.Next()
+			return
+		}
+		if !acquired {
+			replayIdempotentResponse(c, redisClient, redisKey, fingerprint)
+			return
+		}
+
+		writer := &idempotencyWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+
+		// Server errors are not stored so the client can retry them.
+		status := writer.Status()
+		if status >= http.StatusInternalServerError {
+			if _, err := redisClient.Delete(redisKey); err != nil {
+				log.Errorf("Failed to release idempotency key %s: %v", key, err)
+			}
+			return
+		}
+
+		record := idempotencyRecord{
+			Fingerprint: fingerprint,
+			Status:      status,
+			Headers:     map[string]string{},
+			Body:        writer.body.Bytes(),
+		}
+		for _, header := range replayedHeaders {
+			if value := writer.Header().Get(header); value != "" {
+				record.Headers[header] = value
+			}
+		}
+		data, err := json.Marshal(record)
+		if err != nil {
+			log.Errorf("Failed to encode idempotent response for key %s: %v", key, err)
+			return
+		}
+		if _, err := redisClient.SetEx(redisKey, string(data), ttl); err != nil {
+			log.Errorf("Failed to store idempotent response for key %s: %v", key, err)
+		}
+	}
+}
+
+func replayIdempotentResponse(c *gin.Context, redisClient redis.IRedis, redisKey string, fingerprint string) {
+	str, err := redisClient.Get(redisKey)
+	if err != nil {
+		// The key expired between SETNX and GET; treat it as still in flight.
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is already in progress"})
+		return
+	}
+
+	var record idempotencyRecord
+	if err := json.Unmarshal([]byte(str), &record); err != nil {
+		log.Errorf("Corrupted idempotency record %s: %v", redisKey, err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to replay request"})
+		return
+	}
+
+	if record.Fingerprint != fingerprint {
+		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used with a different request"})
+		return
+	}
+	if record.Status == 0 {
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is already in progress"})
+		return
+	}
+
+	for header, value := range record.Headers {
+		c.Header(header, value)
+	}
+	c.Header(IdempotentReplayedHeader, "true")
+	c.Status(record.Status)
+	if len(record.Body) > 0 {
+		_, _ = c.Writer.Write(record.Body)
+	}
+	c.Abort()
+}
+
+// idempotencyRedisKey scopes key to the caller, the API key and the user it
+// acts for, so clients picking the same key never see each other's responses.
+func idempotencyRedisKey(c *gin.Context, key string) string {
+	hash := sha256.New()
+	hash.Write([]byte(c.GetHeader("X-API-KEY")))
+	hash.Write([]byte{0})
+	hash.Write([]byte(actor.FromContext(c.Request.Context())))
+	hash.Write([]byte{0})
+	hash.Write([]byte(key))
+	return "idempotency_" + hex.EncodeToString(hash.Sum(nil))
+}
+
+func requestFingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method))
+	hash.Write([]byte{0})
+	hash.Write([]byte(r.URL.RequestURI()))
+	hash.Write([]byte{0})
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func isMutatingMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
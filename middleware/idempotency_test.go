@@ -0,0 +1,153 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"todo_project/common/actor"
+	"todo_project/internal/redis"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// Fake Redis holding plain keys in memory; only the methods the middleware
+// calls are implemented.
+type fakeRedis struct {
+	redis.IRedis
+	mu   sync.Mutex
+	data map[string]string
+}
+
+func newFakeRedis() *fakeRedis {
+	return &fakeRedis{data: map[string]string{}}
+}
+
+func (f *fakeRedis) SetNX(key string, value interface{}, expiration time.Duration) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.data[key]; ok {
+		return false, nil
+	}
+	f.data[key] = value.(string)
+	return true, nil
+}
+
+func (f *fakeRedis) SetEx(key string, value interface{}, expiration time.Duration) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.data[key] = value.(string)
+	return "OK", nil
+}
+
+func (f *fakeRedis) Get(key string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	value, ok := f.data[key]
+	if !ok {
+		return "", errors.New("redis: nil")
+	}
+	return value, nil
+}
+
+func (f *fakeRedis) Delete(key string) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.data[key]; !ok {
+		return 0, nil
+	}
+	delete(f.data, key)
+	return 1, nil
+}
+
+func TestIdempotencyMiddleWare(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// The handler counts its calls and, given block, signals started and waits
+	// for block to be closed before answering.
+	newRouter := func(store *fakeRedis, calls *int, started chan struct{}, block chan struct{}) *gin.Engine {
+		r := gin.New()
+		r.Use(func(c *gin.Context) {
+			c.Request = c.Request.WithContext(actor.NewContext(c.Request.Context(), c.GetHeader(UserIDHeader)))
+		})
+		r.Use(IdempotencyMiddleWare(store, time.Hour))
+		r.POST("/todo", func(c *gin.Context) {
+			*calls++
+			if block != nil {
+				started <- struct{}{}
+				<-block
+			}
+			c.Header("ETag", `"1-1"`)
+			c.JSON(http.StatusCreated, gin.H{"id": *calls})
+		})
+		return r
+	}
+	send := func(r *gin.Engine, key, user, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPost, "/todo", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(IdempotencyKeyHeader, key)
+		req.Header.Set(UserIDHeader, user)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("replay", func(t *testing.T) {
+		calls := 0
+		r := newRouter(newFakeRedis(), &calls, nil, nil)
+
+		first := send(r, "k1", "alice", `{"name":"a"}`)
+		second := send(r, "k1", "alice", `{"name":"a"}`)
+
+		assert.Equal(t, 1, calls)
+		assert.Equal(t, http.StatusCreated, second.Code)
+		assert.Equal(t, first.Body.String(), second.Body.String())
+		assert.Equal(t, `"1-1"`, second.Header().Get("ETag"))
+		assert.Equal(t, "true", second.Header().Get(IdempotentReplayedHeader))
+		assert.Empty(t, first.Header().Get(IdempotentReplayedHeader))
+	})
+
+	t.Run("different payload", func(t *testing.T) {
+		calls := 0
+		r := newRouter(newFakeRedis(), &calls, nil, nil)
+
+		send(r, "k1", "alice", `{"name":"a"}`)
+		w := send(r, "k1", "alice", `{"name":"b"}`)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("in flight", func(t *testing.T) {
+		calls := 0
+		started, block := make(chan struct{}), make(chan struct{})
+		r := newRouter(newFakeRedis(), &calls, started, block)
+
+		done := make(chan *httptest.ResponseRecorder)
+		go func() { done <- send(r, "k1", "alice", `{"name":"a"}`) }()
+		<-started
+		w := send(r, "k1", "alice", `{"name":"a"}`)
+		close(block)
+		first := <-done
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, http.StatusCreated, first.Code)
+	})
+
+	t.Run("scoped to the caller", func(t *testing.T) {
+		calls := 0
+		r := newRouter(newFakeRedis(), &calls, nil, nil)
+
+		send(r, "k1", "alice", `{"name":"a"}`)
+		w := send(r, "k1", "bob", `{"name":"a"}`)
+
+		assert.Equal(t, 2, calls)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Empty(t, w.Header().Get(IdempotentReplayedHeader))
+	})
+}
@@ -0,0 +1,444 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"todo_project/common/log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ugorji/go/codec"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	MIMEJSON        = "application/json"
+	MIMEYAML        = "application/yaml"
+	MIMEMsgPack     = "application/msgpack"
+	MIMECSV         = "text/csv"
+	mimeEventStream = "text/event-stream"
+)
+
+// mediaAliases maps the other names in use for the same formats.
+var mediaAliases = map[string]string{
+	"application/x-yaml":      MIMEYAML,
+	"text/yaml":               MIMEYAML,
+	"application/x-msgpack":   MIMEMsgPack,
+	"application/vnd.msgpack": MIMEMsgPack,
+}
+
+var (
+	// responseFormats are the formats a response can be rendered in. Event
+	// streams are written as they are by the endpoints producing them.
+	responseFormats = map[string]bool{MIMEJSON: true, MIMEYAML: true, MIMEMsgPack: true, MIMECSV: true, mimeEventStream: true}
+	// requestFormats are the formats a request body can be sent in.
+	requestFormats = map[string]bool{MIMEJSON: true, MIMEYAML: true, MIMEMsgPack: true}
+)
+
+// formatSuffixes tell apart the entity tags of the renderings of a resource,
+// so that a tag is never shared by two different bodies.
+var formatSuffixes = map[string]string{MIMEYAML: "-yaml", MIMEMsgPack: "-msgpack", MIMECSV: "-csv"}
+
+// MaxRequestBody caps the size of a YAML or MessagePack request body, which is
+// read whole to be decoded.
+var MaxRequestBody int64 = 1 << 20
+
+var msgpackHandle = func() *codec.MsgpackHandle {
+	handle := &codec.MsgpackHandle{}
+	handle.MapType = reflect.TypeOf(map[string]interface{}(nil))
+	handle.RawToString = true
+	handle.WriteExt = true
+	return handle
+}()
+
+type negotiationWriter struct {
+	gin.ResponseWriter
+	started   bool
+	buffering bool
+	body      bytes.Buffer
+}
+
+// start decides, on the first write, whether the response is JSON to be
+// converted or anything else to be passed through untouched.
+func (w *negotiationWriter) start() {
+	if w.started {
+		return
+	}
+	w.started = true
+	w.buffering = mediaType(w.Header().Get("Content-Type")) == MIMEJSON
+}
+
+func (w *negotiationWriter) Write(data []byte) (int, error) {
+	w.start()
+	if w.buffering {
+		return w.body.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *negotiationWriter) WriteString(s string) (int, error) {
+	w.start()
+	if w.buffering {
+		return w.body.WriteString(s)
+	}
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *negotiationWriter) Flush() {
+	if !w.buffering {
+		w.ResponseWriter.Flush()
+	}
+}
+
+// NegotiationMiddleWare lets clients choose the format of requests and
+// responses. JSON responses are rendered as YAML, MessagePack or, when they
+// are lists, CSV following the Accept header, and YAML or MessagePack request
+// bodies are decoded to JSON before reaching the handlers. A request whose
+// Accept header allows none of these formats gets 406, one whose body is in
+// another format 415. Converted responses carry their own ETag, the JSON one
+// with the format appended, and every response varies by Accept.
+func NegotiationMiddleWare() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// WebSocket handshakes carry no body and take over the connection.
+		if c.GetHeader("Upgrade") != "" {
+			c.Next()
+			return
+		}
+		if !decodeRequestBody(c) {
+			return
+		}
+
+		format, ok := negotiate(c.GetHeader("Accept"))
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusNotAcceptable, gin.H{"error": "Accept must allow one of " + MIMEJSON + ", " + MIMEYAML + ", " + MIMEMsgPack + " or " + MIMECSV})
+			return
+		}
+		c.Writer.Header().Add("Vary", "Accept")
+		// If-Match guards the version whatever the format it was read in.
+		if ifMatch := c.GetHeader("If-Match"); ifMatch != "" {
+			c.Request.Header.Set("If-Match", mapTags(ifMatch, func(tag string) (string, bool) {
+				for _, suffix := range formatSuffixes {
+					if trimmed, ok := trimTagSuffix(tag, suffix); ok {
+						return trimmed, true
+					}
+				}
+				return tag, true
+			}))
+		}
+		if format == MIMEJSON || format == mimeEventStream {
+			c.Next()
+			return
+		}
+
+		// If-None-Match only matches tags of the format asked for, which the
+		// handlers compare in their JSON form.
+		suffix := formatSuffixes[format]
+		if ifNoneMatch := c.GetHeader("If-None-Match"); ifNoneMatch != "" {
+			ifNoneMatch = mapTags(ifNoneMatch, func(tag string) (string, bool) {
+				if tag == "*" {
+					return tag, true
+				}
+				return trimTagSuffix(tag, suffix)
+			})
+			if ifNoneMatch == "" {
+				c.Request.Header.Del("If-None-Match")
+			} else {
+				c.Request.Header.Set("If-None-Match", ifNoneMatch)
+			}
+		}
+
+		writer := &negotiationWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+		if etag := c.Writer.Header().Get("ETag"); etag != "" && !c.Writer.Written() {
+			c.Writer.Header().Set("ETag", addTagSuffix(etag, suffix))
+		}
+		if writer.buffering {
+			renderResponse(c, format, writer.body.Bytes())
+		}
+	}
+}
+
+// mapTags rewrites each entity tag listed in an If-Match or If-None-Match
+// header, dropping those for which fn returns false.
+func mapTags(header string, fn func(tag string) (string, bool)) string {
+	var tags []string
+	for _, tag := range strings.Split(header, ",") {
+		if mapped, ok := fn(strings.TrimSpace(tag)); ok {
+			tags = append(tags, mapped)
+		}
+	}
+	return strings.Join(tags, ", ")
+}
+
+// addTagSuffix appends suffix inside the quotes of a strong or weak tag.
+func addTagSuffix(tag, suffix string) string {
+	if !strings.HasSuffix(tag, `"`) {
+		return tag
+	}
+	return strings.TrimSuffix(tag, `"`) + suffix + `"`
+}
+
+// trimTagSuffix removes suffix from inside the quotes of a tag and reports
+// whether it was there.
+func trimTagSuffix(tag, suffix string) (string, bool) {
+	if !strings.HasSuffix(tag, suffix+`"`) {
+		return tag, false
+	}
+	return strings.TrimSuffix(tag, suffix+`"`) + `"`, true
+}
+
+// decodeRequestBody replaces a YAML or MessagePack body by its JSON
+// equivalent. It writes a 415, 413 or 400 response and returns false when the
+// body cannot be read.
+func decodeRequestBody(c *gin.Context) bool {
+	if c.Request.Body == nil || c.Request.ContentLength == 0 {
+		return true
+	}
+	contentType := mediaType(c.GetHeader("Content-Type"))
+	if contentType == "" || contentType == MIMEJSON {
+		return true
+	}
+	if !requestFormats[contentType] {
+		c.AbortWithStatusJSON(http.StatusUnsupportedMediaType, gin.H{"error": "Content-Type must be " + MIMEJSON + ", " + MIMEYAML + " or " + MIMEMsgPack})
+		return false
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, MaxRequestBody))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request body is larger than " + strconv.FormatInt(MaxRequestBody, 10) + " bytes"})
+		return false
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return false
+	}
+	var value interface{}
+	if contentType == MIMEYAML {
+		err = yaml.Unmarshal(body, &value)
+	} else {
+		err = codec.NewDecoderBytes(body, msgpackHandle).Decode(&value)
+	}
+	if err == nil {
+		body, err = json.Marshal(value)
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return false
+	}
+
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	c.Request.ContentLength = int64(len(body))
+	c.Request.Header.Set("Content-Type", MIMEJSON)
+	return true
+}
+
+// negotiate picks the response format preferred by an Accept header. Any
+// other JSON flavour and wildcards are served JSON.
+func negotiate(accept string) (string, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return MIMEJSON, true
+	}
+
+	type mediaRange struct {
+		media string
+		q     float64
+	}
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		media, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+		if q > 0 {
+			ranges = append(ranges, mediaRange{media: media, q: q})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+
+	for _, r := range ranges {
+		switch media := mediaType(r.media); {
+		case responseFormats[media]:
+			return media, true
+		case media == "*/*", media == "application/*":
+			return MIMEJSON, true
+		}
+	}
+	return "", false
+}
+
+// mediaType is the lowercase media type of a Content-Type or Accept value
+// without parameters, with aliases and JSON flavours such as
+// application/merge-patch+json resolved.
+func mediaType(value string) string {
+	media, _, err := mime.ParseMediaType(value)
+	if err != nil {
+		return ""
+	}
+	if alias, ok := mediaAliases[media]; ok {
+		return alias
+	}
+	if strings.HasPrefix(media, "application/") && strings.HasSuffix(media, "+json") {
+		return MIMEJSON
+	}
+	return media
+}
+
+// renderResponse converts the JSON response body to format. Error responses
+// are not lists and are sent as JSON when CSV was asked for; other responses
+// that cannot be rendered as CSV get 406.
+func renderResponse(c *gin.Context, format string, body []byte) {
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		log.Errorf("Failed to decode response of %s for %s: %v", c.Request.URL.Path, format, err)
+		writeBody(c, MIMEJSON, body)
+		return
+	}
+	value = normalizeNumbers(value)
+
+	var out bytes.Buffer
+	var err error
+	switch format {
+	case MIMEYAML:
+		err = yaml.NewEncoder(&out).Encode(value)
+	case MIMEMsgPack:
+		err = codec.NewEncoder(&out, msgpackHandle).Encode(value)
+	case MIMECSV:
+		rows, ok := value.([]interface{})
+		if value == nil {
+			// Empty lists are sometimes encoded as null.
+			ok = true
+		}
+		if !ok {
+			if c.Writer.Status() >= http.StatusBadRequest {
+				writeBody(c, MIMEJSON, body)
+				return
+			}
+			c.Writer.WriteHeader(http.StatusNotAcceptable)
+			writeBody(c, MIMEJSON, []byte(`{"error":"Only lists can be sent as `+MIMECSV+`"}`))
+			return
+		}
+		err = writeCSV(&out, rows)
+	}
+	if err != nil {
+		log.Errorf("Failed to encode response of %s as %s: %v", c.Request.URL.Path, format, err)
+		writeBody(c, MIMEJSON, body)
+		return
+	}
+	if format == MIMECSV {
+		format += "; charset=utf-8"
+	}
+	writeBody(c, format, out.Bytes())
+}
+
+func writeBody(c *gin.Context, contentType string, body []byte) {
+	c.Writer.Header().Set("Content-Type", contentType)
+	c.Writer.Header().Del("Content-Length")
+	if _, err := c.Writer.Write(body); err != nil {
+		log.Errorf("Failed to write response of %s: %v", c.Request.URL.Path, err)
+	}
+}
+
+// writeCSV writes one row per object of rows under a header holding every
+// key found, id first and the others sorted. Nested values are written as
+// JSON.
+func writeCSV(out io.Writer, rows []interface{}) error {
+	seen := map[string]bool{}
+	var columns []string
+	for _, row := range rows {
+		object, _ := row.(map[string]interface{})
+		for key := range object {
+			if !seen[key] {
+				seen[key] = true
+				columns = append(columns, key)
+			}
+		}
+	}
+	sort.Slice(columns, func(i, j int) bool {
+		if columns[i] == "id" || columns[j] == "id" {
+			return columns[i] == "id"
+		}
+		return columns[i] < columns[j]
+	})
+
+	w := csv.NewWriter(out)
+	if err := w.Write(columns); err != nil {
+		return err
+	}
+	record := make([]string, len(columns))
+	for _, row := range rows {
+		object, _ := row.(map[string]interface{})
+		for i, column := range columns {
+			record[i] = csvCell(object[column])
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// csvCell renders a value as a CSV cell. Strings that a spreadsheet would
+// read as a formula are prefixed with a quote so they stay text.
+func csvCell(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+			return "'" + v
+		}
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
+
+// normalizeNumbers turns the json.Number values of a decoded document into
+// int64 or float64, so integers stay integers in YAML and MessagePack.
+func normalizeNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = normalizeNumbers(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalizeNumbers(item)
+		}
+	}
+	return value
+}
@@ -0,0 +1,188 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/ugorji/go/codec"
+)
+
+func TestNegotiationMiddleWare(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(NegotiationMiddleWare())
+	r.GET("/todo", func(c *gin.Context) {
+		c.JSON(http.StatusOK, []gin.H{
+			{"id": 1, "name": "Write, docs", "project_id": 3},
+			{"id": 2, "name": "Release", "tags": []string{"a"}},
+			{"id": 3, "name": "=HYPERLINK(\"http://evil\")", "project_id": -1},
+			{"id": 4, "name": "@SUM(A1)", "tags": []string{"-x"}},
+		})
+	})
+	r.GET("/todo/1", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"id": 1, "name": "Write docs"})
+	})
+	r.GET("/todo/2", func(c *gin.Context) {
+		c.Header("ETag", `"2-5"`)
+		if c.GetHeader("If-None-Match") == `"2-5"` {
+			c.Status(http.StatusNotModified)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"id": 2, "name": "Release"})
+	})
+	r.PUT("/todo/2", func(c *gin.Context) {
+		if c.GetHeader("If-Match") != `"2-5"` {
+			c.Status(http.StatusPreconditionFailed)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"id": 2, "name": "Release"})
+	})
+	r.POST("/todo", func(c *gin.Context) {
+		var req struct {
+			Name string `json:"name"`
+			ID   int    `json:"id"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+			return
+		}
+		c.JSON(http.StatusCreated, req)
+	})
+
+	send := func(method, path, accept, contentType string, body []byte) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, bytes.NewReader(body))
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("json by default", func(t *testing.T) {
+		w := send(http.MethodGet, "/todo/1", "*/*", "", nil)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"id":1,"name":"Write docs"}`, w.Body.String())
+	})
+
+	t.Run("yaml", func(t *testing.T) {
+		w := send(http.MethodGet, "/todo/1", "application/json;q=0.5, application/yaml", "", nil)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, MIMEYAML, w.Header().Get("Content-Type"))
+		assert.Equal(t, "id: 1\nname: Write docs\n", w.Body.String())
+	})
+
+	t.Run("msgpack", func(t *testing.T) {
+		w := send(http.MethodGet, "/todo/1", MIMEMsgPack, "", nil)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, MIMEMsgPack, w.Header().Get("Content-Type"))
+		var resp map[string]interface{}
+		assert.NoError(t, codec.NewDecoderBytes(w.Body.Bytes(), msgpackHandle).Decode(&resp))
+		assert.EqualValues(t, 1, resp["id"])
+		assert.Equal(t, "Write docs", resp["name"])
+	})
+
+	t.Run("csv list", func(t *testing.T) {
+		w := send(http.MethodGet, "/todo", MIMECSV, "", nil)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Equal(t, "id,name,project_id,tags\n1,\"Write, docs\",3,\n2,Release,,\"[\"\"a\"\"]\"\n"+
+			"3,\"'=HYPERLINK(\"\"http://evil\"\")\",-1,\n4,'@SUM(A1),,\"[\"\"-x\"\"]\"\n", w.Body.String())
+	})
+
+	t.Run("csv object", func(t *testing.T) {
+		w := send(http.MethodGet, "/todo/1", MIMECSV, "", nil)
+
+		assert.Equal(t, http.StatusNotAcceptable, w.Code)
+	})
+
+	t.Run("not acceptable", func(t *testing.T) {
+		w := send(http.MethodGet, "/todo/1", "application/xml", "", nil)
+
+		assert.Equal(t, http.StatusNotAcceptable, w.Code)
+	})
+
+	t.Run("yaml request", func(t *testing.T) {
+		w := send(http.MethodPost, "/todo", "", "application/x-yaml", []byte("name: Write docs\nid: 4\n"))
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.JSONEq(t, `{"name":"Write docs","id":4}`, w.Body.String())
+	})
+
+	t.Run("msgpack request", func(t *testing.T) {
+		var body []byte
+		assert.NoError(t, codec.NewEncoderBytes(&body, msgpackHandle).Encode(map[string]interface{}{"name": "Write docs", "id": 5}))
+		w := send(http.MethodPost, "/todo", "", MIMEMsgPack, body)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.JSONEq(t, `{"name":"Write docs","id":5}`, w.Body.String())
+	})
+
+	t.Run("request too large", func(t *testing.T) {
+		defer func(limit int64) { MaxRequestBody = limit }(MaxRequestBody)
+		MaxRequestBody = 16
+
+		w := send(http.MethodPost, "/todo", "", MIMEYAML, []byte("name: A todo with a long name\n"))
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	})
+
+	t.Run("formula cells", func(t *testing.T) {
+		for _, cell := range []string{"=1+1", "+1", "-1", "@A1", "\tx", "\rx"} {
+			assert.Equal(t, "'"+cell, csvCell(cell))
+		}
+		assert.Equal(t, "a=b", csvCell("a=b"))
+		assert.Equal(t, "-1", csvCell(float64(-1)))
+	})
+
+	t.Run("unsupported media type", func(t *testing.T) {
+		w := send(http.MethodPost, "/todo", "", "application/xml", []byte("<todo/>"))
+
+		assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+	})
+
+	t.Run("etag per format", func(t *testing.T) {
+		conditional := func(method, accept, header, etag string) *httptest.ResponseRecorder {
+			req, _ := http.NewRequest(method, "/todo/2", nil)
+			req.Header.Set("Accept", accept)
+			req.Header.Set(header, etag)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			return w
+		}
+
+		w := send(http.MethodGet, "/todo/2", MIMEYAML, "", nil)
+		assert.Equal(t, `"2-5-yaml"`, w.Header().Get("ETag"))
+		assert.Equal(t, "Accept", w.Header().Get("Vary"))
+		w = send(http.MethodGet, "/todo/2", MIMEJSON, "", nil)
+		assert.Equal(t, `"2-5"`, w.Header().Get("ETag"))
+		assert.Equal(t, "Accept", w.Header().Get("Vary"))
+
+		w = conditional(http.MethodGet, MIMEYAML, "If-None-Match", `"2-5-yaml"`)
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Equal(t, `"2-5-yaml"`, w.Header().Get("ETag"))
+		w = conditional(http.MethodGet, MIMEYAML, "If-None-Match", `"2-5"`)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "name: Release")
+		w = conditional(http.MethodGet, MIMEJSON, "If-None-Match", `"2-5-yaml"`)
+		assert.Equal(t, http.StatusOK, w.Code)
+		w = conditional(http.MethodGet, MIMEMsgPack, "If-None-Match", `W/"2-5-yaml"`)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = conditional(http.MethodPut, MIMEJSON, "If-Match", `"2-5-yaml"`)
+		assert.Equal(t, http.StatusOK, w.Code)
+		w = conditional(http.MethodPut, MIMEYAML, "If-Match", `"2-4-yaml"`)
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	})
+}
@@ -0,0 +1,45 @@
+package model
+
+import "time"
+
+const (
+	ReminderStatusPending   = "pending"
+	ReminderStatusFiring    = "firing"
+	ReminderStatusDelivered = "delivered"
+	ReminderStatusFailed    = "failed"
+	ReminderStatusCancelled = "cancelled"
+)
+
+// Reminder notifies Target through Channel when a todo needs attention. It
+// fires either at RemindAt or, when Offset is set, Offset minutes after the
+// todo's due date (negative for before). FireAt is the resolved time and is
+// kept in step with the due date of relative reminders.
+//
+// NextAttemptAt is when the scheduler tries next: FireAt at first, later
+// pushed back after each failed delivery until MaxAttempts is reached.
+type Reminder struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	TodoID        int        `gorm:"index;not null" json:"todo_id"`
+	Owner         string     `gorm:"not null" json:"owner"`
+	RemindAt      *time.Time `json:"remind_at"`
+	Offset        *int       `json:"offset"`
+	FireAt        time.Time  `gorm:"not null" json:"fire_at"`
+	Channel       string     `gorm:"not null" json:"channel"`
+	Target        string     `json:"target"`
+	Status        string     `gorm:"index:idx_reminder_due,priority:1;default:pending;not null" json:"status"`
+	NextAttemptAt time.Time  `gorm:"index:idx_reminder_due,priority:2;not null" json:"next_attempt_at"`
+	Attempts      int        `gorm:"default:0;not null" json:"attempts"`
+	LastError     string     `json:"last_error"`
+	DeliveredAt   *time.Time `json:"delivered_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+func (Reminder) TableName() string {
+	return "reminder"
+}
+
+// Relative reports whether the reminder follows the todo's due date.
+func (r *Reminder) Relative() bool {
+	return r.Offset != nil
+}
@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// SavedView is a named todo filter and sort order kept for a user. Shared
+// views can be read and executed by every user but only changed by their
+// owner.
+type SavedView struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Owner     string    `gorm:"index;not null" json:"owner"`
+	Name      string    `gorm:"not null" json:"name"`
+	Filter    string    `json:"filter"`
+	Sort      string    `json:"sort"`
+	Shared    bool      `gorm:"default:false;not null" json:"shared"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (SavedView) TableName() string {
+	return "saved_view"
+}
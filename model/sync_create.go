@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// SyncCreate maps the ID a sync client gave a todo it created offline to the
+// todo created for it, so that pushing the same create again returns that
+// todo instead of a copy. Client IDs are scoped to the actor pushing them.
+type SyncCreate struct {
+	Actor     string    `gorm:"primaryKey" json:"actor"`
+	ClientID  string    `gorm:"primaryKey" json:"client_id"`
+	TodoID    int       `gorm:"index;not null" json:"todo_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (SyncCreate) TableName() string {
+	return "sync_create"
+}
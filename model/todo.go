@@ -6,16 +6,74 @@ import (
 	"gorm.io/gorm"
 )
 
+const (
+	TodoStatusTodo  = "todo"
+	TodoStatusDoing = "doing"
+	TodoStatusDone  = "done"
+)
+
 type Todo struct {
 	ID          int            `json:"id" gorm:"primaryKey;autoIncrement"`
 	Name        string         `json:"name" gorm:"not null"`
 	Description string         `json:"description" gorm:"not null"`
-	Status      string         `json:"status" gorm:"default:doing,not null"`
+	Status      string         `json:"status" gorm:"default:doing;not null"`
+	Version     int            `json:"version" gorm:"default:1;not null"`
+	Owner       string         `json:"owner,omitempty" gorm:"index;not null;default:''"`
+	ProjectID   *int           `json:"project_id,omitempty" gorm:"index:idx_todo_list"`
+	ParentID    *int           `json:"parent_id,omitempty" gorm:"index:idx_todo_list"`
+	Position    string         `json:"position" gorm:"index:idx_todo_list;not null;default:''"`
+	DueAt       *time.Time     `json:"due_at,omitempty" gorm:"index"`
+	Recurrence  string         `json:"recurrence,omitempty" gorm:"not null;default:''"`
+	Timezone    string         `json:"timezone,omitempty" gorm:"not null;default:''"`
+	SeriesID    *int           `json:"series_id,omitempty" gorm:"index:idx_todo_series"`
+	Occurrence  int            `json:"occurrence,omitempty" gorm:"index:idx_todo_series;not null;default:1"`
 	CreatedAt   time.Time      `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt   time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
 	DeletedAt   gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index" swaggerignore:"true"`
+	// Children holds the subtasks of the todo when a query includes them.
+	Children []*Todo `json:"children,omitempty" gorm:"-"`
+}
+
+// TodoList identifies the list a todo is manually ordered in: the todos of a
+// project with the same parent. Nil fields mean no project or no parent.
+type TodoList struct {
+	ProjectID *int
+	ParentID  *int
+}
+
+// List returns the list the todo is ordered in.
+func (t *Todo) List() TodoList {
+	return TodoList{ProjectID: t.ProjectID, ParentID: t.ParentID}
+}
+
+// Series returns the ID shared by every occurrence of a recurring todo: the
+// ID of its first occurrence.
+func (t *Todo) Series() int {
+	if t.SeriesID != nil {
+		return *t.SeriesID
+	}
+	return t.ID
+}
+
+// Location returns the time zone the due dates of the todo are computed in.
+func (t *Todo) Location() *time.Location {
+	if t.Timezone == "" {
+		return time.UTC
+	}
+	if loc, err := time.LoadLocation(t.Timezone); err == nil {
+		return loc
+	}
+	return time.UTC
 }
 
 func (Todo) TableName() string {
 	return "todo"
 }
+
+func IsValidTodoStatus(status string) bool {
+	switch status {
+	case TodoStatusTodo, TodoStatusDoing, TodoStatusDone:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+// Event types published for todo changes.
+const (
+	EventTodoCreated   = "todo.created"
+	EventTodoUpdated   = "todo.updated"
+	EventTodoCompleted = "todo.completed"
+	EventTodoDeleted   = "todo.deleted"
+)
+
+// TodoEvents lists the events published for todo changes.
+var TodoEvents = []string{EventTodoCreated, EventTodoUpdated, EventTodoCompleted, EventTodoDeleted}
+
+// OutboxEvent is an event written in the same transaction as the change it
+// describes and published afterwards by the outbox relay. EventID is stable
+// across republishing, so consumers can use it to drop duplicates. ClaimedAt
+// is set while a relay is publishing the event.
+type OutboxEvent struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	EventID     string     `gorm:"uniqueIndex;not null" json:"event_id"`
+	Type        string     `gorm:"not null" json:"type"`
+	AggregateID int        `gorm:"index;not null" json:"aggregate_id"`
+	Payload     string     `gorm:"type:text;not null" json:"payload"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ClaimedAt   *time.Time `json:"claimed_at"`
+	PublishedAt *time.Time `gorm:"index" json:"published_at"`
+}
+
+func (OutboxEvent) TableName() string {
+	return "outbox"
+}
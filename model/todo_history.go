@@ -0,0 +1,134 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+const (
+	HistoryActionCreated      = "created"
+	HistoryActionUpdated      = "updated"
+	HistoryActionTransitioned = "transitioned"
+	HistoryActionDeleted      = "deleted"
+	HistoryActionRestored     = "restored"
+	HistoryActionReverted     = "reverted"
+	HistoryActionPurged       = "purged"
+	HistoryActionMoved        = "moved"
+	// HistoryActionRepositioned records a todo given a new position by a
+	// rebalance of its list, which keeps the order and the version.
+	HistoryActionRepositioned = "repositioned"
+)
+
+// TodoHistory is one entry of the audit trail of a todo. Version is the todo
+// version after the change, Snapshot the tracked fields at that version and
+// Changes the field-level diff against the previous state. TxID is the
+// database transaction that wrote the entry and orders changes for sync.
+type TodoHistory struct {
+	ID        int          `json:"id" gorm:"primaryKey;autoIncrement"`
+	TodoID    int          `json:"todo_id" gorm:"index;not null"`
+	Version   int          `json:"version" gorm:"not null"`
+	Action    string       `json:"action" gorm:"not null"`
+	Actor     string       `json:"actor" gorm:"not null"`
+	Changes   FieldChanges `json:"changes" gorm:"type:jsonb"`
+	Snapshot  TodoSnapshot `json:"snapshot" gorm:"type:jsonb"`
+	TxID      int64        `json:"-" gorm:"index;not null;default:txid_current()"`
+	CreatedAt time.Time    `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (TodoHistory) TableName() string {
+	return "todo_history"
+}
+
+// TodoSnapshot holds the user-editable fields of a todo.
+type TodoSnapshot struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Status      string     `json:"status"`
+	DueAt       *time.Time `json:"due_at"`
+	Recurrence  string     `json:"recurrence"`
+	Timezone    string     `json:"timezone"`
+}
+
+func SnapshotOf(todo *Todo) TodoSnapshot {
+	return TodoSnapshot{
+		Name:        todo.Name,
+		Description: todo.Description,
+		Status:      todo.Status,
+		DueAt:       todo.DueAt,
+		Recurrence:  todo.Recurrence,
+		Timezone:    todo.Timezone,
+	}
+}
+
+// Diff returns the fields whose value differs between s and next.
+func (s TodoSnapshot) Diff(next TodoSnapshot) FieldChanges {
+	changes := FieldChanges{}
+	if s.Name != next.Name {
+		changes["name"] = FieldChange{Old: s.Name, New: next.Name}
+	}
+	if s.Description != next.Description {
+		changes["description"] = FieldChange{Old: s.Description, New: next.Description}
+	}
+	if s.Status != next.Status {
+		changes["status"] = FieldChange{Old: s.Status, New: next.Status}
+	}
+	if !sameInstant(s.DueAt, next.DueAt) {
+		changes["due_at"] = FieldChange{Old: s.DueAt, New: next.DueAt}
+	}
+	if s.Recurrence != next.Recurrence {
+		changes["recurrence"] = FieldChange{Old: s.Recurrence, New: next.Recurrence}
+	}
+	if s.Timezone != next.Timezone {
+		changes["timezone"] = FieldChange{Old: s.Timezone, New: next.Timezone}
+	}
+	return changes
+}
+
+// sameInstant reports whether two optional times are both unset or equal.
+func sameInstant(a *time.Time, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+func (s TodoSnapshot) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+func (s *TodoSnapshot) Scan(value interface{}) error {
+	return scanJSON(value, s)
+}
+
+type FieldChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+type FieldChanges map[string]FieldChange
+
+func (c FieldChanges) Value() (driver.Value, error) {
+	if c == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(c)
+}
+
+func (c *FieldChanges) Scan(value interface{}) error {
+	return scanJSON(value, c)
+}
+
+func scanJSON(value interface{}, dest interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, dest)
+	case string:
+		return json.Unmarshal([]byte(v), dest)
+	default:
+		return errors.New("unsupported JSON column type")
+	}
+}
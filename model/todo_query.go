@@ -0,0 +1,89 @@
+package model
+
+import "todo_project/common/filter"
+
+// TodoFilterFields is the allow-list of fields a todo filter expression may
+// reference, mapped to their columns.
+var TodoFilterFields = filter.Schema{
+	"id":          {Column: "id", Kind: filter.KindInt},
+	"name":        {Column: "name", Kind: filter.KindString},
+	"description": {Column: "description", Kind: filter.KindString},
+	"status": {
+		Column:  "status",
+		Kind:    filter.KindEnum,
+		Values:  []string{TodoStatusTodo, TodoStatusDoing, TodoStatusDone},
+		Ordered: true,
+	},
+	"version":  {Column: "version", Kind: filter.KindInt},
+	"project":  {Column: "project_id", Kind: filter.KindInt},
+	"parent":   {Column: "parent_id", Kind: filter.KindInt},
+	"position": {Column: "position", Kind: filter.KindString},
+	"created":  {Column: "created_at", Kind: filter.KindTime},
+	"updated":  {Column: "updated_at", Kind: filter.KindTime},
+	"due":      {Column: "due_at", Kind: filter.KindTime},
+}
+
+// TodoIncludeChildren includes the subtasks of every todo listed.
+const TodoIncludeChildren = "children"
+
+// TodoColumns are the fields a listing can be narrowed to, mapped to their
+// columns.
+var TodoColumns = map[string]string{
+	"id":          "id",
+	"name":        "name",
+	"description": "description",
+	"status":      "status",
+	"version":     "version",
+	"project_id":  "project_id",
+	"parent_id":   "parent_id",
+	"position":    "position",
+	"due_at":      "due_at",
+	"recurrence":  "recurrence",
+	"timezone":    "timezone",
+	"occurrence":  "occurrence",
+	"created_at":  "created_at",
+	"updated_at":  "updated_at",
+}
+
+// TodoQuery narrows, orders and pages a todo listing. A zero Limit returns
+// every matching row. Filter and Sort may only reference TodoFilterFields.
+//
+// Columns, when set, are the only columns loaded, for the todos and their
+// relations alike. Include lists the relations loaded with every todo; only
+// TodoIncludeChildren exists.
+//
+// GroupBy, when set to one of TodoFilterFields, applies Limit and Offset to
+// every group of todos sharing that field instead of to the whole listing.
+type TodoQuery struct {
+	Status  string
+	Filter  filter.Expr
+	Sort    []filter.SortKey
+	Limit   int
+	Offset  int
+	Columns []string
+	Include []string
+	GroupBy string
+}
+
+// TodoSearch is a full-text search over todo names and descriptions. Every
+// word of Text is matched as a prefix. An empty Language uses the configured
+// default.
+//
+// A Fuzzy search instead matches todo names by trigram similarity, which
+// tolerates typos. Threshold overrides the configured minimum similarity
+// when it is between 0 and 1.
+type TodoSearch struct {
+	Text      string
+	Language  string
+	Fuzzy     bool
+	Threshold float64
+}
+
+// TodoSearchResult is a todo matched by a search, with its relevance and the
+// matched fields highlighted with <mark> tags.
+type TodoSearchResult struct {
+	Todo
+	Rank                 float64 `json:"rank"`
+	NameHighlight        string  `json:"name_highlight"`
+	DescriptionHighlight string  `json:"description_highlight"`
+}
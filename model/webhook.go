@@ -0,0 +1,77 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	DeliveryStatusPending    = "pending"
+	DeliveryStatusDelivering = "delivering"
+	DeliveryStatusDelivered  = "delivered"
+	DeliveryStatusFailed     = "failed"
+)
+
+// Webhook is a subscription POSTing todo events to URL. Events is a
+// comma-separated list of event names; empty means every event. Payloads
+// are signed with Secret, which is never returned after creation.
+type Webhook struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Owner     string    `gorm:"index;not null" json:"owner"`
+	URL       string    `gorm:"not null" json:"url"`
+	Secret    string    `gorm:"not null" json:"-"`
+	Events    string    `gorm:"not null;default:''" json:"events"`
+	Active    bool      `gorm:"default:true;not null" json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (Webhook) TableName() string {
+	return "webhook"
+}
+
+// EventList returns the subscribed event names, nil for every event.
+func (w *Webhook) EventList() []string {
+	if w.Events == "" {
+		return nil
+	}
+	return strings.Split(w.Events, ",")
+}
+
+// Subscribes reports whether the webhook wants event.
+func (w *Webhook) Subscribes(event string) bool {
+	events := w.EventList()
+	if events == nil {
+		return true
+	}
+	for _, e := range events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery is one event sent to a webhook and the outcome of its
+// attempts. Payload is the exact body that was signed. Failed sends are
+// retried at NextAttemptAt until the attempts run out.
+type WebhookDelivery struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	WebhookID      uint       `gorm:"index;not null" json:"webhook_id"`
+	EventID        string     `gorm:"index;not null" json:"event_id"`
+	Event          string     `gorm:"not null" json:"event"`
+	TodoID         int        `gorm:"not null" json:"todo_id"`
+	Payload        string     `gorm:"type:text;not null" json:"payload"`
+	Status         string     `gorm:"index:idx_webhook_delivery_due,priority:1;default:pending;not null" json:"status"`
+	NextAttemptAt  time.Time  `gorm:"index:idx_webhook_delivery_due,priority:2;not null" json:"next_attempt_at"`
+	Attempts       int        `gorm:"default:0;not null" json:"attempts"`
+	ResponseStatus int        `json:"response_status"`
+	LastError      string     `json:"last_error"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+func (WebhookDelivery) TableName() string {
+	return "webhook_delivery"
+}
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// fakeDB is a database/sql driver that records the statements a repository
+// sends and answers them through a handler, for checking queries without a
+// database. Transactions are recorded as BEGIN, COMMIT and ROLLBACK.
+type fakeDB struct {
+	mu         sync.Mutex
+	statements []fakeStatement
+	handler    func(query string, args []driver.Value) (*fakeRows, error)
+}
+
+type fakeStatement struct {
+	Query string
+	Args  []driver.Value
+}
+
+// fakeRows answers a query with Rows of the Names columns, or an exec with
+// Affected.
+type fakeRows struct {
+	Names    []string
+	Rows     [][]driver.Value
+	Affected int64
+	next     int
+}
+
+// newFakeDB opens a Postgres gorm handle on a fakeDB answering with handler.
+func newFakeDB(t *testing.T, handler func(query string, args []driver.Value) (*fakeRows, error)) (*gorm.DB, *fakeDB) {
+	fake := &fakeDB{handler: handler}
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sql.OpenDB(fake)}), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, fake
+}
+
+// queries returns the recorded statements starting with prefix.
+func (f *fakeDB) queries(prefix string) []fakeStatement {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var statements []fakeStatement
+	for _, statement := range f.statements {
+		if strings.HasPrefix(statement.Query, prefix) {
+			statements = append(statements, statement)
+		}
+	}
+	return statements
+}
+
+// log returns the recorded statements, each cut to its first words.
+func (f *fakeDB) log() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	log := make([]string, len(f.statements))
+	for i, statement := range f.statements {
+		words := strings.Fields(statement.Query)
+		if len(words) > 3 {
+			words = words[:3]
+		}
+		log[i] = strings.Join(words, " ")
+	}
+	return log
+}
+
+func (f *fakeDB) run(query string, args []driver.NamedValue) (*fakeRows, error) {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	f.mu.Lock()
+	f.statements = append(f.statements, fakeStatement{Query: query, Args: values})
+	f.mu.Unlock()
+	if f.handler == nil {
+		return &fakeRows{}, nil
+	}
+	rows, err := f.handler(query, values)
+	if rows == nil && err == nil {
+		rows = &fakeRows{}
+	}
+	return rows, err
+}
+
+func (f *fakeDB) record(query string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.statements = append(f.statements, fakeStatement{Query: query})
+}
+
+func (f *fakeDB) Connect(context.Context) (driver.Conn, error) { return fakeConn{f}, nil }
+func (f *fakeDB) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct{ db *fakeDB }
+
+func (c fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("fakedb: prepared statements are not supported")
+}
+func (c fakeConn) Close() error { return nil }
+func (c fakeConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c fakeConn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	c.db.record("BEGIN")
+	return fakeTx{c.db}, nil
+}
+
+func (c fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return c.db.run(query, args)
+}
+
+func (c fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	rows, err := c.db.run(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(rows.Affected), nil
+}
+
+type fakeTx struct{ db *fakeDB }
+
+func (t fakeTx) Commit() error   { t.db.record("COMMIT"); return nil }
+func (t fakeTx) Rollback() error { t.db.record("ROLLBACK"); return nil }
+
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Columns() []string { return r.Names }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.Rows) {
+		return io.EOF
+	}
+	copy(dest, r.Rows[r.next])
+	r.next++
+	return nil
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"time"
+
+	"todo_project/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OutboxRepository interface {
+	Create(event *model.OutboxEvent) error
+	Publish(limit int, staleBefore time.Time, publish func(events []*model.OutboxEvent) error) (int, error)
+	DeletePublishedBefore(before time.Time) (int64, error)
+}
+
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+// Outbox returns the outbox repository sharing this repository's connection,
+// so events written inside Transaction commit with the change.
+func (r *todoRepository) Outbox() OutboxRepository {
+	return &outboxRepository{db: r.db}
+}
+
+func (r *outboxRepository) Create(event *model.OutboxEvent) error {
+	return r.db.Create(event).Error
+}
+
+// Publish claims up to limit unpublished events, oldest first, hands them to
+// publish and marks them published when it succeeds. The claim commits
+// before publish runs, so no row stays locked while the events are handed
+// over. Concurrent callers skip claimed events unless the claim went stale
+// before staleBefore, so relays on several replicas share the work. When
+// publish fails the claim is released and the events come back on the next
+// call.
+func (r *outboxRepository) Publish(limit int, staleBefore time.Time, publish func(events []*model.OutboxEvent) error) (int, error) {
+	events, err := r.claim(limit, staleBefore)
+	if err != nil || len(events) == 0 {
+		return 0, err
+	}
+	ids := make([]uint, 0, len(events))
+	for _, event := range events {
+		ids = append(ids, event.ID)
+	}
+
+	if err := publish(events); err != nil {
+		// Should the release fail too, the claim goes stale instead.
+		_ = r.db.Model(&model.OutboxEvent{}).Where("id IN ?", ids).Update("claimed_at", nil).Error
+		return 0, err
+	}
+	err = r.db.Model(&model.OutboxEvent{}).Where("id IN ?", ids).
+		Updates(map[string]interface{}{"published_at": time.Now(), "claimed_at": nil}).Error
+	if err != nil {
+		return 0, err
+	}
+	return len(events), nil
+}
+
+// claim marks up to limit unpublished events, oldest first, as claimed now.
+func (r *outboxRepository) claim(limit int, staleBefore time.Time) ([]*model.OutboxEvent, error) {
+	var events []*model.OutboxEvent
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("published_at IS NULL AND (claimed_at IS NULL OR claimed_at < ?)", staleBefore).
+			Order("id").
+			Limit(limit).
+			Find(&events).Error
+		if err != nil || len(events) == 0 {
+			return err
+		}
+
+		ids := make([]uint, 0, len(events))
+		for _, event := range events {
+			ids = append(ids, event.ID)
+		}
+		return tx.Model(&model.OutboxEvent{}).Where("id IN ?", ids).Update("claimed_at", time.Now()).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// DeletePublishedBefore removes events published before the given time.
+func (r *outboxRepository) DeletePublishedBefore(before time.Time) (int64, error) {
+	result := r.db.Where("published_at < ?", before).Delete(&model.OutboxEvent{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"todo_project/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// outboxHandler answers the claim query with events of the given IDs.
+func outboxHandler(ids ...int64) func(query string, args []driver.Value) (*fakeRows, error) {
+	return func(query string, args []driver.Value) (*fakeRows, error) {
+		if strings.HasPrefix(query, `SELECT * FROM "outbox"`) {
+			rows := &fakeRows{Names: []string{"id", "event_id", "type", "aggregate_id", "payload"}}
+			for _, id := range ids {
+				rows.Rows = append(rows.Rows, []driver.Value{id, fmt.Sprintf("e%d", id), "todo.created", int64(7), "{}"})
+			}
+			return rows, nil
+		}
+		return &fakeRows{Affected: int64(len(ids))}, nil
+	}
+}
+
+func TestOutboxPublish(t *testing.T) {
+	staleBefore := time.Now().Add(-time.Minute)
+
+	t.Run("claims, publishes and marks the batch", func(t *testing.T) {
+		db, fake := newFakeDB(t, outboxHandler(1, 2))
+		var published []string
+		var logAtPublish []string
+
+		count, err := (&outboxRepository{db: db}).Publish(10, staleBefore, func(events []*model.OutboxEvent) error {
+			for _, event := range events {
+				published = append(published, event.EventID)
+			}
+			logAtPublish = fake.log()
+			return nil
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, count)
+		assert.Equal(t, []string{"e1", "e2"}, published)
+		// The claim is committed before the events are handed over.
+		assert.Equal(t, []string{"BEGIN", `SELECT * FROM`, `UPDATE "outbox" SET`, "COMMIT"}, logAtPublish)
+
+		claim := fake.queries(`SELECT * FROM "outbox"`)[0]
+		assert.Contains(t, claim.Query, "published_at IS NULL AND (claimed_at IS NULL OR claimed_at < $1)")
+		assert.Contains(t, claim.Query, "ORDER BY id LIMIT $2 FOR UPDATE SKIP LOCKED")
+		assert.Equal(t, staleBefore, claim.Args[0])
+
+		updates := fake.queries(`UPDATE "outbox"`)
+		assert.Len(t, updates, 2)
+		assert.Contains(t, updates[0].Query, `SET "claimed_at"=$1 WHERE id IN ($2,$3)`)
+		assert.Contains(t, updates[1].Query, `"published_at"=`)
+		assert.Contains(t, updates[1].Query, `"claimed_at"=`)
+		assert.Contains(t, updates[1].Query, `WHERE id IN (`)
+	})
+
+	t.Run("releases the claim when publishing fails", func(t *testing.T) {
+		db, fake := newFakeDB(t, outboxHandler(1, 2))
+
+		count, err := (&outboxRepository{db: db}).Publish(10, staleBefore, func(events []*model.OutboxEvent) error {
+			return errors.New("redis down")
+		})
+
+		assert.EqualError(t, err, "redis down")
+		assert.Zero(t, count)
+		updates := fake.queries(`UPDATE "outbox"`)
+		assert.Len(t, updates, 2)
+		assert.Contains(t, updates[1].Query, `SET "claimed_at"=$1 WHERE id IN ($2,$3)`)
+		assert.Nil(t, updates[1].Args[0])
+		assert.NotContains(t, updates[1].Query, "published_at")
+	})
+
+	t.Run("nothing to publish", func(t *testing.T) {
+		db, fake := newFakeDB(t, outboxHandler())
+		called := false
+
+		count, err := (&outboxRepository{db: db}).Publish(10, staleBefore, func(events []*model.OutboxEvent) error {
+			called = true
+			return nil
+		})
+
+		assert.NoError(t, err)
+		assert.Zero(t, count)
+		assert.False(t, called)
+		assert.Empty(t, fake.queries("UPDATE"))
+	})
+
+	t.Run("claim failure", func(t *testing.T) {
+		db, fake := newFakeDB(t, func(query string, args []driver.Value) (*fakeRows, error) {
+			return nil, errors.New("connection reset")
+		})
+
+		_, err := (&outboxRepository{db: db}).Publish(10, staleBefore, func(events []*model.OutboxEvent) error {
+			t.Fatal("publish called")
+			return nil
+		})
+
+		assert.EqualError(t, err, "connection reset")
+		assert.Equal(t, []string{"BEGIN", `SELECT * FROM`, "ROLLBACK"}, fake.log())
+	})
+}
+
+func TestOutboxDeletePublishedBefore(t *testing.T) {
+	before := time.Now().Add(-time.Hour)
+	db, fake := newFakeDB(t, func(query string, args []driver.Value) (*fakeRows, error) {
+		return &fakeRows{Affected: 3}, nil
+	})
+
+	count, err := (&outboxRepository{db: db}).DeletePublishedBefore(before)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+	deletes := fake.queries(`DELETE FROM "outbox"`)
+	assert.Len(t, deletes, 1)
+	assert.Contains(t, deletes[0].Query, "WHERE published_at < $1")
+	assert.Equal(t, before, deletes[0].Args[0])
+}
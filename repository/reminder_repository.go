@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"time"
+
+	"todo_project/model"
+
+	"gorm.io/gorm"
+)
+
+type ReminderRepository interface {
+	Create(reminder *model.Reminder) error
+	FindByID(id uint) (*model.Reminder, error)
+	FindByTodoID(todoID int) ([]*model.Reminder, error)
+	FindDue(before time.Time, staleBefore time.Time, limit int) ([]*model.Reminder, error)
+	Claim(id uint, now time.Time, staleBefore time.Time) (bool, error)
+	Cancel(id uint) (bool, error)
+	Save(reminder *model.Reminder) error
+}
+
+type reminderRepository struct {
+	db *gorm.DB
+}
+
+// Reminders returns the reminder repository sharing this repository's
+// connection, so reminders rescheduled inside Transaction commit with the
+// change to their todo.
+func (r *todoRepository) Reminders() ReminderRepository {
+	return &reminderRepository{db: r.db}
+}
+
+func (r *reminderRepository) Create(reminder *model.Reminder) error {
+	return r.db.Create(reminder).Error
+}
+
+func (r *reminderRepository) FindByID(id uint) (*model.Reminder, error) {
+	var reminder model.Reminder
+	if err := r.db.First(&reminder, id).Error; err != nil {
+		return nil, err
+	}
+	return &reminder, nil
+}
+
+// FindByTodoID returns the reminders of a todo, earliest first.
+func (r *reminderRepository) FindByTodoID(todoID int) ([]*model.Reminder, error) {
+	var reminders []*model.Reminder
+	if err := r.db.Where("todo_id = ?", todoID).Order("fire_at, id").Find(&reminders).Error; err != nil {
+		return nil, err
+	}
+	return reminders, nil
+}
+
+// FindDue returns pending reminders whose next attempt is at or before
+// before, together with reminders left firing since staleBefore by a
+// scheduler that stopped mid-delivery.
+func (r *reminderRepository) FindDue(before time.Time, staleBefore time.Time, limit int) ([]*model.Reminder, error) {
+	var reminders []*model.Reminder
+	err := r.db.Where("status = ? AND next_attempt_at <= ?", model.ReminderStatusPending, before).
+		Or("status = ? AND updated_at < ?", model.ReminderStatusFiring, staleBefore).
+		Order("next_attempt_at, id").
+		Limit(limit).
+		Find(&reminders).Error
+	if err != nil {
+		return nil, err
+	}
+	return reminders, nil
+}
+
+// Claim marks a reminder as firing. It reports false when the reminder is
+// not due by now, e.g. because it was rescheduled after being queued, or is
+// no longer pending because another scheduler claimed it first, unless that
+// claim went stale before staleBefore.
+func (r *reminderRepository) Claim(id uint, now time.Time, staleBefore time.Time) (bool, error) {
+	result := r.db.Model(&model.Reminder{}).
+		Where("id = ? AND ((status = ? AND next_attempt_at <= ?) OR (status = ? AND updated_at < ?))",
+			id, model.ReminderStatusPending, now, model.ReminderStatusFiring, staleBefore).
+		Updates(map[string]interface{}{
+			"status":     model.ReminderStatusFiring,
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Cancel marks a pending reminder as cancelled. It reports false when the
+// reminder is no longer pending, e.g. because a delivery claimed it first.
+func (r *reminderRepository) Cancel(id uint) (bool, error) {
+	result := r.db.Model(&model.Reminder{}).
+		Where("id = ? AND status = ?", id, model.ReminderStatusPending).
+		Updates(map[string]interface{}{
+			"status":     model.ReminderStatusCancelled,
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Save writes the schedule and delivery state of a reminder.
+func (r *reminderRepository) Save(reminder *model.Reminder) error {
+	result := r.db.Model(reminder).
+		Select("fire_at", "status", "next_attempt_at", "attempts", "last_error", "delivered_at").
+		Updates(reminder)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"todo_project/model"
+
+	"gorm.io/gorm"
+)
+
+type SavedViewRepository interface {
+	Create(view *model.SavedView) error
+	FindByID(id uint) (*model.SavedView, error)
+	FindVisibleTo(owner string) ([]*model.SavedView, error)
+	Update(view *model.SavedView) error
+	Delete(id uint) error
+}
+
+type savedViewRepository struct {
+	db *gorm.DB
+}
+
+func NewSavedViewRepository(db *gorm.DB) SavedViewRepository {
+	_ = db.AutoMigrate(&model.SavedView{})
+	return &savedViewRepository{db: db}
+}
+
+func (r *savedViewRepository) Create(view *model.SavedView) error {
+	return r.db.Create(view).Error
+}
+
+func (r *savedViewRepository) FindByID(id uint) (*model.SavedView, error) {
+	var view model.SavedView
+	if err := r.db.First(&view, id).Error; err != nil {
+		return nil, err
+	}
+	return &view, nil
+}
+
+// FindVisibleTo returns the views owner created and the views shared by
+// others, the caller's own first.
+func (r *savedViewRepository) FindVisibleTo(owner string) ([]*model.SavedView, error) {
+	var views []*model.SavedView
+	err := r.db.Where("owner = ? OR shared = ?", owner, true).
+		Order(gorm.Expr("owner = ? DESC, name, id", owner)).
+		Find(&views).Error
+	if err != nil {
+		return nil, err
+	}
+	return views, nil
+}
+
+func (r *savedViewRepository) Update(view *model.SavedView) error {
+	result := r.db.Model(view).Select("name", "filter", "sort", "shared").Updates(view)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *savedViewRepository) Delete(id uint) error {
+	result := r.db.Delete(&model.SavedView{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
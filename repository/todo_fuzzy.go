@@ -0,0 +1,218 @@
+package repository
+
+import (
+	"html"
+	"sort"
+	"strconv"
+	"strings"
+
+	"todo_project/common/log"
+	"todo_project/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FuzzyThreshold is the trigram similarity a todo name needs to reach to
+// match a fuzzy search or be offered as a suggestion.
+var FuzzyThreshold = 0.3
+
+// migrateTrigramIndex enables pg_trgm and indexes todo names for similarity
+// and prefix lookups. Without the extension fuzzy search fails on Postgres, so
+// the error is only logged to keep the rest of the service usable.
+func (r *todoRepository) migrateTrigramIndex() error {
+	if !r.isPostgres() {
+		return nil
+	}
+	if err := r.db.Exec("CREATE EXTENSION IF NOT EXISTS pg_trgm").Error; err != nil {
+		log.Errorf("Failed to enable pg_trgm, fuzzy search is unavailable: %v", err)
+		return nil
+	}
+	return r.db.Exec("CREATE INDEX IF NOT EXISTS idx_todo_name_trgm ON todo USING GIN (name gin_trgm_ops)").Error
+}
+
+func fuzzyThreshold(threshold float64) float64 {
+	if threshold <= 0 || threshold > 1 {
+		return FuzzyThreshold
+	}
+	return threshold
+}
+
+func (r *todoRepository) fuzzySearch(text string, threshold float64, query model.TodoQuery) ([]*model.TodoSearchResult, int64, error) {
+	if !r.isPostgres() {
+		return r.fuzzySearchFallback(text, threshold, query)
+	}
+
+	var (
+		results []*model.TodoSearchResult
+		total   int64
+	)
+	// The % operator uses the session threshold, which lets the trigram index
+	// serve the query; SET LOCAL scopes it to this transaction.
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT set_config('pg_trgm.similarity_threshold', ?, true)",
+			strconv.FormatFloat(threshold, 'f', -1, 64)).Error; err != nil {
+			return err
+		}
+		db := applyTodoQuery(tx.Model(&model.Todo{}), query).
+			Where("name % ?", text).
+			Session(&gorm.Session{})
+		if err := db.Count(&total).Error; err != nil {
+			return err
+		}
+		return db.Select(
+			"todo.*, similarity(name, ?) AS rank, name AS name_highlight, description AS description_highlight", text,
+		).Order("rank DESC, id DESC").Scopes(paginate(query)).Scan(&results).Error
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, result := range results {
+		result.NameHighlight = html.EscapeString(result.NameHighlight)
+		result.DescriptionHighlight = html.EscapeString(result.DescriptionHighlight)
+	}
+	return results, total, nil
+}
+
+func (r *todoRepository) fuzzySearchFallback(text string, threshold float64, query model.TodoQuery) ([]*model.TodoSearchResult, int64, error) {
+	var todos []*model.Todo
+	if err := applyTodoQuery(r.db.Model(&model.Todo{}), query).Find(&todos).Error; err != nil {
+		return nil, 0, err
+	}
+
+	results := make([]*model.TodoSearchResult, 0)
+	for _, todo := range todos {
+		rank := trigramSimilarity(todo.Name, text)
+		if rank < threshold {
+			continue
+		}
+		results = append(results, &model.TodoSearchResult{
+			Todo:                 *todo,
+			Rank:                 rank,
+			NameHighlight:        html.EscapeString(todo.Name),
+			DescriptionHighlight: html.EscapeString(todo.Description),
+		})
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Rank != results[j].Rank {
+			return results[i].Rank > results[j].Rank
+		}
+		return results[i].ID > results[j].ID
+	})
+	return pageResults(results, query), int64(len(results)), nil
+}
+
+// SuggestNames returns up to limit distinct todo names similar to text, the
+// closest first. It backs "did you mean" hints for searches without results.
+func (r *todoRepository) SuggestNames(text string, threshold float64, limit int) ([]string, error) {
+	threshold = fuzzyThreshold(threshold)
+	if !r.isPostgres() {
+		var todos []*model.Todo
+		if err := r.db.Select("name").Find(&todos).Error; err != nil {
+			return nil, err
+		}
+		scores := map[string]float64{}
+		for _, todo := range todos {
+			if score := trigramSimilarity(todo.Name, text); score >= threshold {
+				scores[todo.Name] = score
+			}
+		}
+		return topNames(scores, limit), nil
+	}
+
+	var names []string
+	err := r.db.Model(&model.Todo{}).
+		Where("similarity(name, ?) >= ?", text, threshold).
+		Group("name").
+		Order(clause.OrderBy{Expression: clause.Expr{SQL: "similarity(name, ?) DESC, name", Vars: []interface{}{text}, WithoutParentheses: true}}).
+		Limit(limit).
+		Pluck("name", &names).Error
+	if err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// AutocompleteNames returns up to limit distinct todo names starting with
+// prefix, most used first.
+func (r *todoRepository) AutocompleteNames(prefix string, limit int) ([]string, error) {
+	pattern := escapeLike(prefix) + "%"
+	db := r.db.Model(&model.Todo{})
+	if r.isPostgres() {
+		db = db.Where(`name ILIKE ? ESCAPE '\'`, pattern)
+	} else {
+		db = db.Where(`LOWER(name) LIKE ? ESCAPE '\'`, strings.ToLower(pattern))
+	}
+
+	var names []string
+	err := db.Group("name").
+		Order("COUNT(*) DESC, MAX(updated_at) DESC, name").
+		Limit(limit).
+		Pluck("name", &names).Error
+	if err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// escapeLike escapes the LIKE wildcards of s with a backslash, to be matched
+// with ESCAPE '\'.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+func pageResults(results []*model.TodoSearchResult, query model.TodoQuery) []*model.TodoSearchResult {
+	start, end := query.Offset, len(results)
+	if start > end {
+		start = end
+	}
+	if query.Limit > 0 && start+query.Limit < end {
+		end = start + query.Limit
+	}
+	return results[start:end]
+}
+
+func topNames(scores map[string]float64, limit int) []string {
+	names := make([]string, 0, len(scores))
+	for name := range scores {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if scores[names[i]] != scores[names[j]] {
+			return scores[names[i]] > scores[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	if len(names) > limit {
+		names = names[:limit]
+	}
+	return names
+}
+
+// trigramSimilarity mirrors pg_trgm's similarity(): the share of distinct
+// trigrams two strings have in common, words being padded with two leading
+// and one trailing blank.
+func trigramSimilarity(a, b string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+	shared := 0
+	for t := range ta {
+		if tb[t] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(ta)+len(tb)-shared)
+}
+
+func trigrams(s string) map[string]bool {
+	set := map[string]bool{}
+	for _, word := range searchTerms(s) {
+		runes := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(runes); i++ {
+			set[string(runes[i:i+3])] = true
+		}
+	}
+	return set
+}
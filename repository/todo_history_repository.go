@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"todo_project/model"
+
+	"gorm.io/gorm"
+)
+
+type TodoHistoryRepository interface {
+	Create(entry *model.TodoHistory) error
+	FindByTodoID(todoID uint) ([]*model.TodoHistory, error)
+	FindByVersion(todoID uint, version int) (*model.TodoHistory, error)
+}
+
+type todoHistoryRepository struct {
+	db *gorm.DB
+}
+
+func (r *todoHistoryRepository) Create(entry *model.TodoHistory) error {
+	return r.db.Create(entry).Error
+}
+
+// FindByTodoID returns the history of a todo, oldest entry first.
+func (r *todoHistoryRepository) FindByTodoID(todoID uint) ([]*model.TodoHistory, error) {
+	var entries []*model.TodoHistory
+	if err := r.db.Where("todo_id = ?", todoID).Order("id ASC").Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// FindByVersion returns the entry that produced the given version of a todo.
+func (r *todoHistoryRepository) FindByVersion(todoID uint, version int) (*model.TodoHistory, error) {
+	var entry model.TodoHistory
+	err := r.db.Where("todo_id = ? AND version = ? AND action NOT IN ?", todoID, version,
+		[]string{model.HistoryActionDeleted, model.HistoryActionPurged}).
+		Order("id ASC").First(&entry).Error
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"errors"
+
+	"todo_project/model"
+
+	"gorm.io/gorm"
+)
+
+// inList restricts db to the todos of list.
+func inList(db *gorm.DB, list model.TodoList) *gorm.DB {
+	if list.ProjectID == nil {
+		db = db.Where("project_id IS NULL")
+	} else {
+		db = db.Where("project_id = ?", *list.ProjectID)
+	}
+	if list.ParentID == nil {
+		db = db.Where("parent_id IS NULL")
+	} else {
+		db = db.Where("parent_id = ?", *list.ParentID)
+	}
+	return db
+}
+
+// LastPosition returns the highest position in list, or "" for an empty list.
+func (r *todoRepository) LastPosition(list model.TodoList) (string, error) {
+	var todo model.Todo
+	err := inList(r.db.Model(&model.Todo{}), list).Order("position DESC, id DESC").First(&todo).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return todo.Position, nil
+}
+
+// FindAdjacent returns the todo right after todo in its list, or right before
+// it when after is false, skipping excludeID. It returns nil when todo is the
+// last (or first) one.
+func (r *todoRepository) FindAdjacent(todo *model.Todo, after bool, excludeID int) (*model.Todo, error) {
+	db := inList(r.db.Model(&model.Todo{}), todo.List()).Where("id <> ?", excludeID)
+	if after {
+		db = db.Where("position > ? OR (position = ? AND id > ?)", todo.Position, todo.Position, todo.ID).
+			Order("position ASC, id ASC")
+	} else {
+		db = db.Where("position < ? OR (position = ? AND id < ?)", todo.Position, todo.Position, todo.ID).
+			Order("position DESC, id DESC")
+	}
+
+	var adjacent model.Todo
+	err := db.First(&adjacent).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &adjacent, nil
+}
+
+// FindByList returns the todos of list in their manual order.
+func (r *todoRepository) FindByList(list model.TodoList) ([]*model.Todo, error) {
+	var todos []*model.Todo
+	if err := inList(r.db, list).Order("position ASC, id ASC").Find(&todos).Error; err != nil {
+		return nil, err
+	}
+	return todos, nil
+}
+
+// FindListsToRebalance returns the lists holding a position longer than
+// maxLength, or todos that have no position yet.
+func (r *todoRepository) FindListsToRebalance(maxLength int) ([]model.TodoList, error) {
+	var lists []model.TodoList
+	err := r.db.Model(&model.Todo{}).
+		Distinct("project_id", "parent_id").
+		Where("LENGTH(position) > ? OR position = ''", maxLength).
+		Scan(&lists).Error
+	if err != nil {
+		return nil, err
+	}
+	return lists, nil
+}
+
+// SetPositions rewrites the positions of todos and moves each to its next
+// version, as the position is part of what clients read and cache.
+func (r *todoRepository) SetPositions(positions map[int]string) error {
+	for id, position := range positions {
+		err := r.db.Model(&model.Todo{}).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+			"position": position,
+			"version":  gorm.Expr("version + 1"),
+		}).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LockBoard takes a lock on the board of a project, held until the
+// transaction ends, so that concurrent changes to its columns are checked
+// against the WIP limits one at a time. Other databases than Postgres
+// serialise writing transactions already.
+func (r *todoRepository) LockBoard(projectID int) error {
+	if !r.isPostgres() {
+		return nil
+	}
+	return r.db.Exec("SELECT pg_advisory_xact_lock(hashtext('todo_board'), ?)", projectID).Error
+}
+
+// CountByStatus returns how many todos of list have status.
+func (r *todoRepository) CountByStatus(list model.TodoList, status string) (int64, error) {
+	var count int64
+	if err := inList(r.db.Model(&model.Todo{}), list).Where("status = ?", status).Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
@@ -1,6 +1,11 @@
 package repository
 
 import (
+	"errors"
+	"fmt"
+	"time"
+
+	"todo_project/common/filter"
 	"todo_project/model"
 
 	"gorm.io/gorm"
@@ -10,16 +15,54 @@ type TodoRepository interface {
 	Create(todo *model.Todo) error
 	FindByID(id uint) (*model.Todo, error)
 	FindAll() ([]*model.Todo, error)
+	FindByQuery(query model.TodoQuery) ([]*model.Todo, error)
 	Update(todo *model.Todo) error
+	UpdateColumns(id uint, version int, columns map[string]interface{}) error
 	Delete(id uint) error
+	FindDeleted() ([]*model.Todo, error)
+	FindDeletedByID(id uint) (*model.Todo, error)
+	Restore(id uint) error
+	Purge(id uint) error
+	PurgeDeletedBefore(before time.Time) (int64, error)
+	Transaction(fn func(repo TodoRepository) error) error
+	History() TodoHistoryRepository
+	Reminders() ReminderRepository
+	Webhooks() WebhookRepository
+	Outbox() OutboxRepository
+	Search(search model.TodoSearch, query model.TodoQuery) ([]*model.TodoSearchResult, int64, error)
+	SuggestNames(text string, threshold float64, limit int) ([]string, error)
+	AutocompleteNames(prefix string, limit int) ([]string, error)
+	LastPosition(list model.TodoList) (string, error)
+	FindAdjacent(todo *model.Todo, after bool, excludeID int) (*model.Todo, error)
+	FindByList(list model.TodoList) ([]*model.Todo, error)
+	FindListsToRebalance(maxLength int) ([]model.TodoList, error)
+	SetPositions(positions map[int]string) error
+	CountByStatus(list model.TodoList, status string) (int64, error)
+	LockBoard(projectID int) error
+	FindOccurrence(series int, occurrence int) (*model.Todo, error)
+	SyncWatermark() (int64, error)
+	ChangedTodoIDs(since int64, until int64, limit int) ([]int, int64, error)
+	FindByIDsUnscoped(ids []int) ([]*model.Todo, error)
+	FindSyncCreate(actor string, clientID string) (int, error)
+	CreateSyncCreate(entry *model.SyncCreate) error
 }
 
+// ErrVersionConflict is returned when a todo was changed by someone else
+// since the caller read it.
+var ErrVersionConflict = errors.New("version conflict")
+
 type todoRepository struct {
 	db *gorm.DB
 }
 
 func (r *todoRepository) migrate() error {
-	return r.db.AutoMigrate(&model.Todo{})
+	if err := r.db.AutoMigrate(&model.Todo{}, &model.TodoHistory{}, &model.Reminder{}, &model.Webhook{}, &model.WebhookDelivery{}, &model.OutboxEvent{}, &model.SyncCreate{}); err != nil {
+		return err
+	}
+	if err := r.migrateSearchIndex(); err != nil {
+		return err
+	}
+	return r.migrateTrigramIndex()
 }
 
 func NewTodoRepository(db *gorm.DB) TodoRepository {
@@ -28,6 +71,12 @@ func NewTodoRepository(db *gorm.DB) TodoRepository {
 	return repo
 }
 
+// History returns the history repository sharing this repository's
+// connection, so entries written inside Transaction commit with the change.
+func (r *todoRepository) History() TodoHistoryRepository {
+	return &todoHistoryRepository{db: r.db}
+}
+
 func (r *todoRepository) Create(todo *model.Todo) error {
 	return r.db.Create(todo).Error
 }
@@ -48,10 +97,225 @@ func (r *todoRepository) FindAll() ([]*model.Todo, error) {
 	return todos, nil
 }
 
+// FindByQuery returns the todos matching query in its sort order, ties and
+// unsorted listings going oldest first.
+func (r *todoRepository) FindByQuery(query model.TodoQuery) ([]*model.Todo, error) {
+	order, err := filter.CompileSort(query.Sort, model.TodoFilterFields)
+	if err != nil {
+		return nil, err
+	}
+	db := applyTodoQuery(r.db.Model(&model.Todo{}), query)
+	if query.GroupBy != "" {
+		if db, err = r.pageGroups(db, query, order); err != nil {
+			return nil, err
+		}
+	} else {
+		if order != "" {
+			db = db.Order(order)
+		}
+		if len(query.Columns) > 0 {
+			db = db.Select(query.Columns)
+		}
+		db = db.Order("id").Scopes(paginate(query))
+	}
+
+	var todos []*model.Todo
+	if err := db.Find(&todos).Error; err != nil {
+		return nil, err
+	}
+	for _, include := range query.Include {
+		if include != model.TodoIncludeChildren {
+			return nil, fmt.Errorf("unknown relation %q", include)
+		}
+		if err := r.loadChildren(todos, query.Columns); err != nil {
+			return nil, err
+		}
+	}
+	return todos, nil
+}
+
+// pageGroups pages the todos selected by db group by group: the rows of
+// every group are numbered in sort order and only those of the requested
+// page are kept, so a batch of groups is one query however large they are.
+func (r *todoRepository) pageGroups(db *gorm.DB, query model.TodoQuery, order string) (*gorm.DB, error) {
+	field, ok := model.TodoFilterFields[query.GroupBy]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown group field %q", filter.ErrInvalidFilter, query.GroupBy)
+	}
+	if order != "" {
+		order += ", "
+	}
+	order += "id"
+
+	columns := []string{"*"}
+	if len(query.Columns) > 0 {
+		columns = append([]string{}, query.Columns...)
+	}
+	columns = append(columns, fmt.Sprintf("ROW_NUMBER() OVER (PARTITION BY %s ORDER BY %s) AS group_row", field.Column, order))
+
+	// The inner query already skips deleted todos and may not select
+	// deleted_at.
+	rows := r.db.Unscoped().Table("(?) AS todo", db.Select(columns)).Where("group_row > ?", query.Offset)
+	if query.Limit > 0 {
+		rows = rows.Where("group_row <= ?", query.Offset+query.Limit)
+	}
+	if len(query.Columns) > 0 {
+		rows = rows.Select(query.Columns)
+	}
+	return rows.Order(order), nil
+}
+
+// loadChildren fills the Children of todos, in list order, with one query.
+func (r *todoRepository) loadChildren(todos []*model.Todo, columns []string) error {
+	if len(todos) == 0 {
+		return nil
+	}
+	ids := make([]int, len(todos))
+	byID := make(map[int]*model.Todo, len(todos))
+	for i, todo := range todos {
+		ids[i] = todo.ID
+		byID[todo.ID] = todo
+		todo.Children = []*model.Todo{}
+	}
+
+	db := r.db.Where("parent_id IN ?", ids)
+	if len(columns) > 0 {
+		db = db.Select(append(append([]string{}, columns...), "parent_id"))
+	}
+	var children []*model.Todo
+	if err := db.Order("position, id").Find(&children).Error; err != nil {
+		return err
+	}
+	for _, child := range children {
+		if parent := byID[*child.ParentID]; parent != nil {
+			parent.Children = append(parent.Children, child)
+		}
+	}
+	return nil
+}
+
+// Update writes the editable fields of todo, provided todo.Version still
+// matches the stored row. On success todo.Version is bumped to the new value.
 func (r *todoRepository) Update(todo *model.Todo) error {
-	return r.db.Save(todo).Error
+	err := r.UpdateColumns(uint(todo.ID), todo.Version, map[string]interface{}{
+		"name":        todo.Name,
+		"description": todo.Description,
+		"status":      todo.Status,
+	})
+	if err != nil {
+		return err
+	}
+	todo.Version++
+	return nil
+}
+
+// UpdateColumns writes only the given columns of a todo, leaving every other
+// field untouched. The write only happens if the stored version equals
+// version, and the version is incremented in the same statement. It returns
+// gorm.ErrRecordNotFound when no row matches id and ErrVersionConflict when
+// the row exists with another version.
+func (r *todoRepository) UpdateColumns(id uint, version int, columns map[string]interface{}) error {
+	values := make(map[string]interface{}, len(columns)+1)
+	for column, value := range columns {
+		values[column] = value
+	}
+	values["version"] = gorm.Expr("version + 1")
+
+	result := r.db.Model(&model.Todo{}).Where("id = ? AND version = ?", id, version).Updates(values)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		var count int64
+		if err := r.db.Model(&model.Todo{}).Where("id = ?", id).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return ErrVersionConflict
+	}
+	return nil
 }
 
 func (r *todoRepository) Delete(id uint) error {
 	return r.db.Delete(&model.Todo{}, id).Error
 }
+
+// FindDeleted returns the soft-deleted todos, most recently deleted first.
+func (r *todoRepository) FindDeleted() ([]*model.Todo, error) {
+	var todos []*model.Todo
+	if err := r.db.Unscoped().Where("deleted_at IS NOT NULL").Order("deleted_at DESC").Find(&todos).Error; err != nil {
+		return nil, err
+	}
+	return todos, nil
+}
+
+// FindDeletedByID returns a todo that is in the trash.
+func (r *todoRepository) FindDeletedByID(id uint) (*model.Todo, error) {
+	var todo model.Todo
+	if err := r.db.Unscoped().Where("deleted_at IS NOT NULL").First(&todo, id).Error; err != nil {
+		return nil, err
+	}
+	return &todo, nil
+}
+
+// Restore brings a soft-deleted todo back. It returns gorm.ErrRecordNotFound
+// when id is not in the trash.
+func (r *todoRepository) Restore(id uint) error {
+	result := r.db.Unscoped().Model(&model.Todo{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Updates(map[string]interface{}{
+			"deleted_at": nil,
+			"version":    gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Purge permanently removes a todo that is already in the trash. It returns
+// gorm.ErrRecordNotFound when id is not in the trash.
+func (r *todoRepository) Purge(id uint) error {
+	result := r.db.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).Delete(&model.Todo{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// PurgeDeletedBefore permanently removes every todo soft-deleted before the
+// given time and returns how many rows were removed.
+func (r *todoRepository) PurgeDeletedBefore(before time.Time) (int64, error) {
+	result := r.db.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", before).Delete(&model.Todo{})
+	return result.RowsAffected, result.Error
+}
+
+// Transaction runs fn with a repository bound to a single database
+// transaction. The transaction is committed when fn returns nil and rolled
+// back otherwise.
+func (r *todoRepository) Transaction(fn func(repo TodoRepository) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return fn(&todoRepository{db: tx})
+	})
+}
+
+// FindOccurrence returns an occurrence of a recurring todo series, including
+// deleted ones so a removed occurrence is not generated again.
+func (r *todoRepository) FindOccurrence(series int, occurrence int) (*model.Todo, error) {
+	var todo model.Todo
+	err := r.db.Unscoped().
+		Where("(id = ? OR series_id = ?) AND occurrence = ?", series, series, occurrence).
+		First(&todo).Error
+	if err != nil {
+		return nil, err
+	}
+	return &todo, nil
+}
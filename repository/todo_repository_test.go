@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"database/sql/driver"
+	"testing"
+
+	"todo_project/common/filter"
+	"todo_project/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindByQueryGroupBy(t *testing.T) {
+	t.Run("pages every group in SQL", func(t *testing.T) {
+		db, fake := newFakeDB(t, func(query string, args []driver.Value) (*fakeRows, error) {
+			return &fakeRows{
+				Names: []string{"id", "name", "parent_id", "group_row"},
+				Rows:  [][]driver.Value{{int64(3), "Tag version", int64(1), int64(3)}, {int64(7), "Write notes", int64(2), int64(3)}},
+			}, nil
+		})
+
+		todos, err := (&todoRepository{db: db}).FindByQuery(model.TodoQuery{
+			Filter:  filter.Or{Left: filter.Comparison{Field: "parent", Op: filter.OpEqual, Value: "1"}, Right: filter.Comparison{Field: "parent", Op: filter.OpEqual, Value: "2"}},
+			Sort:    []filter.SortKey{{Field: "position"}},
+			GroupBy: "parent",
+			Limit:   2,
+			Offset:  2,
+		})
+
+		assert.NoError(t, err)
+		assert.Len(t, todos, 2)
+		assert.Equal(t, "Tag version", todos[0].Name)
+		queries := fake.queries("SELECT")
+		assert.Len(t, queries, 1)
+		query := queries[0].Query
+		assert.Contains(t, query, `FROM (SELECT *,ROW_NUMBER() OVER (PARTITION BY parent_id ORDER BY position ASC, id) AS group_row FROM "todo" WHERE`)
+		assert.Contains(t, query, `"todo"."deleted_at" IS NULL) AS todo WHERE group_row > $3 AND group_row <= $4 ORDER BY position ASC, id`)
+		assert.Equal(t, []driver.Value{int64(1), int64(2), int64(2), int64(4)}, queries[0].Args)
+	})
+
+	t.Run("narrowed columns", func(t *testing.T) {
+		db, fake := newFakeDB(t, nil)
+
+		_, err := (&todoRepository{db: db}).FindByQuery(model.TodoQuery{GroupBy: "project", Columns: []string{"id", "name"}})
+
+		assert.NoError(t, err)
+		query := fake.queries("SELECT")[0].Query
+		assert.Contains(t, query, `SELECT "id","name" FROM (SELECT "id","name",ROW_NUMBER() OVER (PARTITION BY project_id ORDER BY id) AS group_row FROM "todo"`)
+		assert.NotContains(t, query, "group_row <=")
+	})
+
+	t.Run("unknown group field", func(t *testing.T) {
+		db, _ := newFakeDB(t, nil)
+
+		_, err := (&todoRepository{db: db}).FindByQuery(model.TodoQuery{GroupBy: "owner"})
+
+		assert.ErrorIs(t, err, filter.ErrInvalidFilter)
+	})
+}
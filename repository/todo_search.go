@@ -0,0 +1,261 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"todo_project/common/filter"
+	"todo_project/model"
+
+	"gorm.io/gorm"
+)
+
+// SearchLanguage is the text search configuration used to build the search
+// index and to parse queries that do not ask for another language.
+var SearchLanguage = "english"
+
+var (
+	ErrEmptySearch     = errors.New("search text has no words")
+	ErrInvalidLanguage = errors.New("invalid search language")
+)
+
+var languagePattern = regexp.MustCompile(`^[a-z_]+$`)
+
+// searchLanguages holds the languages checkLanguage found in pg_ts_config.
+var searchLanguages sync.Map
+
+// Highlights are HTML: the text is escaped and matches are wrapped in <mark>
+// tags. ts_headline marks matches with control characters that are replaced by
+// the tags once the text is escaped.
+const (
+	highlightStart = "<mark>"
+	highlightStop  = "</mark>"
+
+	headlineStart = "\x02"
+	headlineStop  = "\x03"
+)
+
+var headlineMarks = strings.NewReplacer(headlineStart, highlightStart, headlineStop, highlightStop)
+
+// escapeHeadline escapes a ts_headline fragment and turns its marks into tags.
+func escapeHeadline(headline string) string {
+	return headlineMarks.Replace(html.EscapeString(headline))
+}
+
+// searchVector is the weighted document a todo is indexed as: matches in the
+// name rank above matches in the description.
+func searchVector(language string) string {
+	return fmt.Sprintf("setweight(to_tsvector('%[1]s', coalesce(name, '')), 'A') || "+
+		"setweight(to_tsvector('%[1]s', coalesce(description, '')), 'B')", language)
+}
+
+func (r *todoRepository) isPostgres() bool {
+	return r.db.Dialector.Name() == "postgres"
+}
+
+// migrateSearchIndex creates the GIN index backing Search on Postgres.
+func (r *todoRepository) migrateSearchIndex() error {
+	if !r.isPostgres() || !languagePattern.MatchString(SearchLanguage) {
+		return nil
+	}
+	return r.db.Exec(fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_todo_search_%s ON todo USING GIN ((%s))",
+		SearchLanguage, searchVector(SearchLanguage))).Error
+}
+
+func (r *todoRepository) Search(search model.TodoSearch, query model.TodoQuery) ([]*model.TodoSearchResult, int64, error) {
+	terms := searchTerms(search.Text)
+	if len(terms) == 0 {
+		return nil, 0, ErrEmptySearch
+	}
+	if search.Fuzzy {
+		return r.fuzzySearch(strings.TrimSpace(search.Text), fuzzyThreshold(search.Threshold), query)
+	}
+	language := search.Language
+	if language == "" {
+		language = SearchLanguage
+	}
+	if !languagePattern.MatchString(language) {
+		return nil, 0, ErrInvalidLanguage
+	}
+
+	if r.isPostgres() {
+		if err := r.checkLanguage(language); err != nil {
+			return nil, 0, err
+		}
+		return r.searchPostgres(terms, language, query)
+	}
+	return r.searchFallback(terms, query)
+}
+
+func (r *todoRepository) searchPostgres(terms []string, language string, query model.TodoQuery) ([]*model.TodoSearchResult, int64, error) {
+	prefixes := make([]string, len(terms))
+	for i, term := range terms {
+		prefixes[i] = term + ":*"
+	}
+	tsquery := strings.Join(prefixes, " & ")
+	vector := searchVector(language)
+	match := fmt.Sprintf("to_tsquery('%s', ?)", language)
+	headline := fmt.Sprintf("ts_headline('%s', %%s, %s, ?)", language, match)
+	options := "StartSel=" + headlineStart + ", StopSel=" + headlineStop + ", MaxFragments=2, HighlightAll=false"
+
+	db := applyTodoQuery(r.db.Model(&model.Todo{}), query).
+		Where(vector+" @@ "+match, tsquery).
+		Session(&gorm.Session{})
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var results []*model.TodoSearchResult
+	err := db.Select(
+		"todo.*, ts_rank("+vector+", "+match+") AS rank, "+
+			fmt.Sprintf(headline, "name")+" AS name_highlight, "+
+			fmt.Sprintf(headline, "description")+" AS description_highlight",
+		tsquery, tsquery, options, tsquery, options,
+	).Order("rank DESC, id DESC").Scopes(paginate(query)).Scan(&results).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, result := range results {
+		result.NameHighlight = escapeHeadline(result.NameHighlight)
+		result.DescriptionHighlight = escapeHeadline(result.DescriptionHighlight)
+	}
+	return results, total, nil
+}
+
+// checkLanguage returns ErrInvalidLanguage unless language is a text search
+// configuration of the database. Known configurations are remembered.
+func (r *todoRepository) checkLanguage(language string) error {
+	if _, ok := searchLanguages.Load(language); ok {
+		return nil
+	}
+	var count int64
+	if err := r.db.Raw("SELECT count(*) FROM pg_ts_config WHERE cfgname = ?", language).Scan(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrInvalidLanguage
+	}
+	searchLanguages.Store(language, true)
+	return nil
+}
+
+// searchFallback serves databases without full-text search. Rows are matched
+// with LIKE, then ranked and highlighted in memory before paging.
+func (r *todoRepository) searchFallback(terms []string, query model.TodoQuery) ([]*model.TodoSearchResult, int64, error) {
+	db := applyTodoQuery(r.db.Model(&model.Todo{}), query)
+	for _, term := range terms {
+		pattern := "%" + term + "%"
+		db = db.Where("(LOWER(name) LIKE ? OR LOWER(description) LIKE ?)", pattern, pattern)
+	}
+
+	var todos []*model.Todo
+	if err := db.Find(&todos).Error; err != nil {
+		return nil, 0, err
+	}
+
+	results := make([]*model.TodoSearchResult, 0, len(todos))
+	for _, todo := range todos {
+		results = append(results, &model.TodoSearchResult{
+			Todo:                 *todo,
+			Rank:                 fallbackRank(todo, terms),
+			NameHighlight:        highlight(todo.Name, terms),
+			DescriptionHighlight: highlight(todo.Description, terms),
+		})
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Rank != results[j].Rank {
+			return results[i].Rank > results[j].Rank
+		}
+		return results[i].ID > results[j].ID
+	})
+
+	return pageResults(results, query), int64(len(results)), nil
+}
+
+// searchTerms splits text into lower-cased words, dropping anything that is
+// not a letter or a digit so the terms are safe to use in a tsquery.
+func searchTerms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(c rune) bool {
+		return !unicode.IsLetter(c) && !unicode.IsNumber(c)
+	})
+}
+
+func fallbackRank(todo *model.Todo, terms []string) float64 {
+	name, description := strings.ToLower(todo.Name), strings.ToLower(todo.Description)
+	var rank float64
+	for _, term := range terms {
+		rank += float64(strings.Count(name, term)) * 1.0
+		rank += float64(strings.Count(description, term)) * 0.4
+	}
+	return rank
+}
+
+// highlight escapes text as HTML and wraps every word of it starting with
+// one of terms in <mark> tags.
+func highlight(text string, terms []string) string {
+	var b strings.Builder
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		if !unicode.IsLetter(runes[i]) && !unicode.IsNumber(runes[i]) {
+			b.WriteString(html.EscapeString(string(runes[i])))
+			i++
+			continue
+		}
+		j := i
+		for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsNumber(runes[j])) {
+			j++
+		}
+		word := string(runes[i:j])
+		lower := strings.ToLower(word)
+		matched := false
+		for _, term := range terms {
+			if strings.HasPrefix(lower, term) {
+				matched = true
+				break
+			}
+		}
+		if matched {
+			b.WriteString(highlightStart + word + highlightStop)
+		} else {
+			b.WriteString(word)
+		}
+		i = j
+	}
+	return b.String()
+}
+
+func applyTodoQuery(db *gorm.DB, query model.TodoQuery) *gorm.DB {
+	if query.Status != "" {
+		db = db.Where("status = ?", query.Status)
+	}
+	if query.Filter != nil {
+		clause, err := filter.Compile(query.Filter, model.TodoFilterFields, time.Now())
+		if err != nil {
+			_ = db.AddError(err)
+			return db
+		}
+		db = db.Where(clause.SQL, clause.Args...)
+	}
+	return db
+}
+
+func paginate(query model.TodoQuery) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if query.Limit > 0 {
+			db = db.Limit(query.Limit)
+		}
+		if query.Offset > 0 {
+			db = db.Offset(query.Offset)
+		}
+		return db
+	}
+}
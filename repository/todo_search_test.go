@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		terms []string
+		want  string
+	}{
+		{name: "prefix match", text: "Deploy the backend", terms: []string{"dep", "back"}, want: "<mark>Deploy</mark> the <mark>backend</mark>"},
+		{name: "no match", text: "Write docs", terms: []string{"deploy"}, want: "Write docs"},
+		{name: "markup escaped", text: `<img src=x onerror="alert(1)"> deploy`, terms: []string{"deploy"},
+			want: "&lt;img src=x onerror=&#34;alert(1)&#34;&gt; <mark>deploy</mark>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, highlight(tt.text, tt.terms))
+		})
+	}
+}
+
+func TestEscapeHeadline(t *testing.T) {
+	headline := "<script>x</script> " + headlineStart + "deploy" + headlineStop + " & more"
+
+	assert.Equal(t, "&lt;script&gt;x&lt;/script&gt; <mark>deploy</mark> &amp; more", escapeHeadline(headline))
+}
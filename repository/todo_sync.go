@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"errors"
+
+	"todo_project/model"
+
+	"gorm.io/gorm"
+)
+
+// SyncWatermark returns the oldest database transaction that may still be
+// running. Every change written by an older transaction is already visible,
+// so it is a safe starting point for the next delta sync.
+func (r *todoRepository) SyncWatermark() (int64, error) {
+	var xmin int64
+	if err := r.db.Raw("SELECT txid_snapshot_xmin(txid_current_snapshot())").Scan(&xmin).Error; err != nil {
+		return 0, err
+	}
+	return xmin, nil
+}
+
+// ChangedTodoIDs returns the todos changed by transactions in [since, until).
+// At most limit transactions are read; next is where the following page
+// starts and equals until once the range is exhausted.
+func (r *todoRepository) ChangedTodoIDs(since int64, until int64, limit int) ([]int, int64, error) {
+	var txIDs []int64
+	err := r.db.Model(&model.TodoHistory{}).
+		Where("tx_id >= ? AND tx_id < ?", since, until).
+		Distinct("tx_id").Order("tx_id ASC").Limit(limit+1).
+		Pluck("tx_id", &txIDs).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	next := until
+	if len(txIDs) > limit {
+		next = txIDs[limit]
+	}
+
+	var ids []int
+	err = r.db.Model(&model.TodoHistory{}).
+		Where("tx_id >= ? AND tx_id < ?", since, next).
+		Distinct("todo_id").Order("todo_id ASC").
+		Pluck("todo_id", &ids).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return ids, next, nil
+}
+
+// FindByIDsUnscoped returns the todos with the given IDs, soft-deleted ones
+// included. Purged todos are simply missing from the result.
+func (r *todoRepository) FindByIDsUnscoped(ids []int) ([]*model.Todo, error) {
+	var todos []*model.Todo
+	if len(ids) == 0 {
+		return todos, nil
+	}
+	if err := r.db.Unscoped().Where("id IN ?", ids).Order("id ASC").Find(&todos).Error; err != nil {
+		return nil, err
+	}
+	return todos, nil
+}
+
+// FindSyncCreate returns the todo created for the given client ID of actor,
+// or zero when that create was never pushed.
+func (r *todoRepository) FindSyncCreate(actor string, clientID string) (int, error) {
+	var entry model.SyncCreate
+	err := r.db.Where("actor = ? AND client_id = ?", actor, clientID).First(&entry).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return entry.TodoID, nil
+}
+
+// CreateSyncCreate records the todo created for a client ID. It fails when
+// the client ID is taken, so that one of two concurrent pushes of the same
+// create rolls back.
+func (r *todoRepository) CreateSyncCreate(entry *model.SyncCreate) error {
+	return r.db.Create(entry).Error
+}
@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"time"
+
+	"todo_project/model"
+
+	"gorm.io/gorm"
+)
+
+type WebhookRepository interface {
+	Create(webhook *model.Webhook) error
+	FindByID(id uint) (*model.Webhook, error)
+	FindByOwner(owner string) ([]*model.Webhook, error)
+	FindActiveByOwner(owner string) ([]*model.Webhook, error)
+	Update(webhook *model.Webhook) error
+	Delete(id uint) error
+	CreateDelivery(delivery *model.WebhookDelivery) error
+	FindDelivery(id uint) (*model.WebhookDelivery, error)
+	FindDeliveries(webhookID uint, limit int, offset int) ([]*model.WebhookDelivery, error)
+	FindDueDeliveries(before time.Time, staleBefore time.Time, limit int) ([]*model.WebhookDelivery, error)
+	ClaimDelivery(id uint, staleBefore time.Time) (bool, error)
+	SaveDelivery(delivery *model.WebhookDelivery) error
+}
+
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+// Webhooks returns the webhook repository sharing this repository's
+// connection, so deliveries queued inside Transaction only exist if the
+// change that caused them commits.
+func (r *todoRepository) Webhooks() WebhookRepository {
+	return &webhookRepository{db: r.db}
+}
+
+func (r *webhookRepository) Create(webhook *model.Webhook) error {
+	return r.db.Create(webhook).Error
+}
+
+func (r *webhookRepository) FindByID(id uint) (*model.Webhook, error) {
+	var webhook model.Webhook
+	if err := r.db.First(&webhook, id).Error; err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func (r *webhookRepository) FindByOwner(owner string) ([]*model.Webhook, error) {
+	var webhooks []*model.Webhook
+	if err := r.db.Where("owner = ?", owner).Order("id").Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+// FindActiveByOwner returns the active webhooks of owner.
+func (r *webhookRepository) FindActiveByOwner(owner string) ([]*model.Webhook, error) {
+	var webhooks []*model.Webhook
+	if err := r.db.Where("owner = ? AND active = ?", owner, true).Order("id").Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (r *webhookRepository) Update(webhook *model.Webhook) error {
+	result := r.db.Model(webhook).Select("url", "secret", "events", "active").Updates(webhook)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Delete removes a webhook together with its delivery log.
+func (r *webhookRepository) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("webhook_id = ?", id).Delete(&model.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&model.Webhook{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+func (r *webhookRepository) CreateDelivery(delivery *model.WebhookDelivery) error {
+	return r.db.Create(delivery).Error
+}
+
+func (r *webhookRepository) FindDelivery(id uint) (*model.WebhookDelivery, error) {
+	var delivery model.WebhookDelivery
+	if err := r.db.First(&delivery, id).Error; err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// FindDeliveries returns the delivery log of a webhook, newest first.
+func (r *webhookRepository) FindDeliveries(webhookID uint, limit int, offset int) ([]*model.WebhookDelivery, error) {
+	var deliveries []*model.WebhookDelivery
+	err := r.db.Where("webhook_id = ?", webhookID).
+		Order("id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&deliveries).Error
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// FindDueDeliveries returns pending deliveries whose next attempt is at or
+// before before, together with deliveries left in flight since staleBefore.
+func (r *webhookRepository) FindDueDeliveries(before time.Time, staleBefore time.Time, limit int) ([]*model.WebhookDelivery, error) {
+	var deliveries []*model.WebhookDelivery
+	err := r.db.Where("status = ? AND next_attempt_at <= ?", model.DeliveryStatusPending, before).
+		Or("status = ? AND updated_at < ?", model.DeliveryStatusDelivering, staleBefore).
+		Order("next_attempt_at, id").
+		Limit(limit).
+		Find(&deliveries).Error
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// ClaimDelivery marks a delivery as in flight. It reports false when the
+// delivery is no longer pending and not stale since staleBefore.
+func (r *webhookRepository) ClaimDelivery(id uint, staleBefore time.Time) (bool, error) {
+	result := r.db.Model(&model.WebhookDelivery{}).
+		Where("id = ? AND (status = ? OR (status = ? AND updated_at < ?))",
+			id, model.DeliveryStatusPending, model.DeliveryStatusDelivering, staleBefore).
+		Updates(map[string]interface{}{
+			"status":     model.DeliveryStatusDelivering,
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// SaveDelivery writes the outcome of a delivery attempt.
+func (r *webhookRepository) SaveDelivery(delivery *model.WebhookDelivery) error {
+	result := r.db.Model(delivery).
+		Select("status", "next_attempt_at", "attempts", "response_status", "last_error", "delivered_at").
+		Updates(delivery)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
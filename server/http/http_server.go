@@ -42,6 +42,13 @@ func NewEngine() *gin.Engine {
 	return engine
 }
 
+// RegisterOnShutdown registers a function to call when the server starts
+// shutting down, e.g. to end streaming responses that would otherwise keep
+// the shutdown waiting for its timeout.
+func (s *Server) RegisterOnShutdown(f func()) {
+	s.httpServer.RegisterOnShutdown(f)
+}
+
 func (s *Server) Run() error {
 	go func() {
 		logrus.Infof("Service %s listening on %s", serviceName, s.httpServer.Addr)
@@ -0,0 +1,64 @@
+package service
+
+import "sync"
+
+const subscriberBuffer = 64
+
+// broadcaster fans values out to in-process subscribers. A subscriber that
+// falls behind by more than its buffer is dropped and its channel closed.
+// Once closed, every subscriber is dropped and new ones get a closed
+// channel.
+type broadcaster[T any] struct {
+	mu          sync.Mutex
+	subscribers map[chan T]struct{}
+	closed      bool
+}
+
+func newBroadcaster[T any]() *broadcaster[T] {
+	return &broadcaster[T]{subscribers: make(map[chan T]struct{})}
+}
+
+func (b *broadcaster[T]) subscribe() (<-chan T, func()) {
+	ch := make(chan T, subscriberBuffer)
+	b.mu.Lock()
+	if b.closed {
+		close(ch)
+	} else {
+		b.subscribers[ch] = struct{}{}
+	}
+	b.mu.Unlock()
+
+	return ch, func() { b.remove(ch) }
+}
+
+func (b *broadcaster[T]) broadcast(value T) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subscribers {
+		select {
+		case ch <- value:
+		default:
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+func (b *broadcaster[T]) remove(ch chan T) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subscribers[ch]; ok {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+}
+
+func (b *broadcaster[T]) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for ch := range b.subscribers {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+}
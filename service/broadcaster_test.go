@@ -0,0 +1,21 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBroadcasterClose(t *testing.T) {
+	b := newBroadcaster[int]()
+	ch, _ := b.subscribe()
+
+	b.close()
+
+	_, open := <-ch
+	assert.False(t, open)
+	late, _ := b.subscribe()
+	_, open = <-late
+	assert.False(t, open)
+	b.broadcast(1)
+}
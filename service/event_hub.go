@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+
+	"todo_project/common/eventbus"
+	"todo_project/common/log"
+	"todo_project/internal/redis"
+)
+
+// MaxResumeEvents caps the events replayed to a client resuming after the
+// last event it saw.
+var MaxResumeEvents int64 = 1000
+
+// TodoEventSource gives access to the todo event stream: live events as they
+// are published, and past ones to catch up from an offset.
+type TodoEventSource interface {
+	Subscribe() (<-chan eventbus.Message, func())
+	Since(offset string, limit int64) ([]eventbus.Message, error)
+}
+
+// EventHub fans the todo events announced on a Redis channel out to the
+// subscribers of this replica. A subscriber that falls behind by more than
+// its buffer is dropped, its channel closed, and is expected to resume from
+// the last event it saw. Every subscriber is dropped that way when Run
+// returns, so streams end on shutdown.
+type EventHub struct {
+	client      redis.IRedis
+	stream      string
+	channel     string
+	subscribers *broadcaster[eventbus.Message]
+}
+
+func NewEventHub(client redis.IRedis, stream string, channel string) *EventHub {
+	return &EventHub{
+		client:      client,
+		stream:      stream,
+		channel:     channel,
+		subscribers: newBroadcaster[eventbus.Message](),
+	}
+}
+
+// Run listens on the channel until ctx is cancelled.
+func (h *EventHub) Run(ctx context.Context) {
+	defer h.subscribers.close()
+	pubsub := h.client.GetClient().Subscribe(ctx, h.channel)
+	defer pubsub.Close()
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case message, ok := <-messages:
+			if !ok {
+				return
+			}
+			var event eventbus.Message
+			if err := json.Unmarshal([]byte(message.Payload), &event); err != nil {
+				log.Errorf("Failed to decode todo event: %v", err)
+				continue
+			}
+			h.subscribers.broadcast(event)
+		}
+	}
+}
+
+// Subscribe returns a channel receiving every live event and a function to
+// stop receiving them.
+func (h *EventHub) Subscribe() (<-chan eventbus.Message, func()) {
+	return h.subscribers.subscribe()
+}
+
+// Since returns up to limit events published after offset, oldest first.
+func (h *EventHub) Since(offset string, limit int64) ([]eventbus.Message, error) {
+	entries, err := h.client.XRange(h.stream, "("+offset, "+", limit)
+	if err != nil {
+		return nil, err
+	}
+	events := make([]eventbus.Message, 0, len(entries))
+	for _, entry := range entries {
+		if event, err := eventbus.Decode(entry); err == nil {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
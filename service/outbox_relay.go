@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"todo_project/common/eventbus"
+	"todo_project/common/log"
+	"todo_project/internal/redis"
+	"todo_project/model"
+	"todo_project/repository"
+)
+
+const outboxBatchSize = 100
+
+// OutboxLease is how long a relay may take to publish a batch before
+// another relay assumes it stopped and publishes the batch again.
+var OutboxLease = 5 * time.Minute
+
+// OutboxSink is somewhere outbox events are published to. Publish must only
+// return nil once every event was accepted; events may be handed over again
+// after a failure or crash, so sinks see them at least once.
+type OutboxSink interface {
+	Publish(ctx context.Context, events []*model.OutboxEvent) error
+}
+
+// RedisStreamSink appends outbox events to a Redis stream in the entry format
+// read by the eventbus package. When a channel is set, each entry is also
+// announced there with its stream ID for live listeners on every replica.
+type RedisStreamSink struct {
+	client  redis.IRedis
+	stream  string
+	maxLen  int64
+	channel string
+}
+
+func NewRedisStreamSink(client redis.IRedis, stream string, maxLen int64, channel string) *RedisStreamSink {
+	return &RedisStreamSink{client: client, stream: stream, maxLen: maxLen, channel: channel}
+}
+
+func (s *RedisStreamSink) Publish(_ context.Context, events []*model.OutboxEvent) error {
+	for _, event := range events {
+		values := eventbus.Values(event.EventID, event.Type, event.AggregateID, event.Payload)
+		id, err := s.client.XAdd(s.stream, s.maxLen, values)
+		if err != nil {
+			return err
+		}
+		if s.channel == "" {
+			continue
+		}
+		message, err := json.Marshal(eventbus.Message{
+			ID:          id,
+			EventID:     event.EventID,
+			Type:        event.Type,
+			AggregateID: event.AggregateID,
+			Payload:     json.RawMessage(event.Payload),
+		})
+		if err != nil {
+			return err
+		}
+		if err := s.client.Publish(s.channel, string(message)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// OutboxRelay publishes outbox events to its sinks and marks them published.
+// Each relay publishes oldest first, but relays on several replicas publish
+// batches side by side, so consumers must not rely on the order of events
+// across batches: the event ID identifies duplicates and the todo version in
+// the payload orders the changes of a todo. Without sinks events are only
+// marked published. Published events are deleted once they are older than
+// the retention period.
+type OutboxRelay struct {
+	outbox    repository.OutboxRepository
+	sinks     []OutboxSink
+	interval  time.Duration
+	retention time.Duration
+}
+
+func NewOutboxRelay(outbox repository.OutboxRepository, sinks []OutboxSink, interval time.Duration, retention time.Duration) *OutboxRelay {
+	return &OutboxRelay{
+		outbox:    outbox,
+		sinks:     sinks,
+		interval:  interval,
+		retention: retention,
+	}
+}
+
+// Run relays pending events once immediately and then on every interval
+// until ctx is cancelled. Old published events are cleaned up hourly.
+func (r *OutboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	cleanup := time.NewTicker(time.Hour)
+	defer cleanup.Stop()
+
+	for {
+		r.relay(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-cleanup.C:
+			r.cleanup()
+		case <-ticker.C:
+		}
+	}
+}
+
+// relay publishes batches until the outbox is drained or a batch fails.
+func (r *OutboxRelay) relay(ctx context.Context) {
+	for ctx.Err() == nil {
+		count, err := r.outbox.Publish(outboxBatchSize, time.Now().Add(-OutboxLease), func(events []*model.OutboxEvent) error {
+			for _, sink := range r.sinks {
+				if err := sink.Publish(ctx, events); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			log.Errorf("Failed to relay outbox events: %v", err)
+			return
+		}
+		if count < outboxBatchSize {
+			return
+		}
+	}
+}
+
+func (r *OutboxRelay) cleanup() {
+	count, err := r.outbox.DeletePublishedBefore(time.Now().Add(-r.retention))
+	if err != nil {
+		log.Errorf("Failed to clean up the outbox: %v", err)
+		return
+	}
+	if count > 0 {
+		log.Infof("Deleted %d outbox events published more than %s ago", count, r.retention)
+	}
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"todo_project/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeOutbox holds outbox events in memory and publishes them the way the
+// repository does: oldest first, marked published only on success.
+type fakeOutbox struct {
+	mu          sync.Mutex
+	events      []*model.OutboxEvent
+	staleBefore time.Time
+}
+
+func newFakeOutbox(count int) *fakeOutbox {
+	outbox := &fakeOutbox{}
+	for i := 1; i <= count; i++ {
+		_ = outbox.Create(&model.OutboxEvent{EventID: fmt.Sprintf("e%d", i), Type: model.EventTodoCreated})
+	}
+	return outbox
+}
+
+func (o *fakeOutbox) Create(event *model.OutboxEvent) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	event.ID = uint(len(o.events) + 1)
+	o.events = append(o.events, event)
+	return nil
+}
+
+func (o *fakeOutbox) Publish(limit int, staleBefore time.Time, publish func(events []*model.OutboxEvent) error) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.staleBefore = staleBefore
+	var batch []*model.OutboxEvent
+	for _, event := range o.events {
+		if event.PublishedAt == nil && len(batch) < limit {
+			batch = append(batch, event)
+		}
+	}
+	if len(batch) == 0 {
+		return 0, nil
+	}
+	if err := publish(batch); err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	for _, event := range batch {
+		event.PublishedAt = &now
+	}
+	return len(batch), nil
+}
+
+func (o *fakeOutbox) DeletePublishedBefore(before time.Time) (int64, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	var kept []*model.OutboxEvent
+	for _, event := range o.events {
+		if event.PublishedAt == nil || !event.PublishedAt.Before(before) {
+			kept = append(kept, event)
+		}
+	}
+	deleted := int64(len(o.events) - len(kept))
+	o.events = kept
+	return deleted, nil
+}
+
+func (o *fakeOutbox) unpublished() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	count := 0
+	for _, event := range o.events {
+		if event.PublishedAt == nil {
+			count++
+		}
+	}
+	return count
+}
+
+// fakeSink records published event IDs and fails while err is set.
+type fakeSink struct {
+	err       error
+	published []string
+}
+
+func (s *fakeSink) Publish(_ context.Context, events []*model.OutboxEvent) error {
+	if s.err != nil {
+		return s.err
+	}
+	for _, event := range events {
+		s.published = append(s.published, event.EventID)
+	}
+	return nil
+}
+
+func TestOutboxRelay(t *testing.T) {
+	t.Run("drains the outbox in batches", func(t *testing.T) {
+		outbox := newFakeOutbox(outboxBatchSize + 5)
+		sink := &fakeSink{}
+
+		NewOutboxRelay(outbox, []OutboxSink{sink}, time.Second, time.Hour).relay(context.Background())
+
+		assert.Len(t, sink.published, outboxBatchSize+5)
+		assert.Equal(t, "e1", sink.published[0])
+		assert.Zero(t, outbox.unpublished())
+		assert.WithinDuration(t, time.Now().Add(-OutboxLease), outbox.staleBefore, time.Second)
+	})
+
+	t.Run("keeps events when a sink fails", func(t *testing.T) {
+		outbox := newFakeOutbox(3)
+		first, failing := &fakeSink{}, &fakeSink{err: errors.New("redis down")}
+		relay := NewOutboxRelay(outbox, []OutboxSink{first, failing}, time.Second, time.Hour)
+
+		relay.relay(context.Background())
+		assert.Equal(t, 3, outbox.unpublished())
+
+		// Sinks see events at least once: the first one gets them again.
+		failing.err = nil
+		relay.relay(context.Background())
+		assert.Zero(t, outbox.unpublished())
+		assert.Equal(t, []string{"e1", "e2", "e3", "e1", "e2", "e3"}, first.published)
+		assert.Equal(t, []string{"e1", "e2", "e3"}, failing.published)
+	})
+
+	t.Run("marks events published without sinks", func(t *testing.T) {
+		outbox := newFakeOutbox(3)
+
+		NewOutboxRelay(outbox, nil, time.Second, time.Hour).relay(context.Background())
+
+		assert.Zero(t, outbox.unpublished())
+	})
+
+	t.Run("cleans up old published events", func(t *testing.T) {
+		outbox := newFakeOutbox(3)
+		relay := NewOutboxRelay(outbox, nil, time.Second, time.Hour)
+		relay.relay(context.Background())
+		old := time.Now().Add(-2 * time.Hour)
+		outbox.events[0].PublishedAt = &old
+		_ = outbox.Create(&model.OutboxEvent{EventID: "e4"})
+
+		relay.cleanup()
+
+		assert.Len(t, outbox.events, 3)
+		assert.Equal(t, 1, outbox.unpublished())
+	})
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"todo_project/common/log"
+)
+
+// PositionRebalanceJob periodically respaces manually ordered lists whose
+// position keys grew too long.
+type PositionRebalanceJob struct {
+	todoService TodoService
+	interval    time.Duration
+}
+
+func NewPositionRebalanceJob(todoService TodoService, interval time.Duration) *PositionRebalanceJob {
+	return &PositionRebalanceJob{
+		todoService: todoService,
+		interval:    interval,
+	}
+}
+
+// Run rebalances once immediately and then on every interval until ctx is
+// cancelled.
+func (j *PositionRebalanceJob) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		j.rebalance()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (j *PositionRebalanceJob) rebalance() {
+	count, err := j.todoService.RebalancePositions()
+	if err != nil {
+		log.Errorf("Failed to rebalance todo positions: %v", err)
+		return
+	}
+	if count > 0 {
+		log.Infof("Rebalanced positions of %d todo lists", count)
+	}
+}
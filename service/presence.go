@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"todo_project/common/log"
+	"todo_project/internal/redis"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+const (
+	PresenceViewing = "viewing"
+	PresenceEditing = "editing"
+	PresenceLeft    = "left"
+)
+
+var ErrInvalidPresence = errors.New("invalid presence")
+
+// PresenceTTL is how long a presence lasts unless the client reports it
+// again.
+var PresenceTTL = time.Minute
+
+// Presence tells that User is viewing or editing a todo, or has left it.
+type Presence struct {
+	TodoID    int       `json:"todo_id"`
+	ProjectID *int      `json:"project_id,omitempty"`
+	User      string    `json:"user"`
+	State     string    `json:"state"`
+	At        time.Time `json:"at"`
+}
+
+type presenceKey struct {
+	todoID int
+	user   string
+}
+
+// PresenceTracker keeps who is looking at which todo. Updates go through a
+// Redis channel so that every replica tracks the same presences; without
+// Redis they stay local. Presences expire after PresenceTTL and their
+// expiry is announced as the user leaving.
+type PresenceTracker struct {
+	client      redis.IRedis
+	channel     string
+	subscribers *broadcaster[Presence]
+
+	mu        sync.Mutex
+	presences map[presenceKey]Presence
+}
+
+func NewPresenceTracker(client redis.IRedis, channel string) *PresenceTracker {
+	return &PresenceTracker{
+		client:      client,
+		channel:     channel,
+		subscribers: newBroadcaster[Presence](),
+		presences:   make(map[presenceKey]Presence),
+	}
+}
+
+// Update records and announces a presence.
+func (t *PresenceTracker) Update(presence Presence) error {
+	switch presence.State {
+	case PresenceViewing, PresenceEditing, PresenceLeft:
+	default:
+		return ErrInvalidPresence
+	}
+	presence.At = time.Now()
+	if t.client == nil {
+		t.apply(presence)
+		return nil
+	}
+	message, err := json.Marshal(presence)
+	if err != nil {
+		return err
+	}
+	return t.client.Publish(t.channel, string(message))
+}
+
+// Snapshot returns the current presences matching match.
+func (t *PresenceTracker) Snapshot(match func(Presence) bool) []Presence {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var presences []Presence
+	for _, presence := range t.presences {
+		if match(presence) {
+			presences = append(presences, presence)
+		}
+	}
+	return presences
+}
+
+// Subscribe returns a channel receiving every presence change and a function
+// to stop receiving them.
+func (t *PresenceTracker) Subscribe() (<-chan Presence, func()) {
+	return t.subscribers.subscribe()
+}
+
+// Run applies the presences announced by every replica and expires stale
+// ones until ctx is cancelled. Subscribers are dropped when it returns.
+func (t *PresenceTracker) Run(ctx context.Context) {
+	defer t.subscribers.close()
+	expiry := time.NewTicker(PresenceTTL / 4)
+	defer expiry.Stop()
+
+	var messages <-chan *goredis.Message
+	if t.client != nil {
+		pubsub := t.client.GetClient().Subscribe(ctx, t.channel)
+		defer pubsub.Close()
+		messages = pubsub.Channel()
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-expiry.C:
+			t.expire(time.Now())
+		case message, ok := <-messages:
+			if !ok {
+				return
+			}
+			var presence Presence
+			if err := json.Unmarshal([]byte(message.Payload), &presence); err != nil {
+				log.Errorf("Failed to decode presence: %v", err)
+				continue
+			}
+			t.apply(presence)
+		}
+	}
+}
+
+func (t *PresenceTracker) apply(presence Presence) {
+	key := presenceKey{todoID: presence.TodoID, user: presence.User}
+	t.mu.Lock()
+	if presence.State == PresenceLeft {
+		delete(t.presences, key)
+	} else {
+		t.presences[key] = presence
+	}
+	t.mu.Unlock()
+	t.subscribers.broadcast(presence)
+}
+
+func (t *PresenceTracker) expire(now time.Time) {
+	var expired []Presence
+	t.mu.Lock()
+	for key, presence := range t.presences {
+		if now.Sub(presence.At) > PresenceTTL {
+			delete(t.presences, key)
+			presence.State = PresenceLeft
+			presence.At = now
+			expired = append(expired, presence)
+		}
+	}
+	t.mu.Unlock()
+	for _, presence := range expired {
+		t.subscribers.broadcast(presence)
+	}
+}
+
+// PresenceSource is what real-time clients use to share and follow
+// presences.
+type PresenceSource interface {
+	Update(presence Presence) error
+	Snapshot(match func(Presence) bool) []Presence
+	Subscribe() (<-chan Presence, func())
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"todo_project/common/log"
+	"todo_project/internal/redis"
+	"todo_project/model"
+)
+
+// ReminderQueueKey is the Redis sorted set holding the IDs of reminders
+// about to fire, scored by the Unix time of their next attempt.
+const ReminderQueueKey = "reminder_queue"
+
+const reminderBatchSize = 100
+
+// ReminderQueue is the Redis sorted set reminders about to fire wait in. A
+// nil queue, used when Redis is disabled, ignores every call.
+type ReminderQueue struct {
+	client redis.IRedis
+}
+
+func NewReminderQueue(client redis.IRedis) *ReminderQueue {
+	if client == nil {
+		return nil
+	}
+	return &ReminderQueue{client: client}
+}
+
+// Push queues reminder for its next attempt, moving it there if it was
+// already queued.
+func (q *ReminderQueue) Push(reminder *model.Reminder) error {
+	if q == nil {
+		return nil
+	}
+	return q.client.ZAdd(ReminderQueueKey, float64(reminder.NextAttemptAt.Unix()), reminderMember(reminder.ID))
+}
+
+// Requeue takes a rescheduled reminder out of the queue and puts it back at
+// its new time if it is still pending. It is best effort: the queue is
+// rebuilt from the database every tick, and a reminder popped before its
+// time is refused by the claim, so a failure here only delays the fix.
+func (q *ReminderQueue) Requeue(reminder *model.Reminder) {
+	if q == nil {
+		return
+	}
+	if _, err := q.client.ZRem(ReminderQueueKey, reminderMember(reminder.ID)); err != nil {
+		log.Errorf("Failed to requeue reminder %d: %v", reminder.ID, err)
+		return
+	}
+	if reminder.Status != model.ReminderStatusPending {
+		return
+	}
+	if err := q.Push(reminder); err != nil {
+		log.Errorf("Failed to requeue reminder %d: %v", reminder.ID, err)
+	}
+}
+
+// Pop removes and returns up to limit reminders due by now. Popping is a
+// ZREM, so each entry is returned to one caller only.
+func (q *ReminderQueue) Pop(now time.Time, limit int64) ([]uint, error) {
+	if q == nil {
+		return nil, nil
+	}
+	members, err := q.client.ZRangeByScore(ReminderQueueKey, float64(now.Unix()), limit)
+	if err != nil {
+		return nil, err
+	}
+	var ids []uint
+	for _, member := range members {
+		removed, err := q.client.ZRem(ReminderQueueKey, member)
+		if err != nil {
+			return nil, err
+		}
+		if removed == 0 {
+			continue // popped by another replica
+		}
+		id, err := strconv.ParseUint(member, 10, 64)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, uint(id))
+	}
+	return ids, nil
+}
+
+func reminderMember(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+// ReminderScheduler delivers reminders as they come due. Every replica runs
+// one: each tick it queues the reminders due within the next interval in a
+// ReminderQueue and pops those whose time has come, so only the replica that
+// popped an entry delivers it. The claim on the reminder row guards against
+// double delivery when an entry is queued again while in flight, and against
+// early delivery of an entry whose reminder was rescheduled.
+//
+// Without Redis each replica works from the database alone and the row claim
+// is what keeps deliveries unique.
+type ReminderScheduler struct {
+	reminderService ReminderService
+	queue           *ReminderQueue
+	interval        time.Duration
+}
+
+func NewReminderScheduler(reminderService ReminderService, redisClient redis.IRedis, interval time.Duration) *ReminderScheduler {
+	return &ReminderScheduler{
+		reminderService: reminderService,
+		queue:           NewReminderQueue(redisClient),
+		interval:        interval,
+	}
+}
+
+// Run delivers due reminders once immediately and then on every interval
+// until ctx is cancelled.
+func (s *ReminderScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.tick(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *ReminderScheduler) tick(ctx context.Context) {
+	now := time.Now()
+	ids, err := s.due(now)
+	if err != nil {
+		log.Errorf("Failed to load due reminders: %v", err)
+		return
+	}
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := s.reminderService.DeliverReminder(ctx, id); err != nil {
+			log.Errorf("Failed to deliver reminder %d: %v", id, err)
+		}
+	}
+}
+
+// due returns the reminders this replica should deliver now.
+func (s *ReminderScheduler) due(now time.Time) ([]uint, error) {
+	upcoming, err := s.reminderService.DueReminders(now.Add(s.interval), reminderBatchSize)
+	if err != nil {
+		return nil, err
+	}
+	if s.queue == nil {
+		var ids []uint
+		for _, reminder := range upcoming {
+			if !reminder.NextAttemptAt.After(now) {
+				ids = append(ids, reminder.ID)
+			}
+		}
+		return ids, nil
+	}
+
+	for _, reminder := range upcoming {
+		if err := s.queue.Push(reminder); err != nil {
+			return nil, err
+		}
+	}
+	return s.queue.Pop(now, reminderBatchSize)
+}
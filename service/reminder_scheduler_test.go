@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"todo_project/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeReminderService returns a fixed set of reminders as due and records
+// what it is asked to deliver.
+type fakeReminderService struct {
+	ReminderService
+	upcoming  []*model.Reminder
+	mu        sync.Mutex
+	delivered []uint
+}
+
+func (s *fakeReminderService) DueReminders(before time.Time, limit int) ([]*model.Reminder, error) {
+	var due []*model.Reminder
+	for _, reminder := range s.upcoming {
+		if !reminder.NextAttemptAt.After(before) {
+			due = append(due, reminder)
+		}
+	}
+	return due, nil
+}
+
+func (s *fakeReminderService) DeliverReminder(_ context.Context, id uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.delivered = append(s.delivered, id)
+	return nil
+}
+
+func (s *fakeReminderService) deliveredIDs() []uint {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := append([]uint(nil), s.delivered...)
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func TestReminderScheduler(t *testing.T) {
+	now := time.Now()
+	upcoming := []*model.Reminder{
+		{ID: 1, NextAttemptAt: now.Add(-time.Minute)},
+		{ID: 2, NextAttemptAt: now.Add(-time.Second)},
+		{ID: 3, NextAttemptAt: now.Add(30 * time.Second)},
+		{ID: 4, NextAttemptAt: now.Add(time.Hour)},
+	}
+
+	t.Run("without redis", func(t *testing.T) {
+		reminders := &fakeReminderService{upcoming: upcoming}
+
+		NewReminderScheduler(reminders, nil, time.Minute).tick(context.Background())
+
+		assert.Equal(t, []uint{1, 2}, reminders.deliveredIDs())
+	})
+
+	t.Run("queues upcoming reminders and pops due ones", func(t *testing.T) {
+		zset := newFakeZSet()
+		reminders := &fakeReminderService{upcoming: upcoming}
+
+		NewReminderScheduler(reminders, zset, time.Minute).tick(context.Background())
+
+		assert.Equal(t, []uint{1, 2}, reminders.deliveredIDs())
+		assert.Equal(t, map[string]float64{"3": float64(upcoming[2].NextAttemptAt.Unix())}, zset.scores)
+	})
+
+	t.Run("replicas share the queue", func(t *testing.T) {
+		zset := newFakeZSet()
+		reminders := &fakeReminderService{upcoming: upcoming}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				NewReminderScheduler(reminders, zset, time.Minute).tick(context.Background())
+			}()
+		}
+		wg.Wait()
+
+		// Replicas that queue an entry again after it was popped deliver it
+		// too; the claim on the row drops those repeats.
+		ids := reminders.deliveredIDs()
+		assert.Subset(t, []uint{1, 2}, ids)
+		assert.Subset(t, ids, []uint{1, 2})
+		assert.NotContains(t, ids, uint(3))
+	})
+
+	t.Run("stops when cancelled", func(t *testing.T) {
+		reminders := &fakeReminderService{upcoming: upcoming}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		NewReminderScheduler(reminders, newFakeZSet(), time.Minute).tick(ctx)
+
+		assert.Empty(t, reminders.deliveredIDs())
+	})
+}
+
+func TestReminderQueuePop(t *testing.T) {
+	zset := newFakeZSet()
+	queue := NewReminderQueue(zset)
+	now := time.Now()
+	assert.NoError(t, queue.Push(&model.Reminder{ID: 1, NextAttemptAt: now.Add(-time.Minute)}))
+	assert.NoError(t, queue.Push(&model.Reminder{ID: 2, NextAttemptAt: now.Add(time.Minute)}))
+
+	ids, err := queue.Pop(now, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint{1}, ids)
+
+	ids, err = queue.Pop(now, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, ids)
+
+	var disabled *ReminderQueue
+	ids, err = disabled.Pop(now, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, ids)
+}
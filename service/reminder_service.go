@@ -0,0 +1,273 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"todo_project/common/actor"
+	"todo_project/internal/notifier"
+	"todo_project/model"
+	"todo_project/repository"
+
+	"gorm.io/gorm"
+)
+
+type ReminderService interface {
+	CreateReminder(ctx context.Context, reminder *model.Reminder) error
+	GetReminders(ctx context.Context, todoID uint) ([]*model.Reminder, error)
+	CancelReminder(ctx context.Context, id uint) error
+	DueReminders(before time.Time, limit int) ([]*model.Reminder, error)
+	DeliverReminder(ctx context.Context, id uint) error
+}
+
+var ErrInvalidReminder = errors.New("invalid reminder")
+
+// ErrReminderNotPending is returned when cancelling a reminder that is being
+// or has been delivered.
+var ErrReminderNotPending = errors.New("reminder is no longer pending")
+
+var (
+	// ReminderMaxAttempts is how many deliveries are tried before a reminder
+	// is marked failed.
+	ReminderMaxAttempts = 5
+	// ReminderRetryBackoff is the wait after the first failed delivery; it
+	// doubles with every further attempt.
+	ReminderRetryBackoff = 30 * time.Second
+	// ReminderLease is how long a reminder may stay firing before another
+	// scheduler assumes the delivery was abandoned and takes it over.
+	ReminderLease = 5 * time.Minute
+)
+
+type reminderService struct {
+	repo      repository.TodoRepository
+	notifiers notifier.Registry
+}
+
+func NewReminderService(repo repository.TodoRepository, notifiers notifier.Registry) ReminderService {
+	return &reminderService{repo: repo, notifiers: notifiers}
+}
+
+// CreateReminder schedules a reminder on a todo. Exactly one of RemindAt and
+// Offset must be set, and relative reminders need the todo to have a due
+// date.
+func (s *reminderService) CreateReminder(ctx context.Context, reminder *model.Reminder) error {
+	if (reminder.RemindAt == nil) == (reminder.Offset == nil) {
+		return fmt.Errorf("%w: set either remind_at or offset", ErrInvalidReminder)
+	}
+	n, ok := s.notifiers[reminder.Channel]
+	if !ok {
+		return fmt.Errorf("%w: unknown channel %q", ErrInvalidReminder, reminder.Channel)
+	}
+	if err := n.Validate(reminder.Target); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidReminder, err)
+	}
+	todo, err := s.repo.FindByID(uint(reminder.TodoID))
+	if err != nil {
+		return err
+	}
+	fireAt, ok := reminderTime(reminder, todo)
+	if !ok {
+		return fmt.Errorf("%w: a relative reminder needs the todo to have a due_at", ErrInvalidReminder)
+	}
+
+	reminder.ID = 0
+	reminder.Owner = actor.FromContext(ctx)
+	reminder.FireAt = fireAt
+	reminder.NextAttemptAt = fireAt
+	reminder.Status = model.ReminderStatusPending
+	reminder.Attempts = 0
+	reminder.LastError = ""
+	reminder.DeliveredAt = nil
+	return s.repo.Reminders().Create(reminder)
+}
+
+// GetReminders lists the reminders the caller set on a todo. Reminders of
+// other users are left out.
+func (s *reminderService) GetReminders(ctx context.Context, todoID uint) ([]*model.Reminder, error) {
+	if _, err := s.repo.FindByID(todoID); err != nil {
+		return nil, err
+	}
+	reminders, err := s.repo.Reminders().FindByTodoID(int(todoID))
+	if err != nil {
+		return nil, err
+	}
+	owner := actor.FromContext(ctx)
+	own := make([]*model.Reminder, 0, len(reminders))
+	for _, reminder := range reminders {
+		if reminder.Owner == owner {
+			own = append(own, reminder)
+		}
+	}
+	return own, nil
+}
+
+// CancelReminder stops a pending reminder of the caller. Reminders of other
+// users are reported as not found, and reminders a delivery has claimed can
+// no longer be cancelled.
+func (s *reminderService) CancelReminder(ctx context.Context, id uint) error {
+	reminder, err := s.repo.Reminders().FindByID(id)
+	if err != nil {
+		return err
+	}
+	if reminder.Owner != actor.FromContext(ctx) {
+		return gorm.ErrRecordNotFound
+	}
+	if reminder.Status == model.ReminderStatusCancelled {
+		return nil
+	}
+	cancelled, err := s.repo.Reminders().Cancel(id)
+	if err != nil {
+		return err
+	}
+	if !cancelled {
+		return fmt.Errorf("%w: reminder is being or has been delivered", ErrReminderNotPending)
+	}
+	return nil
+}
+
+// DueReminders returns the reminders to deliver by before, including those
+// whose delivery was abandoned mid-way.
+func (s *reminderService) DueReminders(before time.Time, limit int) ([]*model.Reminder, error) {
+	return s.repo.Reminders().FindDue(before, time.Now().Add(-ReminderLease), limit)
+}
+
+// DeliverReminder claims a due reminder and sends it. It does nothing when
+// the reminder was claimed elsewhere or is not due yet. Failed deliveries are retried with
+// exponential backoff until ReminderMaxAttempts; the outcome is recorded on
+// the reminder either way.
+func (s *reminderService) DeliverReminder(ctx context.Context, id uint) error {
+	reminders := s.repo.Reminders()
+	start := time.Now()
+	claimed, err := reminders.Claim(id, start, start.Add(-ReminderLease))
+	if err != nil || !claimed {
+		return err
+	}
+	reminder, err := reminders.FindByID(id)
+	if err != nil {
+		return err
+	}
+
+	todo, err := s.repo.FindByID(uint(reminder.TodoID))
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && todo.Status == model.TodoStatusDone) {
+		// The todo was deleted or finished while the reminder was waiting.
+		reminder.Status = model.ReminderStatusCancelled
+		return reminders.Save(reminder)
+	}
+	if err != nil {
+		// The claim is left to go stale so the delivery is tried again
+		// after ReminderLease.
+		return err
+	}
+
+	err = s.notify(ctx, reminder, todo)
+	now := time.Now()
+	reminder.Attempts++
+	switch {
+	case err == nil:
+		reminder.Status = model.ReminderStatusDelivered
+		reminder.DeliveredAt = &now
+		reminder.LastError = ""
+	case reminder.Attempts >= ReminderMaxAttempts:
+		reminder.Status = model.ReminderStatusFailed
+		reminder.LastError = err.Error()
+	default:
+		reminder.Status = model.ReminderStatusPending
+		reminder.NextAttemptAt = now.Add(ReminderRetryBackoff << (reminder.Attempts - 1))
+		reminder.LastError = err.Error()
+	}
+	return reminders.Save(reminder)
+}
+
+func (s *reminderService) notify(ctx context.Context, reminder *model.Reminder, todo *model.Todo) error {
+	n, ok := s.notifiers[reminder.Channel]
+	if !ok {
+		return fmt.Errorf("channel %q is not configured", reminder.Channel)
+	}
+	return n.Notify(ctx, notifier.Notification{
+		ReminderID: reminder.ID,
+		TodoID:     todo.ID,
+		TodoName:   todo.Name,
+		DueAt:      todo.DueAt,
+		FireAt:     reminder.FireAt,
+		Target:     reminder.Target,
+	})
+}
+
+// reminderTime resolves when a reminder fires. It reports false for a
+// relative reminder on a todo without a due date.
+func reminderTime(reminder *model.Reminder, todo *model.Todo) (time.Time, bool) {
+	if !reminder.Relative() {
+		return *reminder.RemindAt, true
+	}
+	if todo.DueAt == nil {
+		return time.Time{}, false
+	}
+	return todo.DueAt.Add(time.Duration(*reminder.Offset) * time.Minute), true
+}
+
+// rescheduleReminders moves the relative reminders of a todo along with its
+// due date, in the database and in queue. Reminders that can no longer fire
+// because the due date was cleared are cancelled.
+func rescheduleReminders(repo repository.TodoRepository, queue *ReminderQueue, todo *model.Todo) error {
+	reminders, err := repo.Reminders().FindByTodoID(todo.ID)
+	if err != nil {
+		return err
+	}
+	for _, reminder := range reminders {
+		if !reminder.Relative() || reminder.Status != model.ReminderStatusPending {
+			continue
+		}
+		fireAt, ok := reminderTime(reminder, todo)
+		if !ok {
+			reminder.Status = model.ReminderStatusCancelled
+		} else {
+			reminder.FireAt = fireAt
+			reminder.NextAttemptAt = fireAt
+			reminder.Attempts = 0
+			reminder.LastError = ""
+		}
+		if err := repo.Reminders().Save(reminder); err != nil {
+			return err
+		}
+		queue.Requeue(reminder)
+	}
+	return nil
+}
+
+func sameTime(a *time.Time, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// copyReminders gives the next occurrence of a recurring todo the relative
+// reminders of the one just completed.
+func copyReminders(repo repository.TodoRepository, from *model.Todo, to *model.Todo) error {
+	reminders, err := repo.Reminders().FindByTodoID(from.ID)
+	if err != nil {
+		return err
+	}
+	for _, reminder := range reminders {
+		if !reminder.Relative() {
+			continue
+		}
+		fireAt, _ := reminderTime(reminder, to)
+		err := repo.Reminders().Create(&model.Reminder{
+			TodoID:        to.ID,
+			Owner:         reminder.Owner,
+			Offset:        reminder.Offset,
+			FireAt:        fireAt,
+			Channel:       reminder.Channel,
+			Target:        reminder.Target,
+			Status:        model.ReminderStatusPending,
+			NextAttemptAt: fireAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
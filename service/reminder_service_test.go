@@ -0,0 +1,369 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"todo_project/common/actor"
+	"todo_project/internal/notifier"
+	"todo_project/internal/redis"
+	"todo_project/model"
+	"todo_project/repository"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// Fake repository holding todos and reminders in memory; only the methods
+// the reminder service calls are implemented. Claim follows the SQL of the
+// real one.
+type fakeReminderRepo struct {
+	repository.TodoRepository
+	todos     map[int]*model.Todo
+	reminders map[uint]*model.Reminder
+	todoErr   error
+}
+
+func newFakeReminderRepo(todos ...*model.Todo) *fakeReminderRepo {
+	repo := &fakeReminderRepo{todos: map[int]*model.Todo{}, reminders: map[uint]*model.Reminder{}}
+	for _, todo := range todos {
+		repo.todos[todo.ID] = todo
+	}
+	return repo
+}
+
+func (r *fakeReminderRepo) FindByID(id uint) (*model.Todo, error) {
+	if r.todoErr != nil {
+		return nil, r.todoErr
+	}
+	todo, ok := r.todos[int(id)]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *todo
+	return &copied, nil
+}
+
+func (r *fakeReminderRepo) Reminders() repository.ReminderRepository {
+	return fakeReminders{r}
+}
+
+func (r *fakeReminderRepo) add(reminder *model.Reminder) *model.Reminder {
+	reminder.ID = uint(len(r.reminders) + 1)
+	reminder.UpdatedAt = time.Now()
+	r.reminders[reminder.ID] = reminder
+	return reminder
+}
+
+type fakeReminders struct {
+	repo *fakeReminderRepo
+}
+
+func (f fakeReminders) FindByID(id uint) (*model.Reminder, error) {
+	reminder, ok := f.repo.reminders[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *reminder
+	return &copied, nil
+}
+
+func (f fakeReminders) FindByTodoID(todoID int) ([]*model.Reminder, error) {
+	var reminders []*model.Reminder
+	for _, reminder := range f.repo.reminders {
+		if reminder.TodoID == todoID {
+			copied := *reminder
+			reminders = append(reminders, &copied)
+		}
+	}
+	sort.Slice(reminders, func(i, j int) bool { return reminders[i].ID < reminders[j].ID })
+	return reminders, nil
+}
+
+func (f fakeReminders) Claim(id uint, now time.Time, staleBefore time.Time) (bool, error) {
+	reminder, ok := f.repo.reminders[id]
+	if !ok {
+		return false, nil
+	}
+	due := reminder.Status == model.ReminderStatusPending && !reminder.NextAttemptAt.After(now)
+	stale := reminder.Status == model.ReminderStatusFiring && reminder.UpdatedAt.Before(staleBefore)
+	if !due && !stale {
+		return false, nil
+	}
+	reminder.Status = model.ReminderStatusFiring
+	reminder.UpdatedAt = time.Now()
+	return true, nil
+}
+
+func (f fakeReminders) Cancel(id uint) (bool, error) {
+	reminder, ok := f.repo.reminders[id]
+	if !ok || reminder.Status != model.ReminderStatusPending {
+		return false, nil
+	}
+	reminder.Status = model.ReminderStatusCancelled
+	return true, nil
+}
+
+func (f fakeReminders) Save(reminder *model.Reminder) error {
+	if _, ok := f.repo.reminders[reminder.ID]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	copied := *reminder
+	f.repo.reminders[reminder.ID] = &copied
+	return nil
+}
+
+func (f fakeReminders) Create(reminder *model.Reminder) error {
+	f.repo.add(reminder)
+	return nil
+}
+
+func (f fakeReminders) FindDue(before time.Time, staleBefore time.Time, limit int) ([]*model.Reminder, error) {
+	return nil, errors.New("not implemented")
+}
+
+// fakeNotifier fails with err and counts its calls.
+type fakeNotifier struct {
+	err   error
+	calls int
+}
+
+func (n *fakeNotifier) Validate(target string) error {
+	return nil
+}
+
+func (n *fakeNotifier) Notify(_ context.Context, _ notifier.Notification) error {
+	n.calls++
+	return n.err
+}
+
+// Fake Redis holding sorted sets in memory; only the methods the reminder
+// queue calls are implemented.
+type fakeZSet struct {
+	redis.IRedis
+	mu     sync.Mutex
+	scores map[string]float64
+}
+
+func newFakeZSet() *fakeZSet {
+	return &fakeZSet{scores: map[string]float64{}}
+}
+
+func (f *fakeZSet) ZAdd(key string, score float64, member string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.scores[member] = score
+	return nil
+}
+
+func (f *fakeZSet) ZRangeByScore(key string, max float64, limit int64) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var members []string
+	for member, score := range f.scores {
+		if score <= max {
+			members = append(members, member)
+		}
+	}
+	sort.Slice(members, func(i, j int) bool { return f.scores[members[i]] < f.scores[members[j]] })
+	if int64(len(members)) > limit {
+		members = members[:limit]
+	}
+	return members, nil
+}
+
+func (f *fakeZSet) ZRem(key string, member string) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.scores[member]; !ok {
+		return 0, nil
+	}
+	delete(f.scores, member)
+	return 1, nil
+}
+
+func TestReminderOwner(t *testing.T) {
+	alice := actor.NewContext(context.Background(), "alice")
+	bob := actor.NewContext(context.Background(), "bob")
+	setup := func() (*fakeReminderRepo, ReminderService) {
+		repo := newFakeReminderRepo(&model.Todo{ID: 1})
+		repo.add(&model.Reminder{TodoID: 1, Owner: "alice", Channel: "test", Target: "alice@example.com", Status: model.ReminderStatusPending})
+		repo.add(&model.Reminder{TodoID: 1, Owner: "bob", Channel: "test", Target: "bob@example.com", Status: model.ReminderStatusPending})
+		return repo, NewReminderService(repo, notifier.Registry{})
+	}
+
+	t.Run("lists only the caller's reminders", func(t *testing.T) {
+		_, s := setup()
+
+		reminders, err := s.GetReminders(alice, 1)
+
+		assert.NoError(t, err)
+		assert.Len(t, reminders, 1)
+		assert.Equal(t, "alice@example.com", reminders[0].Target)
+	})
+
+	t.Run("cancels the caller's reminder", func(t *testing.T) {
+		repo, s := setup()
+
+		assert.NoError(t, s.CancelReminder(alice, 1))
+		assert.Equal(t, model.ReminderStatusCancelled, repo.reminders[1].Status)
+		// Cancelling again is a no-op.
+		assert.NoError(t, s.CancelReminder(alice, 1))
+	})
+
+	t.Run("reminders of other users are not found", func(t *testing.T) {
+		repo, s := setup()
+
+		assert.ErrorIs(t, s.CancelReminder(bob, 1), gorm.ErrRecordNotFound)
+		assert.Equal(t, model.ReminderStatusPending, repo.reminders[1].Status)
+	})
+
+	t.Run("reminders being delivered are kept", func(t *testing.T) {
+		repo, s := setup()
+		repo.reminders[1].Status = model.ReminderStatusFiring
+
+		assert.ErrorIs(t, s.CancelReminder(alice, 1), ErrReminderNotPending)
+		assert.Equal(t, model.ReminderStatusFiring, repo.reminders[1].Status)
+	})
+}
+
+func TestDeliverReminder(t *testing.T) {
+	due := time.Now().Add(time.Hour)
+	pending := func() *model.Reminder {
+		return &model.Reminder{TodoID: 1, Channel: "test", Status: model.ReminderStatusPending, NextAttemptAt: time.Now().Add(-time.Minute)}
+	}
+
+	t.Run("delivers", func(t *testing.T) {
+		repo := newFakeReminderRepo(&model.Todo{ID: 1, DueAt: &due})
+		reminder := repo.add(pending())
+		n := &fakeNotifier{}
+		s := NewReminderService(repo, notifier.Registry{"test": n})
+
+		assert.NoError(t, s.DeliverReminder(context.Background(), reminder.ID))
+		assert.Equal(t, 1, n.calls)
+		assert.Equal(t, model.ReminderStatusDelivered, repo.reminders[reminder.ID].Status)
+		assert.NotNil(t, repo.reminders[reminder.ID].DeliveredAt)
+	})
+
+	t.Run("not due yet", func(t *testing.T) {
+		repo := newFakeReminderRepo(&model.Todo{ID: 1, DueAt: &due})
+		reminder := pending()
+		reminder.NextAttemptAt = time.Now().Add(time.Minute)
+		repo.add(reminder)
+		n := &fakeNotifier{}
+		s := NewReminderService(repo, notifier.Registry{"test": n})
+
+		assert.NoError(t, s.DeliverReminder(context.Background(), reminder.ID))
+		assert.Equal(t, 0, n.calls)
+		assert.Equal(t, model.ReminderStatusPending, repo.reminders[reminder.ID].Status)
+	})
+
+	t.Run("cancelled when the todo is gone", func(t *testing.T) {
+		repo := newFakeReminderRepo()
+		reminder := repo.add(pending())
+		n := &fakeNotifier{}
+		s := NewReminderService(repo, notifier.Registry{"test": n})
+
+		assert.NoError(t, s.DeliverReminder(context.Background(), reminder.ID))
+		assert.Equal(t, 0, n.calls)
+		assert.Equal(t, model.ReminderStatusCancelled, repo.reminders[reminder.ID].Status)
+	})
+
+	t.Run("cancelled when the todo is done", func(t *testing.T) {
+		repo := newFakeReminderRepo(&model.Todo{ID: 1, Status: model.TodoStatusDone})
+		reminder := repo.add(pending())
+		n := &fakeNotifier{}
+		s := NewReminderService(repo, notifier.Registry{"test": n})
+
+		assert.NoError(t, s.DeliverReminder(context.Background(), reminder.ID))
+		assert.Equal(t, 0, n.calls)
+		assert.Equal(t, model.ReminderStatusCancelled, repo.reminders[reminder.ID].Status)
+	})
+
+	t.Run("kept when the todo cannot be loaded", func(t *testing.T) {
+		repo := newFakeReminderRepo(&model.Todo{ID: 1, DueAt: &due})
+		repo.todoErr = errors.New("connection reset")
+		reminder := repo.add(pending())
+		n := &fakeNotifier{}
+		s := NewReminderService(repo, notifier.Registry{"test": n})
+
+		assert.EqualError(t, s.DeliverReminder(context.Background(), reminder.ID), "connection reset")
+		assert.Equal(t, 0, n.calls)
+		assert.Equal(t, model.ReminderStatusFiring, repo.reminders[reminder.ID].Status)
+
+		// Once the claim goes stale the delivery is taken over.
+		repo.todoErr = nil
+		repo.reminders[reminder.ID].UpdatedAt = time.Now().Add(-2 * ReminderLease)
+		assert.NoError(t, s.DeliverReminder(context.Background(), reminder.ID))
+		assert.Equal(t, 1, n.calls)
+		assert.Equal(t, model.ReminderStatusDelivered, repo.reminders[reminder.ID].Status)
+	})
+}
+
+func TestDeliverReminderBackoff(t *testing.T) {
+	repo := newFakeReminderRepo(&model.Todo{ID: 1})
+	reminder := repo.add(&model.Reminder{TodoID: 1, Channel: "test", Status: model.ReminderStatusPending, NextAttemptAt: time.Now()})
+	n := &fakeNotifier{err: errors.New("unreachable")}
+	s := NewReminderService(repo, notifier.Registry{"test": n})
+
+	for attempt := 1; attempt < ReminderMaxAttempts; attempt++ {
+		start := time.Now()
+		assert.NoError(t, s.DeliverReminder(context.Background(), reminder.ID))
+
+		saved := repo.reminders[reminder.ID]
+		assert.Equal(t, model.ReminderStatusPending, saved.Status)
+		assert.Equal(t, attempt, saved.Attempts)
+		assert.Equal(t, "unreachable", saved.LastError)
+		wait := ReminderRetryBackoff << (attempt - 1)
+		assert.WithinDuration(t, start.Add(wait), saved.NextAttemptAt, time.Second)
+
+		// Retrying before the backoff has passed does nothing.
+		assert.NoError(t, s.DeliverReminder(context.Background(), reminder.ID))
+		assert.Equal(t, attempt, n.calls)
+
+		saved.NextAttemptAt = time.Now()
+	}
+
+	assert.NoError(t, s.DeliverReminder(context.Background(), reminder.ID))
+	assert.Equal(t, ReminderMaxAttempts, n.calls)
+	assert.Equal(t, model.ReminderStatusFailed, repo.reminders[reminder.ID].Status)
+}
+
+func TestRescheduleReminders(t *testing.T) {
+	offset := -30
+	due := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	repo := newFakeReminderRepo()
+	relative := repo.add(&model.Reminder{TodoID: 1, Offset: &offset, Status: model.ReminderStatusPending, NextAttemptAt: due.Add(-30 * time.Minute)})
+	absolute := repo.add(&model.Reminder{TodoID: 1, RemindAt: &due, Status: model.ReminderStatusPending, NextAttemptAt: due})
+	zset := newFakeZSet()
+	queue := NewReminderQueue(zset)
+	assert.NoError(t, queue.Push(relative))
+	assert.NoError(t, queue.Push(absolute))
+
+	t.Run("moves queued reminders", func(t *testing.T) {
+		later := due.Add(24 * time.Hour)
+
+		assert.NoError(t, rescheduleReminders(repo, queue, &model.Todo{ID: 1, DueAt: &later}))
+
+		moved := later.Add(-30 * time.Minute)
+		assert.True(t, moved.Equal(repo.reminders[relative.ID].NextAttemptAt))
+		assert.Equal(t, float64(moved.Unix()), zset.scores[reminderMember(relative.ID)])
+		assert.Equal(t, float64(due.Unix()), zset.scores[reminderMember(absolute.ID)])
+	})
+
+	t.Run("drops cancelled reminders", func(t *testing.T) {
+		assert.NoError(t, rescheduleReminders(repo, queue, &model.Todo{ID: 1}))
+
+		assert.Equal(t, model.ReminderStatusCancelled, repo.reminders[relative.ID].Status)
+		assert.NotContains(t, zset.scores, reminderMember(relative.ID))
+		assert.Contains(t, zset.scores, reminderMember(absolute.ID))
+	})
+
+	t.Run("without a queue", func(t *testing.T) {
+		assert.NoError(t, rescheduleReminders(repo, nil, &model.Todo{ID: 1, DueAt: &due}))
+	})
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"todo_project/common/actor"
+	"todo_project/common/filter"
+	"todo_project/model"
+	"todo_project/repository"
+
+	"gorm.io/gorm"
+)
+
+type SavedViewService interface {
+	CreateView(ctx context.Context, view *model.SavedView) error
+	GetView(ctx context.Context, id uint) (*model.SavedView, error)
+	ListViews(ctx context.Context) ([]*model.SavedView, error)
+	UpdateView(ctx context.Context, view *model.SavedView) error
+	DeleteView(ctx context.Context, id uint) error
+	GetViewTodos(ctx context.Context, id uint, limit int, offset int) ([]*model.Todo, error)
+}
+
+var (
+	ErrInvalidView = errors.New("invalid view")
+	// ErrViewReadOnly is returned when someone other than the owner tries to
+	// change a shared view.
+	ErrViewReadOnly = errors.New("view is read-only")
+	// ErrViewOwnerUnverified is returned when a view would be owned by a user
+	// that was named by the caller rather than authenticated.
+	ErrViewOwnerUnverified = errors.New("view owner is not authenticated")
+)
+
+type savedViewService struct {
+	repo        repository.SavedViewRepository
+	todoService TodoService
+}
+
+func NewSavedViewService(repo repository.SavedViewRepository, todoService TodoService) SavedViewService {
+	return &savedViewService{repo: repo, todoService: todoService}
+}
+
+func (s *savedViewService) CreateView(ctx context.Context, view *model.SavedView) error {
+	if err := validateView(view); err != nil {
+		return err
+	}
+	owner, err := viewOwner(ctx)
+	if err != nil {
+		return err
+	}
+	view.ID = 0
+	view.Owner = owner
+	return s.repo.Create(view)
+}
+
+// GetView returns a view owned by the caller or shared with them. Private
+// views of other users are reported as not found.
+func (s *savedViewService) GetView(ctx context.Context, id uint) (*model.SavedView, error) {
+	view, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !view.Shared && !ownedBy(ctx, view) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return view, nil
+}
+
+// ListViews returns the views of the caller and the shared ones; callers
+// that are not authenticated only see the shared views.
+func (s *savedViewService) ListViews(ctx context.Context) ([]*model.SavedView, error) {
+	owner, err := viewOwner(ctx)
+	if err != nil {
+		views, err := s.repo.FindVisibleTo("")
+		if err != nil {
+			return nil, err
+		}
+		shared := views[:0]
+		for _, view := range views {
+			if view.Shared {
+				shared = append(shared, view)
+			}
+		}
+		return shared, nil
+	}
+	return s.repo.FindVisibleTo(owner)
+}
+
+func (s *savedViewService) UpdateView(ctx context.Context, view *model.SavedView) error {
+	if err := validateView(view); err != nil {
+		return err
+	}
+	existing, err := s.ownedView(ctx, view.ID)
+	if err != nil {
+		return err
+	}
+	view.Owner = existing.Owner
+	view.CreatedAt = existing.CreatedAt
+	return s.repo.Update(view)
+}
+
+func (s *savedViewService) DeleteView(ctx context.Context, id uint) error {
+	if _, err := s.ownedView(ctx, id); err != nil {
+		return err
+	}
+	return s.repo.Delete(id)
+}
+
+// GetViewTodos runs the stored filter and sort of a view.
+func (s *savedViewService) GetViewTodos(ctx context.Context, id uint, limit int, offset int) ([]*model.Todo, error) {
+	view, err := s.GetView(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	query, err := viewQuery(view)
+	if err != nil {
+		return nil, err
+	}
+	query.Limit = limit
+	query.Offset = offset
+	return s.todoService.ListTodos(query)
+}
+
+// ownedView returns the view if the caller owns it.
+func (s *savedViewService) ownedView(ctx context.Context, id uint) (*model.SavedView, error) {
+	if _, err := viewOwner(ctx); err != nil {
+		return nil, err
+	}
+	view, err := s.GetView(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !ownedBy(ctx, view) {
+		return nil, ErrViewReadOnly
+	}
+	return view, nil
+}
+
+// viewOwner returns the caller as a view owner. Only callers authenticated by
+// an API key of their own may own views, since any user can be named with the
+// shared key.
+func viewOwner(ctx context.Context) (string, error) {
+	if !actor.Verified(ctx) {
+		return "", ErrViewOwnerUnverified
+	}
+	return actor.FromContext(ctx), nil
+}
+
+func ownedBy(ctx context.Context, view *model.SavedView) bool {
+	owner, err := viewOwner(ctx)
+	return err == nil && view.Owner == owner
+}
+
+func validateView(view *model.SavedView) error {
+	view.Name = strings.TrimSpace(view.Name)
+	if view.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidView)
+	}
+	if _, err := viewQuery(view); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidView, err)
+	}
+	return nil
+}
+
+// viewQuery parses the stored filter and sort of a view and checks them
+// against the todo fields.
+func viewQuery(view *model.SavedView) (model.TodoQuery, error) {
+	var query model.TodoQuery
+	if strings.TrimSpace(view.Filter) != "" {
+		expr, err := filter.Parse(view.Filter)
+		if err != nil {
+			return query, err
+		}
+		if err := filter.Validate(expr, model.TodoFilterFields); err != nil {
+			return query, err
+		}
+		query.Filter = expr
+	}
+	keys, err := filter.ParseSort(view.Sort)
+	if err != nil {
+		return query, err
+	}
+	if _, err := filter.CompileSort(keys, model.TodoFilterFields); err != nil {
+		return query, err
+	}
+	query.Sort = keys
+	return query, nil
+}
@@ -0,0 +1,329 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"todo_project/common/actor"
+	"todo_project/internal/redis"
+	"todo_project/model"
+	"todo_project/repository"
+
+	"gorm.io/gorm"
+)
+
+// SyncService implements delta sync for offline-first clients: pulling every
+// change since a change token and pushing a batch of offline mutations.
+type SyncService interface {
+	Changes(ctx context.Context, since string) (*SyncChanges, error)
+	Push(ctx context.Context, mutations []SyncMutation) []SyncResult
+}
+
+const (
+	SyncOpUpsert = "upsert"
+	SyncOpDelete = "delete"
+
+	// SyncApplied means the mutation was applied as sent.
+	SyncApplied = "applied"
+	// SyncMerged means the fields that did not clash with a server change
+	// were applied and the others, listed in Conflicts, were dropped.
+	SyncMerged = "merged"
+	// SyncConflict means nothing was applied; Todo holds the server state.
+	SyncConflict = "conflict"
+	SyncError    = "error"
+)
+
+// ErrInvalidSyncToken is returned for change tokens this server never issued.
+var ErrInvalidSyncToken = errors.New("invalid sync token")
+
+// SyncPageSize caps how many transactions a single pull reads.
+var SyncPageSize = 500
+
+// syncTrackedFields are the fields whose changes the todo history records.
+// Any other field is assumed to clash with every server change.
+var syncTrackedFields = map[string]bool{
+	"name":        true,
+	"description": true,
+	"status":      true,
+	"due_at":      true,
+	"recurrence":  true,
+	"timezone":    true,
+}
+
+// SyncChanges is one page of changes. Token is passed as since to get the
+// next page, or the changes that happen afterwards once HasMore is false.
+type SyncChanges struct {
+	Token   string
+	HasMore bool
+	Changes []SyncChange
+}
+
+// SyncChange is the latest state of a changed todo. Deletes carry the
+// soft-deleted todo, or no todo at all when it was purged.
+type SyncChange struct {
+	Op   string
+	ID   int
+	Todo *model.Todo
+}
+
+// SyncMutation is a change a client made while offline. BaseVersion is the
+// version the client edited; zero means it has no copy and always wins.
+// Creates carrying a ClientID are applied once: pushing one again returns the
+// todo created the first time.
+type SyncMutation struct {
+	ClientID    string
+	Op          string
+	ID          uint
+	BaseVersion int
+	Todo        *model.Todo
+	Changes     map[string]interface{}
+}
+
+type SyncResult struct {
+	ClientID  string
+	Op        string
+	ID        uint
+	Status    string
+	Todo      *model.Todo
+	Conflicts []string
+	Err       error
+}
+
+type syncService struct {
+	repo      repository.TodoRepository
+	reminders *ReminderQueue
+	cache     redis.IRedis
+}
+
+func NewSyncService(repo repository.TodoRepository, redisClient redis.IRedis) SyncService {
+	return &syncService{repo: repo, reminders: NewReminderQueue(redisClient), cache: redisClient}
+}
+
+// Changes returns the todos changed since the given token. An empty token
+// starts a full sync with every live todo.
+func (s *syncService) Changes(ctx context.Context, since string) (*SyncChanges, error) {
+	// The watermark is read first so nothing committed after the data
+	// below is read can fall behind the returned token.
+	watermark, err := s.repo.SyncWatermark()
+	if err != nil {
+		return nil, err
+	}
+
+	if since == "" {
+		todos, err := s.repo.FindAll()
+		if err != nil {
+			return nil, err
+		}
+		changes := make([]SyncChange, 0, len(todos))
+		for _, todo := range todos {
+			changes = append(changes, SyncChange{Op: SyncOpUpsert, ID: todo.ID, Todo: todo})
+		}
+		return &SyncChanges{Token: formatSyncToken(watermark), Changes: changes}, nil
+	}
+
+	from, err := parseSyncToken(since)
+	if err != nil {
+		return nil, err
+	}
+	if from >= watermark {
+		return &SyncChanges{Token: since, Changes: []SyncChange{}}, nil
+	}
+
+	ids, next, err := s.repo.ChangedTodoIDs(from, watermark, SyncPageSize)
+	if err != nil {
+		return nil, err
+	}
+	todos, err := s.repo.FindByIDsUnscoped(ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int]*model.Todo, len(todos))
+	for _, todo := range todos {
+		byID[todo.ID] = todo
+	}
+
+	changes := make([]SyncChange, 0, len(ids))
+	for _, id := range ids {
+		todo := byID[id]
+		if todo == nil || todo.DeletedAt.Valid {
+			changes = append(changes, SyncChange{Op: SyncOpDelete, ID: id, Todo: todo})
+			continue
+		}
+		changes = append(changes, SyncChange{Op: SyncOpUpsert, ID: id, Todo: todo})
+	}
+	return &SyncChanges{Token: formatSyncToken(next), HasMore: next < watermark, Changes: changes}, nil
+}
+
+// Push applies mutations in order, each in its own transaction, and reports
+// how every one of them was resolved. Concurrent edits are settled the same
+// way every time: server changes win field by field, edits win over deletes
+// and deleting an already deleted todo succeeds.
+func (s *syncService) Push(ctx context.Context, mutations []SyncMutation) []SyncResult {
+	by := actor.FromContext(ctx)
+	results := make([]SyncResult, len(mutations))
+	for i, mutation := range mutations {
+		var result SyncResult
+		err := s.repo.Transaction(func(repo repository.TodoRepository) error {
+			var err error
+			result, err = applySyncMutation(repo, s.reminders, s.cache, by, mutation)
+			return err
+		})
+		if err != nil {
+			result = SyncResult{Status: SyncError, Err: err}
+		}
+		result.ClientID = mutation.ClientID
+		result.Op = mutation.Op
+		if result.ID == 0 {
+			result.ID = mutation.ID
+		}
+		results[i] = result
+	}
+	return results
+}
+
+func applySyncMutation(repo repository.TodoRepository, queue *ReminderQueue, cache redis.IRedis, by string, mutation SyncMutation) (SyncResult, error) {
+	switch mutation.Op {
+	case BulkOpCreate:
+		if mutation.Todo == nil {
+			return SyncResult{}, fmt.Errorf("%w: create requires a todo", ErrInvalidTodo)
+		}
+		if mutation.ClientID != "" {
+			id, err := repo.FindSyncCreate(by, mutation.ClientID)
+			if err != nil {
+				return SyncResult{}, err
+			}
+			if id != 0 {
+				return replayedSyncCreate(repo, id)
+			}
+		}
+		todo := *mutation.Todo
+		todo.ID = 0
+		if err := createTodo(repo, cache, by, &todo); err != nil {
+			return SyncResult{}, err
+		}
+		if mutation.ClientID != "" {
+			err := repo.CreateSyncCreate(&model.SyncCreate{Actor: by, ClientID: mutation.ClientID, TodoID: todo.ID})
+			if err != nil {
+				return SyncResult{}, err
+			}
+		}
+		return SyncResult{ID: uint(todo.ID), Status: SyncApplied, Todo: &todo}, nil
+	case BulkOpUpdate:
+		current, err := repo.FindByID(mutation.ID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Editing a todo deleted on the server: the delete stands and
+			// the client gets the tombstone back.
+			deleted, derr := repo.FindDeletedByID(mutation.ID)
+			if derr != nil {
+				return SyncResult{}, err
+			}
+			return SyncResult{Status: SyncConflict, Todo: deleted}, nil
+		}
+		if err != nil {
+			return SyncResult{}, err
+		}
+
+		changes, conflicts := mutation.Changes, []string(nil)
+		if mutation.BaseVersion != 0 && mutation.BaseVersion != current.Version {
+			changes, conflicts, err = resolveSyncConflicts(repo, current, mutation.BaseVersion, mutation.Changes)
+			if err != nil {
+				return SyncResult{}, err
+			}
+			if len(changes) == 0 {
+				return SyncResult{Status: SyncConflict, Todo: current, Conflicts: conflicts}, nil
+			}
+		}
+		todo, err := patchTodo(repo, queue, cache, by, mutation.ID, current.Version, changes)
+		if err != nil {
+			return SyncResult{}, err
+		}
+		if len(conflicts) > 0 {
+			return SyncResult{Status: SyncMerged, Todo: todo, Conflicts: conflicts}, nil
+		}
+		return SyncResult{Status: SyncApplied, Todo: todo}, nil
+	case BulkOpDelete:
+		current, err := repo.FindByID(mutation.ID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			deleted, derr := repo.FindDeletedByID(mutation.ID)
+			if derr != nil {
+				return SyncResult{}, err
+			}
+			return SyncResult{Status: SyncApplied, Todo: deleted}, nil
+		}
+		if err != nil {
+			return SyncResult{}, err
+		}
+		if mutation.BaseVersion != 0 && mutation.BaseVersion != current.Version {
+			return SyncResult{Status: SyncConflict, Todo: current}, nil
+		}
+		if err := deleteTodo(repo, by, mutation.ID); err != nil {
+			return SyncResult{}, err
+		}
+		deleted, err := repo.FindDeletedByID(mutation.ID)
+		if err != nil {
+			return SyncResult{}, err
+		}
+		return SyncResult{Status: SyncApplied, Todo: deleted}, nil
+	default:
+		return SyncResult{}, fmt.Errorf("%w: unknown operation %q", ErrInvalidTodo, mutation.Op)
+	}
+}
+
+// replayedSyncCreate answers a create pushed again with the todo created the
+// first time, as it is now. The todo is missing when it was purged since.
+func replayedSyncCreate(repo repository.TodoRepository, id int) (SyncResult, error) {
+	todos, err := repo.FindByIDsUnscoped([]int{id})
+	if err != nil {
+		return SyncResult{}, err
+	}
+	result := SyncResult{ID: uint(id), Status: SyncApplied}
+	if len(todos) > 0 {
+		result.Todo = todos[0]
+	}
+	return result, nil
+}
+
+// resolveSyncConflicts splits changes made against base into the ones that
+// can still be applied and the fields the server changed since base.
+func resolveSyncConflicts(repo repository.TodoRepository, current *model.Todo, base int, changes map[string]interface{}) (map[string]interface{}, []string, error) {
+	entries, err := repo.History().FindByTodoID(uint(current.ID))
+	if err != nil {
+		return nil, nil, err
+	}
+	changed := map[string]bool{}
+	for _, entry := range entries {
+		if entry.Version <= base {
+			continue
+		}
+		for field := range entry.Changes {
+			changed[field] = true
+		}
+	}
+
+	keep := map[string]interface{}{}
+	var conflicts []string
+	for field, value := range changes {
+		if changed[field] || !syncTrackedFields[field] {
+			conflicts = append(conflicts, field)
+			continue
+		}
+		keep[field] = value
+	}
+	sort.Strings(conflicts)
+	return keep, conflicts, nil
+}
+
+func formatSyncToken(txID int64) string {
+	return strconv.FormatInt(txID, 10)
+}
+
+func parseSyncToken(token string) (int64, error) {
+	txID, err := strconv.ParseInt(token, 10, 64)
+	if err != nil || txID < 0 {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidSyncToken, token)
+	}
+	return txID, nil
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"todo_project/common/actor"
+	"todo_project/model"
+	"todo_project/repository"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// Fake repository creating and changing todos in memory on top of
+// fakePositionRepo; only the methods sync and revert call are implemented.
+type fakeSyncRepo struct {
+	*fakePositionRepo
+	creates map[string]int
+	outbox  *fakeOutbox
+}
+
+func newFakeSyncRepo() *fakeSyncRepo {
+	return &fakeSyncRepo{fakePositionRepo: newFakePositionRepo(), creates: map[string]int{}, outbox: &fakeOutbox{}}
+}
+
+func (r *fakeSyncRepo) Transaction(fn func(repo repository.TodoRepository) error) error {
+	return fn(r)
+}
+
+func (r *fakeSyncRepo) Create(todo *model.Todo) error {
+	todo.ID = len(r.todos) + 1
+	todo.Version = 1
+	copied := *todo
+	r.todos[todo.ID] = &copied
+	return nil
+}
+
+func (r *fakeSyncRepo) UpdateColumns(id uint, version int, columns map[string]interface{}) error {
+	todo, ok := r.todos[int(id)]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	if todo.Version != version {
+		return repository.ErrVersionConflict
+	}
+	for column, value := range columns {
+		switch column {
+		case "name":
+			todo.Name = value.(string)
+		case "description":
+			todo.Description = value.(string)
+		case "status":
+			todo.Status = value.(string)
+		case "due_at":
+			switch due := value.(type) {
+			case time.Time:
+				todo.DueAt = &due
+			case *time.Time:
+				todo.DueAt = due
+			default:
+				todo.DueAt = nil
+			}
+		case "recurrence":
+			todo.Recurrence = value.(string)
+		case "timezone":
+			todo.Timezone = value.(string)
+		case "position":
+			todo.Position = value.(string)
+		case "project_id":
+			todo.ProjectID = value.(*int)
+		case "parent_id":
+			todo.ParentID = value.(*int)
+		}
+	}
+	todo.Version++
+	return nil
+}
+
+func (r *fakeSyncRepo) Reminders() repository.ReminderRepository {
+	return fakeReminders{newFakeReminderRepo()}
+}
+
+func (r *fakeSyncRepo) FindByIDsUnscoped(ids []int) ([]*model.Todo, error) {
+	var todos []*model.Todo
+	for _, id := range ids {
+		if todo, err := r.FindByID(uint(id)); err == nil {
+			todos = append(todos, todo)
+		}
+	}
+	return todos, nil
+}
+
+func (r *fakeSyncRepo) FindSyncCreate(actor string, clientID string) (int, error) {
+	return r.creates[actor+"/"+clientID], nil
+}
+
+func (r *fakeSyncRepo) CreateSyncCreate(entry *model.SyncCreate) error {
+	r.creates[entry.Actor+"/"+entry.ClientID] = entry.TodoID
+	return nil
+}
+
+func (r *fakeSyncRepo) Outbox() repository.OutboxRepository {
+	return r.outbox
+}
+
+func (r *fakeSyncRepo) Webhooks() repository.WebhookRepository {
+	return fakeWebhooks{repo: newFakeWebhookRepo()}
+}
+
+func TestSyncPushCreate(t *testing.T) {
+	repo := newFakeSyncRepo()
+	s := NewSyncService(repo, nil)
+	alice := actor.NewContext(context.Background(), "alice")
+	create := SyncMutation{ClientID: "c-1", Op: BulkOpCreate, Todo: &model.Todo{Name: "Offline todo"}}
+
+	first := s.Push(alice, []SyncMutation{create})[0]
+	assert.NoError(t, first.Err)
+	assert.Equal(t, SyncApplied, first.Status)
+	assert.Equal(t, uint(1), first.ID)
+
+	t.Run("replay returns the created todo", func(t *testing.T) {
+		result := s.Push(alice, []SyncMutation{create})[0]
+
+		assert.NoError(t, result.Err)
+		assert.Equal(t, SyncApplied, result.Status)
+		assert.Equal(t, uint(1), result.ID)
+		assert.Equal(t, "Offline todo", result.Todo.Name)
+		assert.Len(t, repo.todos, 1)
+	})
+
+	t.Run("client IDs are scoped to the actor", func(t *testing.T) {
+		bob := actor.NewContext(context.Background(), "bob")
+
+		result := s.Push(bob, []SyncMutation{create})[0]
+
+		assert.NoError(t, result.Err)
+		assert.Equal(t, uint(2), result.ID)
+	})
+
+	t.Run("creates without a client ID are not deduplicated", func(t *testing.T) {
+		anonymous := SyncMutation{Op: BulkOpCreate, Todo: &model.Todo{Name: "Offline todo"}}
+
+		results := s.Push(alice, []SyncMutation{anonymous, anonymous})
+
+		assert.NotEqual(t, results[0].ID, results[1].ID)
+	})
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"todo_project/model"
+	"todo_project/repository"
+)
+
+// WIPLimits caps how many top-level todos of a project may be in each status
+// column at once. Statuses without a positive limit are unlimited.
+var WIPLimits = map[string]int{}
+
+// ErrWIPLimitReached is returned when a change would put more todos in a
+// board column than its WIP limit allows.
+var ErrWIPLimitReached = errors.New("WIP limit reached")
+
+// boardStatuses are the board columns, left to right.
+var boardStatuses = []string{model.TodoStatusTodo, model.TodoStatusDoing, model.TodoStatusDone}
+
+// Board is the Kanban board of a project: its top-level todos grouped into
+// one column per status, each in manual order.
+type Board struct {
+	ProjectID int
+	Columns   []BoardColumn
+}
+
+type BoardColumn struct {
+	Status   string
+	WIPLimit int
+	Cards    []*model.Todo
+}
+
+// GetBoard returns the board of a project. Projects have no todos of their
+// own, so an unknown project yields a board with empty columns.
+func (s *todoService) GetBoard(projectID int) (*Board, error) {
+	todos, err := s.repo.FindByList(model.TodoList{ProjectID: &projectID})
+	if err != nil {
+		return nil, err
+	}
+
+	board := &Board{ProjectID: projectID, Columns: make([]BoardColumn, len(boardStatuses))}
+	columns := make(map[string]*BoardColumn, len(boardStatuses))
+	for i, status := range boardStatuses {
+		board.Columns[i] = BoardColumn{Status: status, WIPLimit: WIPLimits[status], Cards: []*model.Todo{}}
+		columns[status] = &board.Columns[i]
+	}
+	for _, todo := range todos {
+		if column, ok := columns[todo.Status]; ok {
+			column.Cards = append(column.Cards, todo)
+		}
+	}
+	return board, nil
+}
+
+// checkWIPLimit fails with ErrWIPLimitReached when todo, just written into
+// its status column, overflows the column's WIP limit. It runs inside the
+// transaction of the write so the change is rolled back. Only top-level todos
+// of a project are on a board.
+//
+// The board is locked before counting and stays locked until the transaction
+// ends: a concurrent change waits, then counts in a new statement that sees
+// this one committed, so two changes cannot both take the last free card.
+func checkWIPLimit(repo repository.TodoRepository, todo *model.Todo) error {
+	status := todo.Status
+	if status == "" {
+		// New todos without a status get the column default.
+		status = model.TodoStatusDoing
+	}
+	limit := WIPLimits[status]
+	if limit <= 0 || todo.ProjectID == nil || todo.ParentID != nil {
+		return nil
+	}
+	if err := repo.LockBoard(*todo.ProjectID); err != nil {
+		return err
+	}
+	count, err := repo.CountByStatus(todo.List(), status)
+	if err != nil {
+		return err
+	}
+	if count > int64(limit) {
+		return fmt.Errorf("%w: column %q of project %d holds at most %d todos", ErrWIPLimitReached, status, *todo.ProjectID, limit)
+	}
+	return nil
+}
+
+// entersColumn reports whether a change from before to after moves the todo
+// into another board column.
+func entersColumn(before *model.Todo, after *model.Todo) bool {
+	return before.Status != after.Status || !sameID(before.ProjectID, after.ProjectID) || !sameID(before.ParentID, after.ParentID)
+}
+
+func sameID(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
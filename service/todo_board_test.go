@@ -0,0 +1,161 @@
+package service
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"todo_project/model"
+	"todo_project/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Fake repository with READ COMMITTED visibility: a transaction sees the
+// committed statuses and its own writes, which are committed when it ends.
+// LockBoard holds a lock per project until then. Only the methods
+// checkWIPLimit calls are implemented.
+type fakeBoardRepo struct {
+	repository.TodoRepository
+	store *boardStore
+	// Set inside a transaction.
+	pending map[int]string
+	locked  []*sync.Mutex
+}
+
+type boardStore struct {
+	mu       sync.Mutex
+	todos    map[int]model.Todo
+	boards   map[int]*sync.Mutex
+	boardsMu sync.Mutex
+}
+
+func (r *fakeBoardRepo) Transaction(fn func(repo repository.TodoRepository) error) error {
+	tx := &fakeBoardRepo{store: r.store, pending: map[int]string{}}
+	err := fn(tx)
+	if err == nil {
+		// Committing takes a while, leaving concurrent transactions time to
+		// count before the writes become visible.
+		time.Sleep(time.Millisecond)
+		r.store.mu.Lock()
+		for id, status := range tx.pending {
+			todo := r.store.todos[id]
+			todo.Status = status
+			r.store.todos[id] = todo
+		}
+		r.store.mu.Unlock()
+	}
+	for _, lock := range tx.locked {
+		lock.Unlock()
+	}
+	return err
+}
+
+func (r *fakeBoardRepo) setStatus(id int, status string) {
+	r.pending[id] = status
+}
+
+func (r *fakeBoardRepo) LockBoard(projectID int) error {
+	r.store.boardsMu.Lock()
+	lock, ok := r.store.boards[projectID]
+	if !ok {
+		lock = &sync.Mutex{}
+		r.store.boards[projectID] = lock
+	}
+	r.store.boardsMu.Unlock()
+	lock.Lock()
+	r.locked = append(r.locked, lock)
+	return nil
+}
+
+func (r *fakeBoardRepo) CountByStatus(list model.TodoList, status string) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	var count int64
+	for id, todo := range r.store.todos {
+		if pending, ok := r.pending[id]; ok {
+			todo.Status = pending
+		}
+		if sameID(todo.ProjectID, list.ProjectID) && sameID(todo.ParentID, list.ParentID) && todo.Status == status {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func TestCheckWIPLimitConcurrentTransitions(t *testing.T) {
+	WIPLimits[model.TodoStatusDoing] = 2
+	defer delete(WIPLimits, model.TodoStatusDoing)
+
+	const transitions = 8
+	project := intPtr(1)
+	store := &boardStore{todos: map[int]model.Todo{}, boards: map[int]*sync.Mutex{}}
+	for id := 1; id <= transitions; id++ {
+		store.todos[id] = model.Todo{ID: id, ProjectID: project, Status: model.TodoStatusTodo}
+	}
+	repo := &fakeBoardRepo{store: store}
+
+	// Every transition writes its status before any of them checks the limit.
+	var written sync.WaitGroup
+	written.Add(transitions)
+	errs := make(chan error, transitions)
+	for id := 1; id <= transitions; id++ {
+		go func(id int) {
+			errs <- repo.Transaction(func(tx repository.TodoRepository) error {
+				tx.(*fakeBoardRepo).setStatus(id, model.TodoStatusDoing)
+				written.Done()
+				written.Wait()
+				return checkWIPLimit(tx, &model.Todo{ID: id, ProjectID: project, Status: model.TodoStatusDoing})
+			})
+		}(id)
+	}
+
+	succeeded := 0
+	for i := 0; i < transitions; i++ {
+		err := <-errs
+		if err == nil {
+			succeeded++
+		} else {
+			assert.True(t, errors.Is(err, ErrWIPLimitReached), err)
+		}
+	}
+	assert.Equal(t, 2, succeeded)
+	count, _ := (&fakeBoardRepo{store: store}).CountByStatus(model.TodoList{ProjectID: project}, model.TodoStatusDoing)
+	assert.EqualValues(t, 2, count)
+}
+
+func TestCheckWIPLimit(t *testing.T) {
+	WIPLimits[model.TodoStatusDoing] = 1
+	defer delete(WIPLimits, model.TodoStatusDoing)
+
+	store := &boardStore{todos: map[int]model.Todo{
+		1: {ID: 1, ProjectID: intPtr(1), Status: model.TodoStatusDoing},
+		2: {ID: 2, ProjectID: intPtr(1), Status: model.TodoStatusDoing},
+	}, boards: map[int]*sync.Mutex{}}
+	repo := &fakeBoardRepo{store: store}
+
+	tests := []struct {
+		name string
+		todo *model.Todo
+		err  error
+	}{
+		{name: "over the limit", todo: &model.Todo{ID: 2, ProjectID: intPtr(1), Status: model.TodoStatusDoing}, err: ErrWIPLimitReached},
+		{name: "unlimited column", todo: &model.Todo{ID: 2, ProjectID: intPtr(1), Status: model.TodoStatusDone}},
+		{name: "subtask", todo: &model.Todo{ID: 2, ProjectID: intPtr(1), ParentID: intPtr(1), Status: model.TodoStatusDoing}},
+		{name: "no project", todo: &model.Todo{ID: 2, Status: model.TodoStatusDoing}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := repo.Transaction(func(tx repository.TodoRepository) error {
+				return checkWIPLimit(tx, tt.todo)
+			})
+
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"todo_project/common/actor"
+	"todo_project/internal/redis"
+	"todo_project/model"
+	"todo_project/repository"
+)
+
+const (
+	BulkOpCreate    = "create"
+	BulkOpUpdate    = "update"
+	BulkOpDelete    = "delete"
+	BulkOpSetStatus = "set_status"
+)
+
+var (
+	// ErrBulkRolledBack marks operations of an atomic batch that succeeded but
+	// were undone because a later operation failed.
+	ErrBulkRolledBack = errors.New("rolled back because another operation failed")
+	// ErrBulkSkipped marks operations of an atomic batch that were never run.
+	ErrBulkSkipped = errors.New("not executed because another operation failed")
+)
+
+// BulkOperation is a single step of a bulk request. Todo is used by create,
+// ID and Changes by update and set_status, ID alone by delete. A zero Version
+// skips the optimistic concurrency check against the caller's copy.
+type BulkOperation struct {
+	Op      string
+	ID      uint
+	Version int
+	Todo    *model.Todo
+	Changes map[string]interface{}
+}
+
+type BulkResult struct {
+	Op   string
+	ID   uint
+	Todo *model.Todo
+	Err  error
+}
+
+// BulkApply runs ops in order. In atomic mode all of them share one
+// transaction and a single failure rolls everything back; otherwise every
+// operation is applied on its own and the results report each outcome.
+func (s *todoService) BulkApply(ctx context.Context, ops []BulkOperation, atomic bool) []BulkResult {
+	by := actor.FromContext(ctx)
+	results := make([]BulkResult, len(ops))
+	for i, op := range ops {
+		results[i] = BulkResult{Op: op.Op, ID: op.ID}
+	}
+
+	if !atomic {
+		for i, op := range ops {
+			var todo *model.Todo
+			results[i].Err = s.repo.Transaction(func(repo repository.TodoRepository) error {
+				var err error
+				todo, err = applyBulkOperation(repo, s.reminders, s.cache, by, op)
+				return err
+			})
+			if results[i].Err == nil && todo != nil {
+				results[i].Todo = todo
+				results[i].ID = uint(todo.ID)
+			}
+		}
+		return results
+	}
+
+	failed := -1
+	err := s.repo.Transaction(func(repo repository.TodoRepository) error {
+		for i, op := range ops {
+			todo, err := applyBulkOperation(repo, s.reminders, s.cache, by, op)
+			if err != nil {
+				failed = i
+				return err
+			}
+			results[i].Todo = todo
+			if todo != nil {
+				results[i].ID = uint(todo.ID)
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		return results
+	}
+
+	for i := range results {
+		results[i].Todo = nil
+		switch {
+		case failed < 0:
+			// The commit itself failed, so nothing was applied.
+			results[i].Err = err
+		case i < failed:
+			results[i].Err = ErrBulkRolledBack
+		case i == failed:
+			results[i].Err = err
+		default:
+			results[i].Err = ErrBulkSkipped
+		}
+	}
+	return results
+}
+
+func applyBulkOperation(repo repository.TodoRepository, queue *ReminderQueue, cache redis.IRedis, actor string, op BulkOperation) (*model.Todo, error) {
+	switch op.Op {
+	case BulkOpCreate:
+		if op.Todo == nil || op.Todo.Name == "" || op.Todo.Description == "" {
+			return nil, fmt.Errorf("%w: name and description are required", ErrInvalidTodo)
+		}
+		if op.Todo.Status != "" && !model.IsValidTodoStatus(op.Todo.Status) {
+			return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidTodo, op.Todo.Status)
+		}
+		if err := createTodo(repo, cache, actor, op.Todo); err != nil {
+			return nil, err
+		}
+		return op.Todo, nil
+	case BulkOpUpdate, BulkOpSetStatus:
+		if op.Op == BulkOpSetStatus {
+			if _, ok := op.Changes["status"]; !ok || len(op.Changes) != 1 {
+				return nil, fmt.Errorf("%w: set_status only accepts a status", ErrInvalidTodo)
+			}
+		}
+		return patchTodo(repo, queue, cache, actor, op.ID, op.Version, op.Changes)
+	case BulkOpDelete:
+		return nil, deleteTodo(repo, actor, op.ID)
+	default:
+		return nil, fmt.Errorf("%w: unknown operation %q", ErrInvalidTodo, op.Op)
+	}
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"todo_project/model"
+	"todo_project/repository"
+)
+
+// todoEvent is the JSON body of a todo event, as stored in the outbox and
+// POSTed to webhooks.
+type todoEvent struct {
+	ID         string             `json:"id"`
+	Event      string             `json:"event"`
+	OccurredAt time.Time          `json:"occurred_at"`
+	Actor      string             `json:"actor"`
+	Todo       *model.Todo        `json:"todo"`
+	Changes    model.FieldChanges `json:"changes,omitempty"`
+}
+
+// todoEvents names the events a history entry stands for. Completing a todo
+// is both an update and a completion.
+func todoEvents(entry *model.TodoHistory, before *model.Todo, after *model.Todo) []string {
+	switch entry.Action {
+	case model.HistoryActionCreated:
+		return []string{model.EventTodoCreated}
+	case model.HistoryActionDeleted:
+		return []string{model.EventTodoDeleted}
+	case model.HistoryActionPurged, model.HistoryActionRepositioned:
+		return nil
+	}
+	events := []string{model.EventTodoUpdated}
+	if before != nil && before.Status != model.TodoStatusDone && after.Status == model.TodoStatusDone {
+		events = append(events, model.EventTodoCompleted)
+	}
+	return events
+}
+
+// todoOwner returns the owner of a changed todo. The stored copy is used
+// when there is one, as after may be built from a request.
+func todoOwner(before *model.Todo, after *model.Todo) string {
+	if before != nil {
+		return before.Owner
+	}
+	return after.Owner
+}
+
+// publishEvents writes the events of a history entry to the outbox and
+// queues them for subscribed webhooks. It runs in the transaction of the
+// change, so events exist exactly when the change was committed. Event IDs
+// derive from the history entry and stay the same however often an event is
+// sent.
+func publishEvents(repo repository.TodoRepository, entry *model.TodoHistory, before *model.Todo, after *model.Todo) error {
+	now := time.Now()
+	for _, name := range todoEvents(entry, before, after) {
+		event := todoEvent{
+			ID:         fmt.Sprintf("%d-%s", entry.ID, name),
+			Event:      name,
+			OccurredAt: now,
+			Actor:      entry.Actor,
+			Todo:       after,
+			Changes:    entry.Changes,
+		}
+		body, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		err = repo.Outbox().Create(&model.OutboxEvent{
+			EventID:     event.ID,
+			Type:        name,
+			AggregateID: after.ID,
+			Payload:     string(body),
+		})
+		if err != nil {
+			return err
+		}
+		if err := queueWebhooks(repo, todoOwner(before, after), event, body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,261 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"todo_project/api/todocache"
+	"todo_project/common/actor"
+	"todo_project/common/rank"
+	"todo_project/internal/redis"
+	"todo_project/model"
+	"todo_project/repository"
+
+	"gorm.io/gorm"
+)
+
+// MaxPositionLength is the position length above which a list is respaced by
+// RebalancePositions.
+var MaxPositionLength = 12
+
+var ErrInvalidMove = errors.New("invalid move")
+
+// MoveTodo places a todo right after target, or right before it when after is
+// false. The todo joins target's list and only its own position is
+// rewritten. version is the version the caller expects the todo to be at,
+// zero meaning the current one.
+func (s *todoService) MoveTodo(ctx context.Context, id uint, version int, target uint, after bool) (*model.Todo, error) {
+	if id == target {
+		return nil, fmt.Errorf("%w: a todo cannot be moved next to itself", ErrInvalidMove)
+	}
+
+	var todo *model.Todo
+	err := s.repo.Transaction(func(repo repository.TodoRepository) error {
+		before, err := repo.FindByID(id)
+		if err != nil {
+			return err
+		}
+		if version == 0 {
+			version = before.Version
+		} else if version != before.Version {
+			return ErrVersionConflict
+		}
+		anchor, err := repo.FindByID(target)
+		if err != nil {
+			return fmt.Errorf("%w: target todo %d not found", ErrInvalidMove, target)
+		}
+
+		position, err := positionNextTo(repo, anchor, after, before.ID)
+		if errors.Is(err, rank.ErrInvalidRange) {
+			// Missing or duplicate keys around the target: respace its list
+			// and try again.
+			if err := rebalanceList(repo, s.cache, actor.FromContext(ctx), anchor.List()); err != nil {
+				return err
+			}
+			if anchor, err = repo.FindByID(target); err != nil {
+				return err
+			}
+			// The moved todo may have been respaced along with the list.
+			if before, err = repo.FindByID(id); err != nil {
+				return err
+			}
+			version = before.Version
+			position, err = positionNextTo(repo, anchor, after, before.ID)
+		}
+		if err != nil {
+			return err
+		}
+
+		moved := *before
+		moved.ProjectID, moved.ParentID = anchor.ProjectID, anchor.ParentID
+		if err := validateList(repo, &moved); err != nil {
+			if errors.Is(err, ErrInvalidTodo) {
+				return fmt.Errorf("%w: %v", ErrInvalidMove, err)
+			}
+			return err
+		}
+
+		err = repo.UpdateColumns(id, version, map[string]interface{}{
+			"position":   position,
+			"project_id": anchor.ProjectID,
+			"parent_id":  anchor.ParentID,
+		})
+		if err != nil {
+			return err
+		}
+		todo, err = repo.FindByID(id)
+		if err != nil {
+			return err
+		}
+		if entersColumn(before, todo) {
+			if err := checkWIPLimit(repo, todo); err != nil {
+				return err
+			}
+		}
+		return recordHistory(repo, actor.FromContext(ctx), model.HistoryActionMoved, before, todo)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
+
+// RebalancePositions respaces every list holding a position longer than
+// MaxPositionLength, or todos without a position, and returns the number of
+// lists rewritten.
+func (s *todoService) RebalancePositions() (int, error) {
+	lists, err := s.repo.FindListsToRebalance(MaxPositionLength)
+	if err != nil {
+		return 0, err
+	}
+	for _, list := range lists {
+		err := s.repo.Transaction(func(repo repository.TodoRepository) error {
+			return rebalanceList(repo, s.cache, actor.System, list)
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(lists), nil
+}
+
+// positionNextTo returns a position between anchor and its neighbour on the
+// requested side, ignoring the todo being moved.
+func positionNextTo(repo repository.TodoRepository, anchor *model.Todo, after bool, movingID int) (string, error) {
+	neighbour, err := repo.FindAdjacent(anchor, after, movingID)
+	if err != nil {
+		return "", err
+	}
+	if anchor.Position == "" {
+		return "", rank.ErrInvalidRange
+	}
+	if after {
+		if neighbour == nil {
+			return rank.After(anchor.Position)
+		}
+		return rank.Between(anchor.Position, neighbour.Position)
+	}
+	if neighbour == nil {
+		return rank.Between("", anchor.Position)
+	}
+	return rank.Between(neighbour.Position, anchor.Position)
+}
+
+// rebalanceList gives the todos of list evenly spaced positions, keeping
+// their order. Todos without a position go last, oldest first. Every todo
+// whose position changed moves to its next version, with a history entry so
+// that sync clients pick up the new position, and is evicted from cache.
+func rebalanceList(repo repository.TodoRepository, cache redis.IRedis, actor string, list model.TodoList) error {
+	todos, err := repo.FindByList(list)
+	if err != nil {
+		return err
+	}
+	ordered := make([]*model.Todo, 0, len(todos))
+	var unplaced []*model.Todo
+	for _, todo := range todos {
+		if todo.Position == "" {
+			unplaced = append(unplaced, todo)
+		} else {
+			ordered = append(ordered, todo)
+		}
+	}
+	ordered = append(ordered, unplaced...)
+
+	keys := rank.Spread(len(ordered))
+	positions := make(map[int]string, len(ordered))
+	var moved []*model.Todo
+	for i, todo := range ordered {
+		if todo.Position != keys[i] {
+			positions[todo.ID] = keys[i]
+			moved = append(moved, todo)
+		}
+	}
+	if len(positions) == 0 {
+		return nil
+	}
+	if err := repo.SetPositions(positions); err != nil {
+		return err
+	}
+	for _, before := range moved {
+		after := *before
+		after.Position = positions[before.ID]
+		after.Version++
+		if err := recordHistory(repo, actor, model.HistoryActionRepositioned, before, &after); err != nil {
+			return err
+		}
+		todocache.Evict(cache, before.ID)
+	}
+	return nil
+}
+
+// appendPosition places a new todo at the end of its list.
+func appendPosition(repo repository.TodoRepository, cache redis.IRedis, actor string, todo *model.Todo) error {
+	last, err := repo.LastPosition(todo.List())
+	if err != nil {
+		return err
+	}
+	position, err := rank.After(last)
+	if errors.Is(err, rank.ErrInvalidRange) {
+		// A malformed key ends the list: respace it and try again.
+		if err := rebalanceList(repo, cache, actor, todo.List()); err != nil {
+			return err
+		}
+		if last, err = repo.LastPosition(todo.List()); err != nil {
+			return err
+		}
+		position, err = rank.After(last)
+	}
+	if err != nil {
+		return err
+	}
+	todo.Position = position
+	return nil
+}
+
+// maxNesting bounds the walk up the parents of a todo in validateList.
+const maxNesting = 100
+
+// validateList checks the list a todo is placed in. Its parent must be an
+// existing todo of the same project that is not the todo itself or one of
+// its subtasks; a subtask without a project joins its parent's. Projects have
+// no table of their own, so a project only needs a positive ID.
+func validateList(repo repository.TodoRepository, todo *model.Todo) error {
+	if todo.ProjectID != nil && *todo.ProjectID <= 0 {
+		return fmt.Errorf("%w: project_id must be positive", ErrInvalidTodo)
+	}
+	if todo.ParentID == nil {
+		return nil
+	}
+
+	parent, err := repo.FindByID(uint(*todo.ParentID))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("%w: parent todo %d not found", ErrInvalidTodo, *todo.ParentID)
+	}
+	if err != nil {
+		return err
+	}
+	if todo.ProjectID == nil {
+		todo.ProjectID = parent.ProjectID
+	} else if parent.ProjectID == nil || *parent.ProjectID != *todo.ProjectID {
+		return fmt.Errorf("%w: a subtask must be in the project of its parent", ErrInvalidTodo)
+	}
+
+	if todo.ID == 0 {
+		return nil
+	}
+	for depth := 0; parent != nil; depth++ {
+		if parent.ID == todo.ID {
+			return fmt.Errorf("%w: a todo cannot be nested under itself", ErrInvalidTodo)
+		}
+		if parent.ParentID == nil || depth == maxNesting {
+			return nil
+		}
+		if parent, err = repo.FindByID(uint(*parent.ParentID)); errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+	return nil
+}
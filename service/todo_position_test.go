@@ -0,0 +1,294 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"todo_project/api/todocache"
+	"todo_project/common/actor"
+	"todo_project/internal/redis"
+	"todo_project/model"
+	"todo_project/repository"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// Fake repository holding todos and their history in memory; only the
+// methods the position helpers call are implemented.
+type fakePositionRepo struct {
+	repository.TodoRepository
+	todos   map[int]*model.Todo
+	history []*model.TodoHistory
+}
+
+func newFakePositionRepo(todos ...*model.Todo) *fakePositionRepo {
+	repo := &fakePositionRepo{todos: map[int]*model.Todo{}}
+	for _, todo := range todos {
+		repo.todos[todo.ID] = todo
+	}
+	return repo
+}
+
+func (r *fakePositionRepo) FindByID(id uint) (*model.Todo, error) {
+	todo, ok := r.todos[int(id)]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *todo
+	return &copied, nil
+}
+
+func (r *fakePositionRepo) FindByList(list model.TodoList) ([]*model.Todo, error) {
+	var todos []*model.Todo
+	for _, todo := range r.todos {
+		if sameID(todo.ProjectID, list.ProjectID) && sameID(todo.ParentID, list.ParentID) {
+			copied := *todo
+			todos = append(todos, &copied)
+		}
+	}
+	sort.Slice(todos, func(i, j int) bool {
+		if todos[i].Position != todos[j].Position {
+			return todos[i].Position < todos[j].Position
+		}
+		return todos[i].ID < todos[j].ID
+	})
+	return todos, nil
+}
+
+func (r *fakePositionRepo) FindAdjacent(todo *model.Todo, after bool, excludeID int) (*model.Todo, error) {
+	todos, _ := r.FindByList(todo.List())
+	var adjacent *model.Todo
+	for _, other := range todos {
+		if other.ID == excludeID || other.ID == todo.ID {
+			continue
+		}
+		precedes := other.Position < todo.Position || (other.Position == todo.Position && other.ID < todo.ID)
+		if after && !precedes {
+			return other, nil
+		}
+		if !after && precedes {
+			adjacent = other
+		}
+	}
+	return adjacent, nil
+}
+
+func (r *fakePositionRepo) LastPosition(list model.TodoList) (string, error) {
+	todos, _ := r.FindByList(list)
+	if len(todos) == 0 {
+		return "", nil
+	}
+	return todos[len(todos)-1].Position, nil
+}
+
+func (r *fakePositionRepo) SetPositions(positions map[int]string) error {
+	for id, position := range positions {
+		r.todos[id].Position = position
+		r.todos[id].Version++
+	}
+	return nil
+}
+
+func (r *fakePositionRepo) History() repository.TodoHistoryRepository {
+	return fakeHistory{repo: r}
+}
+
+type fakeHistory struct {
+	repository.TodoHistoryRepository
+	repo *fakePositionRepo
+}
+
+func (h fakeHistory) Create(entry *model.TodoHistory) error {
+	entry.ID = len(h.repo.history) + 1
+	h.repo.history = append(h.repo.history, entry)
+	return nil
+}
+
+func (h fakeHistory) FindByTodoID(todoID uint) ([]*model.TodoHistory, error) {
+	var entries []*model.TodoHistory
+	for _, entry := range h.repo.history {
+		if entry.TodoID == int(todoID) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+func (h fakeHistory) FindByVersion(todoID uint, version int) (*model.TodoHistory, error) {
+	for _, entry := range h.repo.history {
+		if entry.TodoID == int(todoID) && entry.Version == version {
+			return entry, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakePositionRepo) positions(list model.TodoList) []int {
+	todos, _ := r.FindByList(list)
+	ids := make([]int, len(todos))
+	for i, todo := range todos {
+		ids[i] = todo.ID
+	}
+	return ids
+}
+
+func intPtr(n int) *int {
+	return &n
+}
+
+// Fake Redis recording the keys deleted from it.
+type fakeCache struct {
+	redis.IRedis
+	deleted []string
+}
+
+func (f *fakeCache) Delete(key string) (int64, error) {
+	f.deleted = append(f.deleted, key)
+	return 1, nil
+}
+
+func TestRebalanceList(t *testing.T) {
+	project := intPtr(1)
+	repo := newFakePositionRepo(
+		&model.Todo{ID: 1, ProjectID: project, Position: "i0000000000001"},
+		&model.Todo{ID: 2, ProjectID: project, Position: "a"},
+		&model.Todo{ID: 3, ProjectID: project},
+		&model.Todo{ID: 4, ProjectID: project, Position: "b"},
+		&model.Todo{ID: 5, ProjectID: intPtr(2), Position: "zzzzzzzzzzzzzzz"},
+	)
+	list := model.TodoList{ProjectID: project}
+	cache := &fakeCache{}
+
+	assert.NoError(t, rebalanceList(repo, cache, "system", list))
+
+	// Order is kept and unplaced todos go last.
+	assert.Equal(t, []int{2, 4, 1, 3}, repo.positions(list))
+	for _, id := range []int{1, 2, 3, 4} {
+		assert.Len(t, repo.todos[id].Position, 1)
+	}
+	assert.Equal(t, "zzzzzzzzzzzzzzz", repo.todos[5].Position)
+
+	// Sync clients see every todo given a new position.
+	var repositioned []int
+	for _, entry := range repo.history {
+		assert.Equal(t, model.HistoryActionRepositioned, entry.Action)
+		assert.Equal(t, "system", entry.Actor)
+		assert.Equal(t, 1, entry.Version)
+		repositioned = append(repositioned, entry.TodoID)
+	}
+	sort.Ints(repositioned)
+	assert.Equal(t, []int{1, 2, 3, 4}, repositioned)
+
+	// Their version moves on and cached copies are dropped.
+	for _, id := range []int{1, 2, 3, 4} {
+		assert.Equal(t, 1, repo.todos[id].Version)
+		assert.Contains(t, cache.deleted, todocache.TodoKey(id))
+	}
+	assert.Equal(t, 0, repo.todos[5].Version)
+	assert.NotContains(t, cache.deleted, todocache.TodoKey(5))
+
+	// Respacing an evenly spaced list changes nothing.
+	repo.history = nil
+	assert.NoError(t, rebalanceList(repo, nil, "system", list))
+	assert.Empty(t, repo.history)
+}
+
+func TestMoveTodoRespacingList(t *testing.T) {
+	project := intPtr(1)
+	setup := func() (*fakeSyncRepo, TodoService) {
+		repo := newFakeSyncRepo()
+		for _, todo := range []*model.Todo{
+			{ID: 1, ProjectID: project, Position: "a", Version: 1},
+			{ID: 2, ProjectID: project, Version: 1},
+			{ID: 3, ProjectID: project, Position: "b", Version: 1},
+		} {
+			repo.todos[todo.ID] = todo
+		}
+		return repo, NewTodoService(repo, nil)
+	}
+	ctx := actor.NewContext(context.Background(), "alice")
+
+	t.Run("checks the version read before respacing", func(t *testing.T) {
+		repo, s := setup()
+
+		// Todo 2 has no position, so its list is respaced first, giving
+		// todo 3 a new version along the way.
+		todo, err := s.MoveTodo(ctx, 3, 1, 2, true)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 3, todo.Version)
+		assert.Equal(t, []int{1, 2, 3}, repo.positions(model.TodoList{ProjectID: project}))
+	})
+
+	t.Run("stale version", func(t *testing.T) {
+		repo, s := setup()
+
+		_, err := s.MoveTodo(ctx, 3, 2, 2, true)
+
+		assert.ErrorIs(t, err, ErrVersionConflict)
+		assert.Empty(t, repo.history)
+	})
+}
+
+func TestAppendPosition(t *testing.T) {
+	t.Run("after the last todo", func(t *testing.T) {
+		repo := newFakePositionRepo(&model.Todo{ID: 1, Position: "i"})
+		todo := &model.Todo{}
+
+		assert.NoError(t, appendPosition(repo, nil, "alice", todo))
+		assert.Greater(t, todo.Position, "i")
+	})
+
+	t.Run("empty list", func(t *testing.T) {
+		todo := &model.Todo{}
+
+		assert.NoError(t, appendPosition(newFakePositionRepo(), nil, "alice", todo))
+		assert.Equal(t, "i", todo.Position)
+	})
+
+	t.Run("malformed last key", func(t *testing.T) {
+		repo := newFakePositionRepo(&model.Todo{ID: 1, Position: "a"}, &model.Todo{ID: 2, Position: "z0"})
+		todo := &model.Todo{}
+
+		assert.NoError(t, appendPosition(repo, nil, "alice", todo))
+		assert.Greater(t, todo.Position, repo.todos[2].Position)
+		assert.Less(t, repo.todos[1].Position, repo.todos[2].Position)
+	})
+}
+
+func TestValidateList(t *testing.T) {
+	repo := newFakePositionRepo(
+		&model.Todo{ID: 1, ProjectID: intPtr(1)},
+		&model.Todo{ID: 2, ProjectID: intPtr(1), ParentID: intPtr(1)},
+		&model.Todo{ID: 3, ProjectID: intPtr(1), ParentID: intPtr(2)},
+	)
+
+	t.Run("subtask joins its parent's project", func(t *testing.T) {
+		todo := &model.Todo{ParentID: intPtr(1)}
+
+		assert.NoError(t, validateList(repo, todo))
+		assert.Equal(t, intPtr(1), todo.ProjectID)
+	})
+
+	tests := []struct {
+		name string
+		todo *model.Todo
+		want string
+	}{
+		{name: "unknown parent", todo: &model.Todo{ParentID: intPtr(9)}, want: "parent todo 9 not found"},
+		{name: "other project", todo: &model.Todo{ProjectID: intPtr(2), ParentID: intPtr(1)}, want: "project of its parent"},
+		{name: "invalid project", todo: &model.Todo{ProjectID: intPtr(0)}, want: "project_id must be positive"},
+		{name: "own parent", todo: &model.Todo{ID: 1, ProjectID: intPtr(1), ParentID: intPtr(1)}, want: "nested under itself"},
+		{name: "under a subtask", todo: &model.Todo{ID: 1, ProjectID: intPtr(1), ParentID: intPtr(3)}, want: "nested under itself"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateList(repo, tt.todo)
+
+			assert.ErrorIs(t, err, ErrInvalidTodo)
+			assert.ErrorContains(t, err, tt.want)
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"todo_project/common/rrule"
+	"todo_project/internal/redis"
+	"todo_project/model"
+	"todo_project/repository"
+
+	"gorm.io/gorm"
+)
+
+// PreviewOccurrences returns the due dates of the next n occurrences of a
+// recurring todo after its own, in the todo's time zone.
+func (s *todoService) PreviewOccurrences(id uint, n int) ([]time.Time, error) {
+	todo, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if todo.Recurrence == "" || todo.DueAt == nil {
+		return nil, ErrNotRecurring
+	}
+	rule, err := rrule.Parse(todo.Recurrence)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTodo, err)
+	}
+	return rule.Upcoming(todo.DueAt.In(todo.Location()), todo.Occurrence, n), nil
+}
+
+// validateSchedule checks the recurrence rule and time zone of a todo. A
+// recurring todo needs a due date to count occurrences from.
+func validateSchedule(todo *model.Todo) error {
+	if todo.Timezone != "" {
+		if _, err := time.LoadLocation(todo.Timezone); err != nil {
+			return fmt.Errorf("%w: unknown timezone %q", ErrInvalidTodo, todo.Timezone)
+		}
+	}
+	if todo.Recurrence == "" {
+		return nil
+	}
+	if _, err := rrule.Parse(todo.Recurrence); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidTodo, err)
+	}
+	if todo.DueAt == nil {
+		return fmt.Errorf("%w: a recurring todo needs a due_at", ErrInvalidTodo)
+	}
+	return nil
+}
+
+// createNextOccurrence creates the occurrence following a completed recurring
+// todo, unless the series has ended or that occurrence already exists, e.g.
+// because the todo was reopened and completed again.
+func createNextOccurrence(repo repository.TodoRepository, cache redis.IRedis, actor string, done *model.Todo) error {
+	if done.Recurrence == "" || done.DueAt == nil {
+		return nil
+	}
+	rule, err := rrule.Parse(done.Recurrence)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidTodo, err)
+	}
+	due, ok := rule.Next(done.DueAt.In(done.Location()), done.Occurrence)
+	if !ok {
+		return nil
+	}
+
+	series := done.Series()
+	_, err = repo.FindOccurrence(series, done.Occurrence+1)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	next := &model.Todo{
+		Name:        done.Name,
+		Description: done.Description,
+		Status:      model.TodoStatusTodo,
+		Owner:       done.Owner,
+		ProjectID:   done.ProjectID,
+		ParentID:    done.ParentID,
+		DueAt:       &due,
+		Recurrence:  done.Recurrence,
+		Timezone:    done.Timezone,
+		SeriesID:    &series,
+		Occurrence:  done.Occurrence + 1,
+	}
+	if err := createTodo(repo, cache, actor, next); err != nil {
+		return err
+	}
+	return copyReminders(repo, done, next)
+}
@@ -1,28 +1,80 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"todo_project/common/actor"
+	"todo_project/internal/redis"
 	"todo_project/model"
 	"todo_project/repository"
+
+	"gorm.io/gorm"
 )
 
 type TodoService interface {
-	CreateTodo(todo *model.Todo) error
+	CreateTodo(ctx context.Context, todo *model.Todo) error
 	GetTodoByID(id uint) (*model.Todo, error)
 	GetAllTodos() ([]*model.Todo, error)
-	UpdateTodo(todo *model.Todo) error
-	DeleteTodo(id uint) error
+	ListTodos(query model.TodoQuery) ([]*model.Todo, error)
+	UpdateTodo(ctx context.Context, todo *model.Todo) error
+	PatchTodo(ctx context.Context, id uint, version int, changes map[string]interface{}) (*model.Todo, error)
+	DeleteTodo(ctx context.Context, id uint) error
+	BulkApply(ctx context.Context, ops []BulkOperation, atomic bool) []BulkResult
+	GetTrash() ([]*model.Todo, error)
+	RestoreTodo(ctx context.Context, id uint) (*model.Todo, error)
+	PurgeTodo(ctx context.Context, id uint) error
+	PurgeTrash(olderThan time.Duration) (int64, error)
+	GetTodoHistory(id uint) ([]*model.TodoHistory, error)
+	RevertTodo(ctx context.Context, id uint, version int, target int) (*model.Todo, error)
+	SearchTodos(search model.TodoSearch, query model.TodoQuery) ([]*model.TodoSearchResult, int64, error)
+	SuggestTodoNames(search model.TodoSearch, limit int) ([]string, error)
+	AutocompleteTodoNames(prefix string, limit int) ([]string, error)
+	MoveTodo(ctx context.Context, id uint, version int, target uint, after bool) (*model.Todo, error)
+	RebalancePositions() (int, error)
+	GetBoard(projectID int) (*Board, error)
+	PreviewOccurrences(id uint, n int) ([]time.Time, error)
+}
+
+var (
+	ErrInvalidTodo      = errors.New("invalid todo")
+	ErrVersionConflict  = repository.ErrVersionConflict
+	ErrRevisionNotFound = errors.New("revision not found")
+	ErrEmptySearch      = repository.ErrEmptySearch
+	ErrInvalidLanguage  = repository.ErrInvalidLanguage
+	ErrNotRecurring     = errors.New("todo does not recur")
+)
+
+// patchableColumns lists the columns PatchTodo is allowed to change.
+var patchableColumns = map[string]bool{
+	"name":        true,
+	"description": true,
+	"status":      true,
+	"due_at":      true,
+	"recurrence":  true,
+	"timezone":    true,
 }
 
 type todoService struct {
-	repo repository.TodoRepository
+	repo      repository.TodoRepository
+	reminders *ReminderQueue
+	cache     redis.IRedis
 }
 
-func NewTodoService(repo repository.TodoRepository) TodoService {
-	return &todoService{repo: repo}
+// NewTodoService returns the todo service. redisClient may be nil; it is
+// used to keep queued reminders in step with due date changes and to evict
+// the cached copies of todos respaced along with the one changed.
+func NewTodoService(repo repository.TodoRepository, redisClient redis.IRedis) TodoService {
+	return &todoService{repo: repo, reminders: NewReminderQueue(redisClient), cache: redisClient}
 }
 
-func (s *todoService) CreateTodo(todo *model.Todo) error {
-	return s.repo.Create(todo)
+func (s *todoService) CreateTodo(ctx context.Context, todo *model.Todo) error {
+	return s.repo.Transaction(func(repo repository.TodoRepository) error {
+		return createTodo(repo, s.cache, actor.FromContext(ctx), todo)
+	})
 }
 
 func (s *todoService) GetTodoByID(id uint) (*model.Todo, error) {
@@ -33,10 +85,345 @@ func (s *todoService) GetAllTodos() ([]*model.Todo, error) {
 	return s.repo.FindAll()
 }
 
-func (s *todoService) UpdateTodo(todo *model.Todo) error {
-	return s.repo.Update(todo)
+// ListTodos returns the todos matching query.
+func (s *todoService) ListTodos(query model.TodoQuery) ([]*model.Todo, error) {
+	return s.repo.FindByQuery(query)
+}
+
+func (s *todoService) UpdateTodo(ctx context.Context, todo *model.Todo) error {
+	return s.repo.Transaction(func(repo repository.TodoRepository) error {
+		before, err := repo.FindByID(uint(todo.ID))
+		if err != nil {
+			return err
+		}
+		if err := repo.Update(todo); err != nil {
+			return err
+		}
+		if entersColumn(before, todo) {
+			if err := checkWIPLimit(repo, todo); err != nil {
+				return err
+			}
+		}
+		by := actor.FromContext(ctx)
+		if err := recordHistory(repo, by, changeAction(before, todo), before, todo); err != nil {
+			return err
+		}
+		return followSchedule(repo, s.reminders, s.cache, by, before, todo)
+	})
+}
+
+func (s *todoService) PatchTodo(ctx context.Context, id uint, version int, changes map[string]interface{}) (*model.Todo, error) {
+	var todo *model.Todo
+	err := s.repo.Transaction(func(repo repository.TodoRepository) error {
+		var err error
+		todo, err = patchTodo(repo, s.reminders, s.cache, actor.FromContext(ctx), id, version, changes)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
+
+// validateChanges checks changes against the patchable columns. A due_at
+// given as an RFC 3339 string is replaced by its time, or nil when empty.
+func validateChanges(changes map[string]interface{}) error {
+	for column, value := range changes {
+		if !patchableColumns[column] {
+			return fmt.Errorf("%w: field %q cannot be changed", ErrInvalidTodo, column)
+		}
+		str, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%w: field %q must be a string", ErrInvalidTodo, column)
+		}
+		switch column {
+		case "name", "description":
+			if str == "" {
+				return fmt.Errorf("%w: field %q is required", ErrInvalidTodo, column)
+			}
+		case "status":
+			if !model.IsValidTodoStatus(str) {
+				return fmt.Errorf("%w: unknown status %q", ErrInvalidTodo, str)
+			}
+		case "due_at":
+			if str == "" {
+				changes[column] = nil
+				continue
+			}
+			due, err := time.Parse(time.RFC3339, str)
+			if err != nil {
+				return fmt.Errorf("%w: due_at must be an RFC 3339 timestamp", ErrInvalidTodo)
+			}
+			changes[column] = due
+		}
+	}
+	return nil
+}
+
+func (s *todoService) DeleteTodo(ctx context.Context, id uint) error {
+	return s.repo.Transaction(func(repo repository.TodoRepository) error {
+		return deleteTodo(repo, actor.FromContext(ctx), id)
+	})
+}
+
+func (s *todoService) GetTrash() ([]*model.Todo, error) {
+	return s.repo.FindDeleted()
+}
+
+func (s *todoService) RestoreTodo(ctx context.Context, id uint) (*model.Todo, error) {
+	var todo *model.Todo
+	err := s.repo.Transaction(func(repo repository.TodoRepository) error {
+		if err := repo.Restore(id); err != nil {
+			return err
+		}
+		var err error
+		todo, err = repo.FindByID(id)
+		if err != nil {
+			return err
+		}
+		if err := checkWIPLimit(repo, todo); err != nil {
+			return err
+		}
+		return recordHistory(repo, actor.FromContext(ctx), model.HistoryActionRestored, todo, todo)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
+
+func (s *todoService) PurgeTodo(ctx context.Context, id uint) error {
+	return s.repo.Transaction(func(repo repository.TodoRepository) error {
+		todo, err := repo.FindDeletedByID(id)
+		if err != nil {
+			return err
+		}
+		if err := repo.Purge(id); err != nil {
+			return err
+		}
+		return recordHistory(repo, actor.FromContext(ctx), model.HistoryActionPurged, todo, todo)
+	})
+}
+
+// PurgeTrash permanently deletes todos that have been in the trash for longer
+// than olderThan. Retention purges are not written to the todo history.
+func (s *todoService) PurgeTrash(olderThan time.Duration) (int64, error) {
+	return s.repo.PurgeDeletedBefore(time.Now().Add(-olderThan))
 }
 
-func (s *todoService) DeleteTodo(id uint) error {
-	return s.repo.Delete(id)
-}
\ No newline at end of file
+// GetTodoHistory returns every recorded change of a todo, oldest first. The
+// history outlives the todo, so it is also available for deleted todos. A todo
+// without history, created before it was recorded, has an empty one; an
+// unknown todo gives gorm.ErrRecordNotFound.
+func (s *todoService) GetTodoHistory(id uint) ([]*model.TodoHistory, error) {
+	entries, err := s.repo.History().FindByTodoID(id)
+	if err != nil || len(entries) > 0 {
+		return entries, err
+	}
+	todos, err := s.repo.FindByIDsUnscoped([]int{int(id)})
+	if err != nil {
+		return nil, err
+	}
+	if len(todos) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return entries, nil
+}
+
+// RevertTodo restores the tracked fields of a todo to the state they had at
+// version target. version is the version the caller expects the todo to be at.
+func (s *todoService) RevertTodo(ctx context.Context, id uint, version int, target int) (*model.Todo, error) {
+	var todo *model.Todo
+	err := s.repo.Transaction(func(repo repository.TodoRepository) error {
+		before, err := repo.FindByID(id)
+		if err != nil {
+			return err
+		}
+		if version != before.Version {
+			return ErrVersionConflict
+		}
+		revision, err := repo.History().FindByVersion(id, target)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrRevisionNotFound
+			}
+			return err
+		}
+
+		changes := map[string]interface{}{}
+		for column, change := range model.SnapshotOf(before).Diff(revision.Snapshot) {
+			changes[column] = change.New
+		}
+		if len(changes) == 0 {
+			todo = before
+			return nil
+		}
+		if err := repo.UpdateColumns(id, before.Version, changes); err != nil {
+			return err
+		}
+		todo, err = repo.FindByID(id)
+		if err != nil {
+			return err
+		}
+		if entersColumn(before, todo) {
+			if err := checkWIPLimit(repo, todo); err != nil {
+				return err
+			}
+		}
+		by := actor.FromContext(ctx)
+		if err := recordHistory(repo, by, model.HistoryActionReverted, before, todo); err != nil {
+			return err
+		}
+		return followSchedule(repo, s.reminders, s.cache, by, before, todo)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
+
+// SearchTodos runs a ranked full-text search over todo names and
+// descriptions, restricted and paged by query.
+func (s *todoService) SearchTodos(search model.TodoSearch, query model.TodoQuery) ([]*model.TodoSearchResult, int64, error) {
+	return s.repo.Search(search, query)
+}
+
+// SuggestTodoNames returns up to limit todo names that look like the search
+// text, for "did you mean" hints when a search finds nothing.
+func (s *todoService) SuggestTodoNames(search model.TodoSearch, limit int) ([]string, error) {
+	if strings.TrimSpace(search.Text) == "" {
+		return nil, ErrEmptySearch
+	}
+	return s.repo.SuggestNames(strings.TrimSpace(search.Text), search.Threshold, limit)
+}
+
+// AutocompleteTodoNames returns up to limit todo names starting with prefix.
+func (s *todoService) AutocompleteTodoNames(prefix string, limit int) ([]string, error) {
+	return s.repo.AutocompleteNames(prefix, limit)
+}
+
+func createTodo(repo repository.TodoRepository, cache redis.IRedis, actor string, todo *model.Todo) error {
+	if todo.Owner == "" {
+		todo.Owner = actor
+	}
+	if err := validateSchedule(todo); err != nil {
+		return err
+	}
+	if err := validateList(repo, todo); err != nil {
+		return err
+	}
+	if err := appendPosition(repo, cache, actor, todo); err != nil {
+		return err
+	}
+	if err := repo.Create(todo); err != nil {
+		return err
+	}
+	if err := checkWIPLimit(repo, todo); err != nil {
+		return err
+	}
+	return recordHistory(repo, actor, model.HistoryActionCreated, nil, todo)
+}
+
+// patchTodo applies changes to a todo at the given version. A zero version
+// means the caller has no copy to compare against and the current one is used.
+func patchTodo(repo repository.TodoRepository, queue *ReminderQueue, cache redis.IRedis, actor string, id uint, version int, changes map[string]interface{}) (*model.Todo, error) {
+	if err := validateChanges(changes); err != nil {
+		return nil, err
+	}
+	before, err := repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if len(changes) == 0 {
+		return before, nil
+	}
+	if version == 0 {
+		version = before.Version
+	}
+	if err := repo.UpdateColumns(id, version, changes); err != nil {
+		return nil, err
+	}
+	after, err := repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateSchedule(after); err != nil {
+		return nil, err
+	}
+	if entersColumn(before, after) {
+		if err := checkWIPLimit(repo, after); err != nil {
+			return nil, err
+		}
+	}
+	if err := recordHistory(repo, actor, changeAction(before, after), before, after); err != nil {
+		return nil, err
+	}
+	if err := followSchedule(repo, queue, cache, actor, before, after); err != nil {
+		return nil, err
+	}
+	return after, nil
+}
+
+// followSchedule moves the reminders of a todo whose due date changed and
+// creates the next occurrence of a recurring todo that was just completed.
+func followSchedule(repo repository.TodoRepository, queue *ReminderQueue, cache redis.IRedis, actor string, before *model.Todo, after *model.Todo) error {
+	if !sameTime(before.DueAt, after.DueAt) {
+		if err := rescheduleReminders(repo, queue, after); err != nil {
+			return err
+		}
+	}
+	if before.Status != model.TodoStatusDone && after.Status == model.TodoStatusDone {
+		if err := createNextOccurrence(repo, cache, actor, after); err != nil {
+			return err
+		}
+		// Placing the occurrence may have respaced the list of after.
+		current, err := repo.FindByID(uint(after.ID))
+		if err != nil {
+			return err
+		}
+		after.Position, after.Version = current.Position, current.Version
+	}
+	return nil
+}
+
+func deleteTodo(repo repository.TodoRepository, actor string, id uint) error {
+	before, err := repo.FindByID(id)
+	if err != nil {
+		return err
+	}
+	if err := repo.Delete(id); err != nil {
+		return err
+	}
+	return recordHistory(repo, actor, model.HistoryActionDeleted, before, before)
+}
+
+// changeAction tells a status transition apart from a plain edit.
+func changeAction(before *model.Todo, after *model.Todo) string {
+	if before.Status != after.Status {
+		return model.HistoryActionTransitioned
+	}
+	return model.HistoryActionUpdated
+}
+
+// recordHistory appends an entry for after to the todo history and publishes
+// the matching events. before is the state the change started from and
+// is nil for newly created todos.
+func recordHistory(repo repository.TodoRepository, actor string, action string, before *model.Todo, after *model.Todo) error {
+	var previous model.TodoSnapshot
+	if before != nil {
+		previous = model.SnapshotOf(before)
+	}
+	snapshot := model.SnapshotOf(after)
+	entry := &model.TodoHistory{
+		TodoID:   after.ID,
+		Version:  after.Version,
+		Action:   action,
+		Actor:    actor,
+		Changes:  previous.Diff(snapshot),
+		Snapshot: snapshot,
+	}
+	if err := repo.History().Create(entry); err != nil {
+		return err
+	}
+	return publishEvents(repo, entry, before, after)
+}
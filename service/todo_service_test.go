@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"todo_project/common/actor"
+	"todo_project/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduleHistory(t *testing.T) {
+	repo := newFakeSyncRepo()
+	s := NewTodoService(repo, nil)
+	ctx := actor.NewContext(context.Background(), "alice")
+	due := time.Date(2026, 10, 20, 9, 0, 0, 0, time.UTC)
+	todo := &model.Todo{Name: "Pay rent", Description: "Monthly", Status: model.TodoStatusTodo, DueAt: &due, Timezone: "Europe/Paris"}
+	assert.NoError(t, s.CreateTodo(ctx, todo))
+
+	changed, err := s.PatchTodo(ctx, uint(todo.ID), todo.Version, map[string]interface{}{
+		"due_at":   "2026-10-27T09:00:00Z",
+		"timezone": "UTC",
+	})
+	assert.NoError(t, err)
+
+	entry := repo.history[len(repo.history)-1]
+	assert.Equal(t, changed.Version, entry.Version)
+	assert.ElementsMatch(t, []string{"due_at", "timezone"}, keys(entry.Changes))
+	assert.True(t, due.Equal(*entry.Changes["due_at"].Old.(*time.Time)))
+
+	reverted, err := s.RevertTodo(ctx, uint(todo.ID), changed.Version, todo.Version)
+	assert.NoError(t, err)
+	assert.True(t, due.Equal(*reverted.DueAt))
+	assert.Equal(t, "Europe/Paris", reverted.Timezone)
+	assert.Equal(t, model.HistoryActionReverted, repo.history[len(repo.history)-1].Action)
+}
+
+func keys(changes model.FieldChanges) []string {
+	fields := make([]string, 0, len(changes))
+	for field := range changes {
+		fields = append(fields, field)
+	}
+	return fields
+}
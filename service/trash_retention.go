@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"todo_project/common/log"
+)
+
+// TrashRetentionJob periodically hard-deletes todos that stayed in the trash
+// longer than the retention period.
+type TrashRetentionJob struct {
+	todoService TodoService
+	retention   time.Duration
+	interval    time.Duration
+}
+
+func NewTrashRetentionJob(todoService TodoService, retention time.Duration, interval time.Duration) *TrashRetentionJob {
+	return &TrashRetentionJob{
+		todoService: todoService,
+		retention:   retention,
+		interval:    interval,
+	}
+}
+
+// Run purges the trash once immediately and then on every interval until ctx
+// is cancelled.
+func (j *TrashRetentionJob) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		j.purge()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (j *TrashRetentionJob) purge() {
+	count, err := j.todoService.PurgeTrash(j.retention)
+	if err != nil {
+		log.Errorf("Failed to purge trash: %v", err)
+		return
+	}
+	if count > 0 {
+		log.Infof("Purged %d todos deleted more than %s ago", count, j.retention)
+	}
+}
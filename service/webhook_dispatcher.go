@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"todo_project/common/log"
+)
+
+const webhookBatchSize = 100
+
+// WebhookDispatcher sends queued webhook deliveries. Every replica may run
+// one; claiming a delivery row makes sure each attempt is made once.
+type WebhookDispatcher struct {
+	webhookService WebhookService
+	interval       time.Duration
+}
+
+func NewWebhookDispatcher(webhookService WebhookService, interval time.Duration) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		webhookService: webhookService,
+		interval:       interval,
+	}
+}
+
+// Run sends due deliveries once immediately and then on every interval until
+// ctx is cancelled.
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		d.dispatch(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *WebhookDispatcher) dispatch(ctx context.Context) {
+	deliveries, err := d.webhookService.DueDeliveries(webhookBatchSize)
+	if err != nil {
+		log.Errorf("Failed to load due webhook deliveries: %v", err)
+		return
+	}
+	for _, delivery := range deliveries {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := d.webhookService.Deliver(ctx, delivery.ID); err != nil {
+			log.Errorf("Failed to deliver webhook delivery %d: %v", delivery.ID, err)
+		}
+	}
+}
@@ -0,0 +1,280 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"todo_project/common/actor"
+	"todo_project/internal/netguard"
+	"todo_project/internal/webhook"
+	"todo_project/model"
+	"todo_project/repository"
+
+	"gorm.io/gorm"
+)
+
+type WebhookService interface {
+	CreateWebhook(ctx context.Context, hook *model.Webhook) error
+	ListWebhooks(ctx context.Context) ([]*model.Webhook, error)
+	GetWebhook(ctx context.Context, id uint) (*model.Webhook, error)
+	UpdateWebhook(ctx context.Context, hook *model.Webhook) error
+	DeleteWebhook(ctx context.Context, id uint) error
+	GetDeliveries(ctx context.Context, webhookID uint, limit int, offset int) ([]*model.WebhookDelivery, error)
+	Redeliver(ctx context.Context, webhookID uint, deliveryID uint) (*model.WebhookDelivery, error)
+	DueDeliveries(limit int) ([]*model.WebhookDelivery, error)
+	Deliver(ctx context.Context, id uint) error
+}
+
+var ErrInvalidWebhook = errors.New("invalid webhook")
+
+var (
+	// WebhookMaxAttempts is how many sends are tried before a delivery is
+	// marked failed.
+	WebhookMaxAttempts = 8
+	// WebhookRetryBackoff is the wait after the first failed send; it doubles
+	// with every further attempt.
+	WebhookRetryBackoff = 30 * time.Second
+	// WebhookLease is how long a delivery may stay in flight before it is
+	// assumed abandoned and sent again.
+	WebhookLease = 5 * time.Minute
+)
+
+type webhookService struct {
+	repo   repository.TodoRepository
+	sender *webhook.Sender
+}
+
+func NewWebhookService(repo repository.TodoRepository, sender *webhook.Sender) WebhookService {
+	return &webhookService{repo: repo, sender: sender}
+}
+
+// CreateWebhook subscribes the caller to todo events. A secret is generated
+// when none is given; it is only ever returned by this call.
+func (s *webhookService) CreateWebhook(ctx context.Context, hook *model.Webhook) error {
+	if err := validateWebhook(hook); err != nil {
+		return err
+	}
+	if hook.Secret == "" {
+		secret, err := newWebhookSecret()
+		if err != nil {
+			return err
+		}
+		hook.Secret = secret
+	}
+	hook.ID = 0
+	hook.Owner = actor.FromContext(ctx)
+	return s.repo.Webhooks().Create(hook)
+}
+
+func (s *webhookService) ListWebhooks(ctx context.Context) ([]*model.Webhook, error) {
+	return s.repo.Webhooks().FindByOwner(actor.FromContext(ctx))
+}
+
+// GetWebhook returns a webhook of the caller. Webhooks of other users are
+// reported as not found.
+func (s *webhookService) GetWebhook(ctx context.Context, id uint) (*model.Webhook, error) {
+	hook, err := s.repo.Webhooks().FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if hook.Owner != actor.FromContext(ctx) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return hook, nil
+}
+
+// UpdateWebhook replaces the URL, events and active flag of a webhook. An
+// empty secret keeps the current one.
+func (s *webhookService) UpdateWebhook(ctx context.Context, hook *model.Webhook) error {
+	if err := validateWebhook(hook); err != nil {
+		return err
+	}
+	existing, err := s.GetWebhook(ctx, hook.ID)
+	if err != nil {
+		return err
+	}
+	if hook.Secret == "" {
+		hook.Secret = existing.Secret
+	}
+	hook.Owner = existing.Owner
+	hook.CreatedAt = existing.CreatedAt
+	return s.repo.Webhooks().Update(hook)
+}
+
+func (s *webhookService) DeleteWebhook(ctx context.Context, id uint) error {
+	if _, err := s.GetWebhook(ctx, id); err != nil {
+		return err
+	}
+	return s.repo.Webhooks().Delete(id)
+}
+
+func (s *webhookService) GetDeliveries(ctx context.Context, webhookID uint, limit int, offset int) ([]*model.WebhookDelivery, error) {
+	if _, err := s.GetWebhook(ctx, webhookID); err != nil {
+		return nil, err
+	}
+	return s.repo.Webhooks().FindDeliveries(webhookID, limit, offset)
+}
+
+// Redeliver queues the event of a past delivery to be sent again right away,
+// as a new delivery with the same payload and event ID.
+func (s *webhookService) Redeliver(ctx context.Context, webhookID uint, deliveryID uint) (*model.WebhookDelivery, error) {
+	if _, err := s.GetWebhook(ctx, webhookID); err != nil {
+		return nil, err
+	}
+	previous, err := s.repo.Webhooks().FindDelivery(deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if previous.WebhookID != webhookID {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	delivery := &model.WebhookDelivery{
+		WebhookID:     webhookID,
+		EventID:       previous.EventID,
+		Event:         previous.Event,
+		TodoID:        previous.TodoID,
+		Payload:       previous.Payload,
+		Status:        model.DeliveryStatusPending,
+		NextAttemptAt: time.Now(),
+	}
+	if err := s.repo.Webhooks().CreateDelivery(delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// DueDeliveries returns the deliveries to send now, including those whose
+// send was abandoned mid-way.
+func (s *webhookService) DueDeliveries(limit int) ([]*model.WebhookDelivery, error) {
+	now := time.Now()
+	return s.repo.Webhooks().FindDueDeliveries(now, now.Add(-WebhookLease), limit)
+}
+
+// Deliver claims a due delivery and sends it. It does nothing when the
+// delivery was claimed elsewhere. Failed sends are retried with exponential
+// backoff until WebhookMaxAttempts, and the outcome of every attempt is
+// recorded on the delivery.
+func (s *webhookService) Deliver(ctx context.Context, id uint) error {
+	webhooks := s.repo.Webhooks()
+	claimed, err := webhooks.ClaimDelivery(id, time.Now().Add(-WebhookLease))
+	if err != nil || !claimed {
+		return err
+	}
+	delivery, err := webhooks.FindDelivery(id)
+	if err != nil {
+		return err
+	}
+
+	hook, err := webhooks.FindByID(delivery.WebhookID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && !hook.Active) {
+		delivery.Status = model.DeliveryStatusFailed
+		delivery.LastError = "webhook was disabled"
+		return webhooks.SaveDelivery(delivery)
+	}
+	if err != nil {
+		// The claim is left to go stale so the send is tried again after
+		// WebhookLease.
+		return err
+	}
+
+	status, err := s.sender.Send(ctx, webhook.Request{
+		URL:        hook.URL,
+		Secret:     hook.Secret,
+		Event:      delivery.Event,
+		EventID:    delivery.EventID,
+		DeliveryID: delivery.ID,
+		Body:       []byte(delivery.Payload),
+	})
+	now := time.Now()
+	delivery.Attempts++
+	delivery.ResponseStatus = status
+	switch {
+	case err == nil:
+		delivery.Status = model.DeliveryStatusDelivered
+		delivery.DeliveredAt = &now
+		delivery.LastError = ""
+	case delivery.Attempts >= WebhookMaxAttempts:
+		delivery.Status = model.DeliveryStatusFailed
+		delivery.LastError = err.Error()
+	default:
+		delivery.Status = model.DeliveryStatusPending
+		delivery.NextAttemptAt = now.Add(WebhookRetryBackoff << (delivery.Attempts - 1))
+		delivery.LastError = err.Error()
+	}
+	return webhooks.SaveDelivery(delivery)
+}
+
+// validateWebhook checks the URL and normalises the event filter. URLs on
+// loopback, private or otherwise non-public addresses are refused; the
+// sender checks again on every connection.
+func validateWebhook(hook *model.Webhook) error {
+	if err := netguard.CheckURL(hook.URL); err != nil {
+		return fmt.Errorf("%w: url: %v", ErrInvalidWebhook, err)
+	}
+
+	var events []string
+	seen := make(map[string]bool)
+	for _, event := range strings.Split(hook.Events, ",") {
+		event = strings.TrimSpace(event)
+		if event == "" || seen[event] {
+			continue
+		}
+		if !isTodoEvent(event) {
+			return fmt.Errorf("%w: unknown event %q", ErrInvalidWebhook, event)
+		}
+		seen[event] = true
+		events = append(events, event)
+	}
+	hook.Events = strings.Join(events, ",")
+	return nil
+}
+
+func isTodoEvent(event string) bool {
+	for _, e := range model.TodoEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// queueWebhooks queues a delivery of event to each active webhook of owner,
+// the owner of the todo, subscribed to it. body is the encoded event.
+func queueWebhooks(repo repository.TodoRepository, owner string, event todoEvent, body []byte) error {
+	hooks, err := repo.Webhooks().FindActiveByOwner(owner)
+	if err != nil {
+		return err
+	}
+	for _, hook := range hooks {
+		if !hook.Subscribes(event.Event) {
+			continue
+		}
+		err := repo.Webhooks().CreateDelivery(&model.WebhookDelivery{
+			WebhookID:     hook.ID,
+			EventID:       event.ID,
+			Event:         event.Event,
+			TodoID:        event.Todo.ID,
+			Payload:       string(body),
+			Status:        model.DeliveryStatusPending,
+			NextAttemptAt: event.OccurredAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
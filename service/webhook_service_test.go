@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"todo_project/internal/webhook"
+	"todo_project/model"
+	"todo_project/repository"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// Fake repository holding webhooks and deliveries in memory; only the
+// methods the webhook service calls are implemented. ClaimDelivery follows
+// the SQL of the real one.
+type fakeWebhookRepo struct {
+	repository.TodoRepository
+	hooks      map[uint]*model.Webhook
+	deliveries map[uint]*model.WebhookDelivery
+	hookErr    error
+}
+
+func newFakeWebhookRepo(hooks ...*model.Webhook) *fakeWebhookRepo {
+	repo := &fakeWebhookRepo{hooks: map[uint]*model.Webhook{}, deliveries: map[uint]*model.WebhookDelivery{}}
+	for _, hook := range hooks {
+		repo.hooks[hook.ID] = hook
+	}
+	return repo
+}
+
+func (r *fakeWebhookRepo) Webhooks() repository.WebhookRepository {
+	return fakeWebhooks{repo: r}
+}
+
+type fakeWebhooks struct {
+	repository.WebhookRepository
+	repo *fakeWebhookRepo
+}
+
+func (f fakeWebhooks) FindByID(id uint) (*model.Webhook, error) {
+	if f.repo.hookErr != nil {
+		return nil, f.repo.hookErr
+	}
+	hook, ok := f.repo.hooks[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *hook
+	return &copied, nil
+}
+
+func (f fakeWebhooks) FindActiveByOwner(owner string) ([]*model.Webhook, error) {
+	var hooks []*model.Webhook
+	for id := uint(1); id <= uint(len(f.repo.hooks)); id++ {
+		if hook, ok := f.repo.hooks[id]; ok && hook.Owner == owner && hook.Active {
+			hooks = append(hooks, hook)
+		}
+	}
+	return hooks, nil
+}
+
+func (f fakeWebhooks) CreateDelivery(delivery *model.WebhookDelivery) error {
+	delivery.ID = uint(len(f.repo.deliveries) + 1)
+	delivery.UpdatedAt = time.Now()
+	copied := *delivery
+	f.repo.deliveries[delivery.ID] = &copied
+	return nil
+}
+
+func (f fakeWebhooks) FindDelivery(id uint) (*model.WebhookDelivery, error) {
+	delivery, ok := f.repo.deliveries[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *delivery
+	return &copied, nil
+}
+
+func (f fakeWebhooks) ClaimDelivery(id uint, staleBefore time.Time) (bool, error) {
+	delivery, ok := f.repo.deliveries[id]
+	if !ok {
+		return false, nil
+	}
+	pending := delivery.Status == model.DeliveryStatusPending
+	stale := delivery.Status == model.DeliveryStatusDelivering && delivery.UpdatedAt.Before(staleBefore)
+	if !pending && !stale {
+		return false, nil
+	}
+	delivery.Status = model.DeliveryStatusDelivering
+	delivery.UpdatedAt = time.Now()
+	return true, nil
+}
+
+func (f fakeWebhooks) SaveDelivery(delivery *model.WebhookDelivery) error {
+	if _, ok := f.repo.deliveries[delivery.ID]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	copied := *delivery
+	f.repo.deliveries[delivery.ID] = &copied
+	return nil
+}
+
+func TestWebhookDeliver(t *testing.T) {
+	status := http.StatusOK
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+	sender := &webhook.Sender{Client: server.Client()}
+
+	setup := func(active bool) (*fakeWebhookRepo, uint) {
+		calls = 0
+		repo := newFakeWebhookRepo(&model.Webhook{ID: 1, Owner: "alice", URL: server.URL, Secret: "s3cr3t", Active: active})
+		delivery := &model.WebhookDelivery{WebhookID: 1, Event: "todo.created", Payload: "{}", Status: model.DeliveryStatusPending}
+		_ = repo.Webhooks().CreateDelivery(delivery)
+		return repo, delivery.ID
+	}
+
+	t.Run("delivers", func(t *testing.T) {
+		status = http.StatusOK
+		repo, id := setup(true)
+
+		assert.NoError(t, NewWebhookService(repo, sender).Deliver(context.Background(), id))
+
+		delivery := repo.deliveries[id]
+		assert.Equal(t, model.DeliveryStatusDelivered, delivery.Status)
+		assert.Equal(t, http.StatusOK, delivery.ResponseStatus)
+		assert.Equal(t, 1, delivery.Attempts)
+	})
+
+	t.Run("retries with backoff until failed", func(t *testing.T) {
+		status = http.StatusInternalServerError
+		repo, id := setup(true)
+		s := NewWebhookService(repo, sender)
+
+		for attempt := 1; attempt < WebhookMaxAttempts; attempt++ {
+			start := time.Now()
+			assert.NoError(t, s.Deliver(context.Background(), id))
+
+			delivery := repo.deliveries[id]
+			assert.Equal(t, model.DeliveryStatusPending, delivery.Status)
+			assert.Equal(t, attempt, delivery.Attempts)
+			assert.Equal(t, http.StatusInternalServerError, delivery.ResponseStatus)
+			assert.WithinDuration(t, start.Add(WebhookRetryBackoff<<(attempt-1)), delivery.NextAttemptAt, time.Second)
+		}
+		assert.NoError(t, s.Deliver(context.Background(), id))
+
+		assert.Equal(t, WebhookMaxAttempts, calls)
+		assert.Equal(t, model.DeliveryStatusFailed, repo.deliveries[id].Status)
+	})
+
+	t.Run("fails for an inactive webhook", func(t *testing.T) {
+		repo, id := setup(false)
+
+		assert.NoError(t, NewWebhookService(repo, sender).Deliver(context.Background(), id))
+
+		assert.Equal(t, 0, calls)
+		assert.Equal(t, model.DeliveryStatusFailed, repo.deliveries[id].Status)
+	})
+
+	t.Run("fails for a deleted webhook", func(t *testing.T) {
+		repo, id := setup(true)
+		delete(repo.hooks, 1)
+
+		assert.NoError(t, NewWebhookService(repo, sender).Deliver(context.Background(), id))
+
+		assert.Equal(t, 0, calls)
+		assert.Equal(t, model.DeliveryStatusFailed, repo.deliveries[id].Status)
+	})
+
+	t.Run("kept when the webhook cannot be loaded", func(t *testing.T) {
+		status = http.StatusOK
+		repo, id := setup(true)
+		repo.hookErr = errors.New("connection reset")
+		s := NewWebhookService(repo, sender)
+
+		assert.EqualError(t, s.Deliver(context.Background(), id), "connection reset")
+		assert.Equal(t, 0, calls)
+		assert.Equal(t, model.DeliveryStatusDelivering, repo.deliveries[id].Status)
+
+		// Once the claim goes stale the send is tried again.
+		repo.hookErr = nil
+		repo.deliveries[id].UpdatedAt = time.Now().Add(-2 * WebhookLease)
+		assert.NoError(t, s.Deliver(context.Background(), id))
+		assert.Equal(t, model.DeliveryStatusDelivered, repo.deliveries[id].Status)
+	})
+}
+
+func TestQueueWebhooks(t *testing.T) {
+	repo := newFakeWebhookRepo(
+		&model.Webhook{ID: 1, Owner: "alice", Active: true},
+		&model.Webhook{ID: 2, Owner: "alice", Events: "todo.deleted", Active: true},
+		&model.Webhook{ID: 3, Owner: "alice", Active: false},
+		&model.Webhook{ID: 4, Owner: "bob", Active: true},
+	)
+	event := todoEvent{ID: "5-todo.created", Event: "todo.created", OccurredAt: time.Now(), Todo: &model.Todo{ID: 9, Owner: "alice"}}
+
+	assert.NoError(t, queueWebhooks(repo, "alice", event, []byte("{}")))
+
+	assert.Len(t, repo.deliveries, 1)
+	assert.Equal(t, uint(1), repo.deliveries[1].WebhookID)
+	assert.Equal(t, "5-todo.created", repo.deliveries[1].EventID)
+	assert.Equal(t, 9, repo.deliveries[1].TodoID)
+}
+
+func TestValidateWebhook(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		events  string
+		want    string
+		invalid bool
+	}{
+		{name: "public url", url: "https://93.184.216.34/hook", events: " todo.created,todo.created , todo.deleted", want: "todo.created,todo.deleted"},
+		{name: "loopback", url: "http://127.0.0.1:8080/hook", invalid: true},
+		{name: "metadata endpoint", url: "http://169.254.169.254/latest/meta-data", invalid: true},
+		{name: "localhost", url: "http://localhost/hook", invalid: true},
+		{name: "not http", url: "ftp://93.184.216.34/hook", invalid: true},
+		{name: "unknown event", url: "https://93.184.216.34/hook", events: "todo.exploded", invalid: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hook := &model.Webhook{URL: tt.url, Events: tt.events}
+
+			err := validateWebhook(hook)
+
+			if tt.invalid {
+				assert.ErrorIs(t, err, ErrInvalidWebhook)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, hook.Events)
+		})
+	}
+}